@@ -6,6 +6,8 @@ func (s *ServerRunOptions) Validate() []error {
 	var errors []error
 
 	errors = append(errors, s.AuthenticationOptions.Validate()...)
+	errors = append(errors, s.AuthorizationOptions.Validate()...)
+	errors = append(errors, s.TerminalOptions.Validate()...)
 
 	return errors
 }
@@ -8,11 +8,13 @@ import (
 	"aiscope/pkg/controller/globalrolebinding"
 	"aiscope/pkg/controller/group"
 	"aiscope/pkg/controller/groupbinding"
+	"aiscope/pkg/controller/groupsync"
 	"aiscope/pkg/controller/loginrecord"
 	"aiscope/pkg/informers"
 	"aiscope/pkg/simple/client/k8s"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"time"
 )
 
 func addControllers(mgr manager.Manager,
@@ -20,13 +22,14 @@ func addControllers(mgr manager.Manager,
 	informerFactory informers.InformerFactory,
 	authenticationOptions *authentication.Options,
 	kubectlImage string,
+	groupSyncPeriod time.Duration,
 	stopCh <-chan struct{}) error {
 	kubernetesInformer := informerFactory.KubernetesSharedInformerFactory()
 	aiscopeInformer := informerFactory.AIScopeSharedInformerFactory()
 
 	csrController := certificatesigningrequest.NewController(client.Kubernetes(),
 		kubernetesInformer.Certificates().V1().CertificateSigningRequests(),
-		kubernetesInformer.Core().V1().ConfigMaps(), client.Config())
+		kubernetesInformer.Core().V1().Secrets(), client.Config())
 
 	loginRecordController := loginrecord.NewLoginRecordController(
 		client.Kubernetes(),
@@ -34,7 +37,9 @@ func addControllers(mgr manager.Manager,
 		aiscopeInformer.Iam().V1alpha2().LoginRecords(),
 		aiscopeInformer.Iam().V1alpha2().Users(),
 		authenticationOptions.LoginHistoryRetentionPeriod,
-		authenticationOptions.LoginHistoryMaximumEntries)
+		authenticationOptions.LoginHistoryMaximumEntries,
+		authenticationOptions.AuthenticateRateLimiterMaxTries,
+		authenticationOptions.AuthenticateRateLimiterDuration)
 
 	clusterRoleBindingController := clusterrolebinding.NewController(client.Kubernetes(),
 		kubernetesInformer.Rbac().V1().ClusterRoleBindings(),
@@ -44,10 +49,12 @@ func addControllers(mgr manager.Manager,
 		kubectlImage)
 
 	globalRoleController := globalrole.NewController(client.Kubernetes(), client.AIScope(),
-		aiscopeInformer.Iam().V1alpha2().GlobalRoles())
+		aiscopeInformer.Iam().V1alpha2().GlobalRoles(),
+		kubernetesInformer.Rbac().V1().ClusterRoles())
 
 	globalRoleBindingController := globalrolebinding.NewController(client.Kubernetes(), client.AIScope(),
-		aiscopeInformer.Iam().V1alpha2().GlobalRoleBindings())
+		aiscopeInformer.Iam().V1alpha2().GlobalRoleBindings(),
+		kubernetesInformer.Rbac().V1().ClusterRoleBindings())
 
 	groupBindingController := groupbinding.NewController(client.Kubernetes(), client.AIScope(),
 		aiscopeInformer.Iam().V1alpha2().GroupBindings())
@@ -55,6 +62,12 @@ func addControllers(mgr manager.Manager,
 	groupController := group.NewController(client.Kubernetes(), client.AIScope(),
 		aiscopeInformer.Iam().V1alpha2().Groups())
 
+	var groupSyncController manager.Runnable
+	if len(authenticationOptions.OAuthOptions.IdentityProviders) > 0 {
+		groupSyncController = groupsync.NewController(client.AIScope(),
+			authenticationOptions.OAuthOptions.IdentityProviders, groupSyncPeriod)
+	}
+
 	controllers := map[string]manager.Runnable{
 		"csr-controller":                csrController,
 		"loginrecord-controller":        loginRecordController,
@@ -63,6 +76,7 @@ func addControllers(mgr manager.Manager,
 		"groupbinding-controller":       groupBindingController,
 		"group-controller":              groupController,
 		"globalrole-controller":         globalRoleController,
+		"groupsync-controller":          groupSyncController,
 	}
 
 	for name, ctrl := range controllers {
@@ -15,6 +15,8 @@ type AIScopeControllerManagerOptions struct {
 	LeaderElect           bool
 	LeaderElection        *leaderelection.LeaderElectionConfig
 	IngressController     string
+	// GroupSyncPeriod is the interval to sync the groups of identity providers
+	GroupSyncPeriod time.Duration
 }
 
 func NewAIScopeControllerManagerOptions() *AIScopeControllerManagerOptions {
@@ -29,6 +31,7 @@ func NewAIScopeControllerManagerOptions() *AIScopeControllerManagerOptions {
 		},
 		LeaderElect:         false,
 		IngressController:   "traefik", // nginx, traefik
+		GroupSyncPeriod:     10 * time.Minute,
 	}
 
 	return s
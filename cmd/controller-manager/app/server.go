@@ -3,7 +3,11 @@ package app
 import (
 	"aiscope/cmd/controller-manager/app/options"
 	"aiscope/pkg/apis"
+	"aiscope/pkg/apiserver/authentication/identityprovider"
+	apiserverconfig "aiscope/pkg/apiserver/config"
 	"aiscope/pkg/controller/namespace"
+	"aiscope/pkg/controller/codeserver"
+	"aiscope/pkg/controller/jupyternotebook"
 	"aiscope/pkg/controller/trackingserver"
 	"aiscope/pkg/controller/user"
 	"aiscope/pkg/controller/workspace"
@@ -29,6 +33,13 @@ func NewControllerManagerCommand() *cobra.Command {
 
 	s := options.NewAIScopeControllerManagerOptions()
 
+	// the identity providers are shared with the apiserver
+	if conf, err := apiserverconfig.TryLoadFromDisk(); err == nil {
+		s.AuthenticationOptions = conf.AuthenticationOptions
+	} else {
+		klog.Warningf("Failed to load configuration from disk: %v", err)
+	}
+
 	cmd := &cobra.Command{
 		Use: "controller-manager",
 		Long: `AIScope controller manager`,
@@ -66,6 +77,10 @@ func run(s *options.AIScopeControllerManagerOptions, ctx context.Context) error
 		klog.Warning("ks-controller-manager starts without ldap provided, it will not sync user into ldap")
 	}
 
+	if err = identityprovider.SetupWithOptions(s.AuthenticationOptions.OAuthOptions.IdentityProviders); err != nil {
+		return err
+	}
+
 	informerFactory := informers.NewInformerFactories(
 		kubernetesClient.Kubernetes(),
 		kubernetesClient.AIScope())
@@ -122,12 +137,13 @@ func run(s *options.AIScopeControllerManagerOptions, ctx context.Context) error
 	}
 
 	kubeconfigClient := kubeconfig.NewOperator(kubernetesClient.Kubernetes(),
-		informerFactory.KubernetesSharedInformerFactory().Core().V1().ConfigMaps().Lister(),
-		kubernetesClient.Config())
+		informerFactory.KubernetesSharedInformerFactory().Core().V1().Secrets().Lister(),
+		kubernetesClient.Config(), kubernetesClient.Master())
 	userController := user.Reconciler{
 		MaxConcurrentReconciles: 4,
 		KubeconfigClient:        kubeconfigClient,
 		LdapClient:              ldapClient,
+		AuthenticationOptions:   s.AuthenticationOptions,
 	}
 	if err = userController.SetupWithManager(mgr); err != nil {
 		klog.Fatalf("Unable to create user controller: %v", err)
@@ -138,11 +154,22 @@ func run(s *options.AIScopeControllerManagerOptions, ctx context.Context) error
 		klog.Fatalf("Unable to create trackingserver controller: %v", err)
 	}
 
+	jupyterNotebookReconciler := &jupyternotebook.JupyterNotebookReconciler{IngressController: s.IngressController, TraefikClient: kubernetesClient.Traefik()}
+	if err = jupyterNotebookReconciler.SetupWithManager(mgr); err != nil {
+		klog.Fatalf("Unable to create jupyternotebook controller: %v", err)
+	}
+
+	codeServerReconciler := &codeserver.CodeServerReconciler{IngressController: s.IngressController, TraefikClient: kubernetesClient.Traefik()}
+	if err = codeServerReconciler.SetupWithManager(mgr); err != nil {
+		klog.Fatalf("Unable to create codeserver controller: %v", err)
+	}
+
 	if err = addControllers(mgr,
 		kubernetesClient,
 		informerFactory,
 		s.AuthenticationOptions,
-		s.AuthenticationOptions.KubectlImage, ctx.Done()); err != nil {
+		s.AuthenticationOptions.KubectlImage,
+		s.GroupSyncPeriod, ctx.Done()); err != nil {
 		klog.Fatalf("unable to register controllers to the manager: %v", err)
 	}
 
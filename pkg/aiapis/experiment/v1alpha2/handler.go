@@ -121,3 +121,106 @@ func (h *handler) DeleteTrackingServer(request *restful.Request, response *restf
 
 	response.WriteEntity(servererr.None)
 }
+
+func (h *handler) CreateCodeServer(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	var codeserver *experimentv1alpha2.CodeServer
+	if err := request.ReadEntity(&codeserver); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	created, err := h.ep.CreateOrUpdateCodeServer(namespace, codeserver)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(created)
+}
+
+func (h *handler) UpdateCodeServer(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	codeserverName := request.PathParameter("codeserver")
+
+	var codeserver experimentv1alpha2.CodeServer
+	err := request.ReadEntity(&codeserver)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	if codeserverName != codeserver.Name {
+		err := fmt.Errorf("the name of the object (%s) does not match the name on the URL (%s)", codeserver.Name, codeserverName)
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	updated, err := h.ep.CreateOrUpdateCodeServer(namespace, &codeserver)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(updated)
+}
+
+func (h *handler) PatchCodeServer(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	codeserverName := request.PathParameter("codeserver")
+
+	var codeserver experimentv1alpha2.CodeServer
+	err := request.ReadEntity(&codeserver)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	codeserver.Name = codeserverName
+	patched, err := h.ep.PatchCodeServer(namespace, &codeserver)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(patched)
+}
+
+func (h *handler) ListCodeServer(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	queryParam := query.ParseQueryParameter(request)
+
+	result, err := h.ep.ListCodeServers(namespace, queryParam)
+	if err != nil {
+		api.HandleError(response, nil, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *handler) DescribeCodeServer(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	codeserverName := request.PathParameter("codeserver")
+
+	codeserver, err := h.ep.DescribeCodeServer(namespace, codeserverName)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(codeserver)
+}
+
+func (h *handler) DeleteCodeServer(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	codeserverName := request.PathParameter("codeserver")
+
+	err := h.ep.DeleteCodeServer(namespace, codeserverName)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
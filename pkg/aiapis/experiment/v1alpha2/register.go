@@ -63,6 +63,52 @@ func AddToContainer(container *restful.Container, ep model.Interface) error {
 		Returns(http.StatusOK, api.StatusOK, experimentv1alpha2.TrackingServer{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ExperimentTrackingServerTag}))
 
+	// codeservers
+	ws.Route(ws.POST("/namespaces/{namespace}/codeservers").
+		To(handler.CreateCodeServer).
+		Reads(experimentv1alpha2.CodeServer{}).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Doc("Create a codeserver in the specified namespace.").
+		Returns(http.StatusOK, api.StatusOK, experimentv1alpha2.CodeServer{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ExperimentCodeServerTag}))
+	ws.Route(ws.PUT("/namespaces/{namespace}/codeservers/{codeserver}").
+		To(handler.UpdateCodeServer).
+		Doc("Update codeserver in the specified namespace.").
+		Param(ws.PathParameter("namespace", "namespace")).
+		Param(ws.PathParameter("codeserver", "codeserver name")).
+		Reads(experimentv1alpha2.CodeServer{}).
+		Returns(http.StatusOK, api.StatusOK, experimentv1alpha2.CodeServer{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ExperimentCodeServerTag}))
+	ws.Route(ws.PATCH("/namespaces/{namespace}/codeservers/{codeserver}").
+		To(handler.PatchCodeServer).
+		Consumes(mimePatch...).
+		Doc("Update codeserver in the specified namespace.").
+		Param(ws.PathParameter("namespace", "namespace")).
+		Param(ws.PathParameter("codeserver", "codeserver name")).
+		Reads(experimentv1alpha2.CodeServer{}).
+		Returns(http.StatusOK, api.StatusOK, experimentv1alpha2.CodeServer{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ExperimentCodeServerTag}))
+	ws.Route(ws.GET("/namespaces/{namespace}/codeservers").
+		To(handler.ListCodeServer).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Doc("List the codeservers of the specified namespace for the current user").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ExperimentCodeServerTag}))
+	ws.Route(ws.GET("/namespaces/{namespace}/codeservers/{codeserver}").
+		To(handler.DescribeCodeServer).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Param(ws.PathParameter("codeserver", "codeserver name")).
+		Doc("Retrieve codeserver details.").
+		Returns(http.StatusOK, api.StatusOK, experimentv1alpha2.CodeServer{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ExperimentCodeServerTag}))
+	ws.Route(ws.DELETE("/namespaces/{namespace}/codeservers/{codeserver}").
+		To(handler.DeleteCodeServer).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Param(ws.PathParameter("codeserver", "codeserver name")).
+		Doc("Delete codeserver under namespace.").
+		Returns(http.StatusOK, api.StatusOK, experimentv1alpha2.CodeServer{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ExperimentCodeServerTag}))
+
 	container.Add(ws)
 	return nil
 }
@@ -0,0 +1,284 @@
+package v1alpha2
+
+import (
+	"aiscope/pkg/api"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	"aiscope/pkg/models/iam/group"
+	servererr "aiscope/pkg/server/errors"
+	"fmt"
+	"github.com/emicklei/go-restful"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+type GroupMember struct {
+	UserName  string `json:"userName"`
+	GroupName string `json:"groupName"`
+}
+
+type GroupRoleBinding struct {
+	GroupName string `json:"groupName"`
+	RoleRef   string `json:"roleRef"`
+}
+
+type groupHandler struct {
+	group group.GroupOperator
+}
+
+func newGroupHandler(group group.GroupOperator) *groupHandler {
+	return &groupHandler{
+		group: group,
+	}
+}
+
+func (h *groupHandler) ListGroups(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	queryParam := query.ParseQueryParameter(request)
+
+	result, err := h.group.ListGroups(workspace, queryParam)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *groupHandler) DescribeGroup(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	groupName := request.PathParameter("group")
+
+	group, err := h.group.DescribeGroup(workspace, groupName)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(group)
+}
+
+func (h *groupHandler) CreateGroup(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+
+	var group iamv1alpha2.Group
+	err := request.ReadEntity(&group)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	created, err := h.group.CreateGroup(workspace, &group)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(created)
+}
+
+func (h *groupHandler) UpdateGroup(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	groupName := request.PathParameter("group")
+
+	var group iamv1alpha2.Group
+	err := request.ReadEntity(&group)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	if groupName != group.Name {
+		err := errors.NewBadRequest(fmt.Sprintf("the name of the object (%s) does not match the name on the URL (%s)", group.Name, groupName))
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	updated, err := h.group.UpdateGroup(workspace, &group)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(updated)
+}
+
+func (h *groupHandler) PatchGroup(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	groupName := request.PathParameter("group")
+
+	var group iamv1alpha2.Group
+	err := request.ReadEntity(&group)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+	group.Name = groupName
+
+	patched, err := h.group.PatchGroup(workspace, &group)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(patched)
+}
+
+func (h *groupHandler) DeleteGroup(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	groupName := request.PathParameter("group")
+
+	if err := h.group.DeleteGroup(workspace, groupName); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *groupHandler) ListGroupBindings(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	queryParam := query.ParseQueryParameter(request)
+
+	result, err := h.group.ListGroupBindings(workspace, queryParam)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *groupHandler) CreateGroupBindings(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+
+	var members []GroupMember
+	err := request.ReadEntity(&members)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	var created []*iamv1alpha2.GroupBinding
+	for _, member := range members {
+		groupBinding, err := h.group.CreateGroupBinding(workspace, member.GroupName, member.UserName)
+		if err != nil {
+			api.HandleError(response, request, err)
+			return
+		}
+		created = append(created, groupBinding)
+	}
+
+	response.WriteEntity(created)
+}
+
+func (h *groupHandler) DeleteGroupBinding(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	name := request.PathParameter("groupbinding")
+
+	if err := h.group.DeleteGroupBinding(workspace, name); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *groupHandler) ListWorkspaceRoleBindings(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	queryParam := query.ParseQueryParameter(request)
+
+	result, err := h.group.ListWorkspaceRoleBindings(workspace, queryParam)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *groupHandler) CreateWorkspaceRoleBindings(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+
+	var bindings []GroupRoleBinding
+	err := request.ReadEntity(&bindings)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	var created []*iamv1alpha2.WorkspaceRoleBinding
+	for _, binding := range bindings {
+		roleBinding, err := h.group.CreateWorkspaceRoleBinding(workspace, binding.GroupName, binding.RoleRef)
+		if err != nil {
+			api.HandleError(response, request, err)
+			return
+		}
+		created = append(created, roleBinding)
+	}
+
+	response.WriteEntity(created)
+}
+
+func (h *groupHandler) DeleteWorkspaceRoleBinding(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	name := request.PathParameter("workspacerolebinding")
+
+	if err := h.group.DeleteWorkspaceRoleBinding(workspace, name); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *groupHandler) ListRoleBindings(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	namespace := request.PathParameter("namespace")
+	queryParam := query.ParseQueryParameter(request)
+
+	result, err := h.group.ListRoleBindings(workspace, namespace, queryParam)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *groupHandler) CreateRoleBindings(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	namespace := request.PathParameter("namespace")
+
+	var bindings []GroupRoleBinding
+	err := request.ReadEntity(&bindings)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	var created []*rbacv1.RoleBinding
+	for _, binding := range bindings {
+		roleBinding, err := h.group.CreateRoleBinding(workspace, namespace, binding.GroupName, binding.RoleRef)
+		if err != nil {
+			api.HandleError(response, request, err)
+			return
+		}
+		created = append(created, roleBinding)
+	}
+
+	response.WriteEntity(created)
+}
+
+func (h *groupHandler) DeleteRoleBinding(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("rolebinding")
+
+	if err := h.group.DeleteRoleBinding(workspace, namespace, name); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
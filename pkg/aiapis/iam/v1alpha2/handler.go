@@ -3,20 +3,65 @@ package v1alpha2
 import (
 	"aiscope/pkg/api"
 	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authorization/authorizer"
 	"aiscope/pkg/apiserver/query"
+	apirequest "aiscope/pkg/apiserver/request"
+	"aiscope/pkg/models/auth"
+	"aiscope/pkg/models/iam/am"
 	"aiscope/pkg/models/iam/im"
+	servererr "aiscope/pkg/server/errors"
+	"fmt"
 	"github.com/emicklei/go-restful"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apiserver/pkg/endpoints/request"
 )
 
+type Member struct {
+	Username string `json:"username"`
+	RoleRef  string `json:"roleRef"`
+}
+
+type PasswordReset struct {
+	CurrentPassword string `json:"currentPassword"`
+	Password        string `json:"password"`
+}
+
+type UserState struct {
+	State iamv1alpha2.UserState `json:"state"`
+}
+
+type MFAActivation struct {
+	Code string `json:"code" description:"the TOTP code generated by the authenticator app"`
+}
+
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes" description:"the one-time recovery codes used when the authenticator app is unavailable"`
+}
+
+type UserRoles struct {
+	GlobalRoles    []*iamv1alpha2.GlobalRole    `json:"globalRoles"`
+	WorkspaceRoles []*iamv1alpha2.WorkspaceRole `json:"workspaceRoles"`
+	Roles          []*rbacv1.Role               `json:"roles"`
+}
+
 type iamHandler struct {
-	im         im.IdentityManagementInterface
+	im            im.IdentityManagementInterface
+	am            am.AccessManagementInterface
+	accessToken   auth.AccessTokenOperator
+	tokenOperator auth.TokenManagementInterface
+	mfaOperator   auth.MFAOperator
+	authorizer    authorizer.Authorizer
 }
 
-func newIAMHandler(im im.IdentityManagementInterface) *iamHandler {
+func newIAMHandler(im im.IdentityManagementInterface, am am.AccessManagementInterface, accessToken auth.AccessTokenOperator,
+	tokenOperator auth.TokenManagementInterface, mfaOperator auth.MFAOperator, authorizer authorizer.Authorizer) *iamHandler {
 	return &iamHandler{
-		im:         im,
+		im:            im,
+		am:            am,
+		accessToken:   accessToken,
+		tokenOperator: tokenOperator,
+		mfaOperator:   mfaOperator,
+		authorizer:    authorizer,
 	}
 }
 
@@ -28,7 +73,7 @@ func (h *iamHandler) CreateUser(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
-	operator, ok := request.UserFrom(req.Request.Context())
+	operator, ok := apirequest.UserFrom(req.Request.Context())
 	if ok && operator.GetName() == iamv1alpha2.PreRegistrationUser {
 		extra := operator.GetExtra()
 		// The token used for registration must contain additional information
@@ -57,7 +102,6 @@ func (h *iamHandler) CreateUser(req *restful.Request, resp *restful.Response) {
 	resp.WriteEntity(created)
 }
 
-
 func (h *iamHandler) ListUsers(request *restful.Request, response *restful.Response) {
 	queryParam := query.ParseQueryParameter(request)
 	result, err := h.im.ListUsers(queryParam)
@@ -82,6 +126,474 @@ func (h *iamHandler) ListUsers(request *restful.Request, response *restful.Respo
 	response.WriteEntity(result)
 }
 
+func (h *iamHandler) DescribeUser(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+
+	user, err := h.im.DescribeUser(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(user)
+}
+
+func (h *iamHandler) UpdateUser(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+
+	var user iamv1alpha2.User
+	err := request.ReadEntity(&user)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	if username != user.Name {
+		err := errors.NewBadRequest(fmt.Sprintf("the name of the object (%s) does not match the name on the URL (%s)", user.Name, username))
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	updated, err := h.im.UpdateUser(&user)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(updated)
+}
+
+func (h *iamHandler) PatchUser(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+
+	var user iamv1alpha2.User
+	err := request.ReadEntity(&user)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+	user.Name = username
+
+	patched, err := h.im.PatchUser(&user)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(patched)
+}
+
+func (h *iamHandler) DeleteUser(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+
+	if err := h.im.DeleteUser(username); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+// ModifyPassword changes the password of the user, users changing their own password have to provide the current one,
+// resetting the password of others requires the permission to update users.
+func (h *iamHandler) ModifyPassword(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+
+	var passwordReset PasswordReset
+	err := req.ReadEntity(&passwordReset)
+	if err != nil {
+		api.HandleBadRequest(response, req, err)
+		return
+	}
+
+	if passwordReset.Password == "" {
+		api.HandleBadRequest(response, req, errors.NewBadRequest("password must not be empty"))
+		return
+	}
+
+	operator, ok := apirequest.UserFrom(req.Request.Context())
+	if !ok {
+		api.HandleUnauthorized(response, req, errors.NewUnauthorized("unauthorized"))
+		return
+	}
+
+	verify := operator.GetName() == username
+	if !verify {
+		decision, _, err := h.authorizer.Authorize(authorizer.AttributesRecord{
+			User:            operator,
+			Verb:            "update",
+			APIGroup:        iamv1alpha2.SchemeGroupVersion.Group,
+			APIVersion:      iamv1alpha2.SchemeGroupVersion.Version,
+			Resource:        iamv1alpha2.ResourcesPluralUser,
+			Name:            username,
+			ResourceRequest: true,
+			ResourceScope:   apirequest.GlobalScope,
+		})
+		if err != nil {
+			api.HandleInternalError(response, req, err)
+			return
+		}
+		if decision != authorizer.DecisionAllow {
+			err := errors.NewForbidden(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), username,
+				fmt.Errorf("user %s is not allowed to reset the password of others", operator.GetName()))
+			api.HandleForbidden(response, req, err)
+			return
+		}
+	}
+
+	if err = h.im.ModifyPassword(username, passwordReset.CurrentPassword, passwordReset.Password, verify); err != nil {
+		api.HandleError(response, req, err)
+		return
+	}
+
+	// the user logs in again with the new password, the tokens restricted to change the password are revoked as well
+	if err = h.tokenOperator.RevokeAllUserTokens(username); err != nil {
+		api.HandleInternalError(response, req, err)
+		return
+	}
+	if err = h.accessToken.RevokeAllAccessTokens(username); err != nil {
+		api.HandleInternalError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) UpdateUserState(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+
+	var state UserState
+	err := request.ReadEntity(&state)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	updated, err := h.im.UpdateUserState(username, state.State)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	// the disabled user is logged out, the access tokens are not restored when the user is enabled again
+	if state.State == iamv1alpha2.UserDisabled {
+		if err = h.tokenOperator.RevokeAllUserTokens(username); err != nil {
+			api.HandleInternalError(response, request, err)
+			return
+		}
+		if err = h.accessToken.RevokeAllAccessTokens(username); err != nil {
+			api.HandleInternalError(response, request, err)
+			return
+		}
+	}
+
+	response.WriteEntity(updated)
+}
+
+func (h *iamHandler) UnlockUser(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+
+	updated, err := h.im.UnlockUser(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(updated)
+}
+
+func (h *iamHandler) ListUserLoginRecords(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	queryParam := query.ParseQueryParameter(request)
+
+	result, err := h.im.ListLoginRecords(username, queryParam)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *iamHandler) ListUserRoles(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	workspace := request.QueryParameter("workspace")
+	namespace := request.QueryParameter("namespace")
+
+	user, err := h.im.DescribeUser(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	globalRoles, err := h.am.GetGlobalRoleOfUser(user.Name, user.Spec.Groups)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	workspaceRoles, err := h.am.GetWorkspaceRoleOfUser(user.Name, user.Spec.Groups, workspace)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	roles, err := h.am.GetNamespaceRoleOfUser(user.Name, user.Spec.Groups, namespace)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(UserRoles{GlobalRoles: globalRoles, WorkspaceRoles: workspaceRoles, Roles: roles})
+}
+
+func (h *iamHandler) ListAccessTokens(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+
+	if !h.authorizeTokenOperation(req, response, username) {
+		return
+	}
+
+	result, err := h.accessToken.ListAccessTokens(username)
+	if err != nil {
+		api.HandleInternalError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *iamHandler) CreateAccessToken(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+
+	var accessToken auth.AccessToken
+	err := req.ReadEntity(&accessToken)
+	if err != nil {
+		api.HandleBadRequest(response, req, err)
+		return
+	}
+
+	// the access tokens act on behalf of the user, nobody else can create them
+	operator, ok := apirequest.UserFrom(req.Request.Context())
+	if !ok || operator.GetName() != username {
+		err := errors.NewForbidden(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), username,
+			fmt.Errorf("access tokens can only be created by the user"))
+		api.HandleForbidden(response, req, err)
+		return
+	}
+
+	created, err := h.accessToken.CreateAccessToken(username, &accessToken)
+	if err != nil {
+		api.HandleError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(created)
+}
+
+func (h *iamHandler) RevokeAccessToken(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+	name := req.PathParameter("accesstoken")
+
+	if !h.authorizeTokenOperation(req, response, username) {
+		return
+	}
+
+	if err := h.accessToken.RevokeAccessToken(username, name); err != nil {
+		api.HandleError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) ListSessions(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+
+	if !h.authorizeTokenOperation(req, response, username) {
+		return
+	}
+
+	result, err := h.tokenOperator.ListSessions(username)
+	if err != nil {
+		api.HandleInternalError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *iamHandler) RevokeSession(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+	id := req.PathParameter("session")
+
+	if !h.authorizeTokenOperation(req, response, username) {
+		return
+	}
+
+	if err := h.tokenOperator.RevokeSession(username, id); err != nil {
+		api.HandleError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) DescribeMFA(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+
+	if !h.authorizeTokenOperation(req, response, username) {
+		return
+	}
+
+	status, err := h.mfaOperator.Status(username)
+	if err != nil {
+		api.HandleError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(status)
+}
+
+// EnrollMFA generates the TOTP secret of the user, the two-factor authentication is enabled once the secret is activated
+func (h *iamHandler) EnrollMFA(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+
+	// the second factor belongs to the user, nobody else can enroll it
+	operator, ok := apirequest.UserFrom(req.Request.Context())
+	if !ok || operator.GetName() != username {
+		err := errors.NewForbidden(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), username,
+			fmt.Errorf("two-factor authentication can only be enrolled by the user"))
+		api.HandleForbidden(response, req, err)
+		return
+	}
+
+	enrollment, err := h.mfaOperator.Enroll(username)
+	if err != nil {
+		api.HandleError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(enrollment)
+}
+
+// ActivateMFA enables the two-factor authentication with the code of the enrolled secret,
+// the recovery codes are returned only once.
+func (h *iamHandler) ActivateMFA(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+
+	var activation MFAActivation
+	if err := req.ReadEntity(&activation); err != nil {
+		api.HandleBadRequest(response, req, err)
+		return
+	}
+
+	operator, ok := apirequest.UserFrom(req.Request.Context())
+	if !ok || operator.GetName() != username {
+		err := errors.NewForbidden(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), username,
+			fmt.Errorf("two-factor authentication can only be activated by the user"))
+		api.HandleForbidden(response, req, err)
+		return
+	}
+
+	recoveryCodes, err := h.mfaOperator.Activate(username, activation.Code)
+	if err != nil {
+		if err == auth.IncorrectMFACodeError {
+			api.HandleBadRequest(response, req, err)
+			return
+		}
+		api.HandleError(response, req, err)
+		return
+	}
+
+	// the user logs in again with the second factor, the tokens restricted to enroll it are revoked as well
+	if err = h.tokenOperator.RevokeAllUserTokens(username); err != nil {
+		api.HandleInternalError(response, req, err)
+		return
+	}
+	if err = h.accessToken.RevokeAllAccessTokens(username); err != nil {
+		api.HandleInternalError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(MFARecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+// DisableMFA removes the second factor of the user, users disabling their own have to provide a code,
+// resetting the second factor of others, e.g. the device is lost, requires the permission to update users.
+func (h *iamHandler) DisableMFA(req *restful.Request, response *restful.Response) {
+	username := req.PathParameter("user")
+
+	operator, ok := apirequest.UserFrom(req.Request.Context())
+	if !ok {
+		api.HandleUnauthorized(response, req, errors.NewUnauthorized("unauthorized"))
+		return
+	}
+
+	if !h.authorizeTokenOperation(req, response, username) {
+		return
+	}
+
+	if operator.GetName() == username {
+		status, err := h.mfaOperator.Status(username)
+		if err != nil {
+			api.HandleError(response, req, err)
+			return
+		}
+		if status.Enabled {
+			if err = h.mfaOperator.Verify(username, req.QueryParameter("code")); err != nil {
+				if err == auth.IncorrectMFACodeError {
+					api.HandleForbidden(response, req, err)
+					return
+				}
+				api.HandleError(response, req, err)
+				return
+			}
+		}
+	}
+
+	if err := h.mfaOperator.Disable(username); err != nil {
+		api.HandleError(response, req, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+// authorizeTokenOperation allows the user to manage the tokens and the second factor of their own,
+// managing the ones of others requires the permission to update the user, the same as resetting the password.
+func (h *iamHandler) authorizeTokenOperation(req *restful.Request, response *restful.Response, username string) bool {
+	operator, ok := apirequest.UserFrom(req.Request.Context())
+	if !ok {
+		api.HandleUnauthorized(response, req, errors.NewUnauthorized("unauthorized"))
+		return false
+	}
+	if operator.GetName() == username {
+		return true
+	}
+	decision, _, err := h.authorizer.Authorize(authorizer.AttributesRecord{
+		User:            operator,
+		Verb:            "update",
+		APIGroup:        iamv1alpha2.SchemeGroupVersion.Group,
+		APIVersion:      iamv1alpha2.SchemeGroupVersion.Version,
+		Resource:        iamv1alpha2.ResourcesPluralUser,
+		Name:            username,
+		ResourceRequest: true,
+		ResourceScope:   apirequest.GlobalScope,
+	})
+	if err != nil {
+		api.HandleInternalError(response, req, err)
+		return false
+	}
+	if decision != authorizer.DecisionAllow {
+		err := errors.NewForbidden(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), username,
+			fmt.Errorf("user %s is not allowed to manage the tokens of others", operator.GetName()))
+		api.HandleForbidden(response, req, err)
+		return false
+	}
+	return true
+}
+
 func appendGlobalRoleAnnotation(user *iamv1alpha2.User, globalRole string) *iamv1alpha2.User {
 	if user.Annotations == nil {
 		user.Annotations = make(map[string]string, 0)
@@ -90,3 +602,215 @@ func appendGlobalRoleAnnotation(user *iamv1alpha2.User, globalRole string) *iamv
 	return user
 }
 
+func (h *iamHandler) ListWorkspaceMembers(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	queryParam := query.ParseQueryParameter(request)
+	// the workspacerole query parameter is kept in the filters and narrows down the members by role
+	queryParam.Filters[iamv1alpha2.ScopeWorkspace] = query.Value(workspace)
+
+	result, err := h.im.ListUsers(queryParam)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *iamHandler) DescribeWorkspaceMember(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	username := request.PathParameter("workspacemember")
+
+	user, err := h.im.DescribeUser(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	workspaceRoles, err := h.am.GetWorkspaceRoleOfUser(username, nil, workspace)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+	if len(workspaceRoles) == 0 {
+		err := errors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcesSingularUser), username)
+		api.HandleNotFound(response, request, err)
+		return
+	}
+
+	if user.Annotations == nil {
+		user.Annotations = make(map[string]string)
+	}
+	user.Annotations[iamv1alpha2.WorkspaceRoleAnnotation] = workspaceRoles[0].Name
+
+	response.WriteEntity(user)
+}
+
+func (h *iamHandler) CreateWorkspaceMembers(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+
+	var members []Member
+	err := request.ReadEntity(&members)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	for _, member := range members {
+		if err := h.am.CreateOrUpdateWorkspaceRoleBinding(member.Username, workspace, member.RoleRef); err != nil {
+			api.HandleError(response, request, err)
+			return
+		}
+	}
+
+	response.WriteEntity(members)
+}
+
+func (h *iamHandler) UpdateWorkspaceMember(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	username := request.PathParameter("workspacemember")
+
+	var member Member
+	err := request.ReadEntity(&member)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	if username != member.Username {
+		err := errors.NewBadRequest(fmt.Sprintf("the name of the object (%s) does not match the name on the URL (%s)", member.Username, username))
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	if err := h.am.CreateOrUpdateWorkspaceRoleBinding(member.Username, workspace, member.RoleRef); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(member)
+}
+
+func (h *iamHandler) RemoveUserFromWorkspace(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	username := request.PathParameter("workspacemember")
+
+	if err := h.am.RemoveUserFromWorkspace(username, workspace); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) ListWorkspaceRoles(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	queryParam := query.ParseQueryParameter(request)
+
+	result, err := h.am.ListWorkspaceRoles(workspace, queryParam)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *iamHandler) DescribeWorkspaceRole(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	workspaceRoleName := request.PathParameter("workspacerole")
+
+	workspaceRole, err := h.am.GetWorkspaceRole(workspace, workspaceRoleName)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(workspaceRole)
+}
+
+func (h *iamHandler) CreateWorkspaceRole(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+
+	var workspaceRole iamv1alpha2.WorkspaceRole
+	err := request.ReadEntity(&workspaceRole)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+	// ignore the resource version, it is a creation
+	workspaceRole.ResourceVersion = ""
+
+	created, err := h.am.CreateOrUpdateWorkspaceRole(workspace, &workspaceRole)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(created)
+}
+
+func (h *iamHandler) UpdateWorkspaceRole(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	workspaceRoleName := request.PathParameter("workspacerole")
+
+	var workspaceRole iamv1alpha2.WorkspaceRole
+	err := request.ReadEntity(&workspaceRole)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	if workspaceRoleName != workspaceRole.Name {
+		err := errors.NewBadRequest(fmt.Sprintf("the name of the object (%s) does not match the name on the URL (%s)", workspaceRole.Name, workspaceRoleName))
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	if workspaceRole.ResourceVersion == "" {
+		err := errors.NewBadRequest("metadata.resourceVersion must be specified for an update")
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	updated, err := h.am.CreateOrUpdateWorkspaceRole(workspace, &workspaceRole)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(updated)
+}
+
+func (h *iamHandler) PatchWorkspaceRole(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	workspaceRoleName := request.PathParameter("workspacerole")
+
+	var workspaceRole iamv1alpha2.WorkspaceRole
+	err := request.ReadEntity(&workspaceRole)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+	workspaceRole.Name = workspaceRoleName
+
+	patched, err := h.am.PatchWorkspaceRole(workspace, &workspaceRole)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(patched)
+}
+
+func (h *iamHandler) DeleteWorkspaceRole(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	workspaceRoleName := request.PathParameter("workspacerole")
+
+	if err := h.am.DeleteWorkspaceRole(workspace, workspaceRoleName); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
@@ -0,0 +1,270 @@
+package v1alpha2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"golang.org/x/crypto/bcrypt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	k8scache "k8s.io/client-go/tools/cache"
+
+	"aiscope/pkg/api"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authentication"
+	"aiscope/pkg/apiserver/authentication/token"
+	"aiscope/pkg/apiserver/authorization/authorizer"
+	"aiscope/pkg/apiserver/filters"
+	"aiscope/pkg/apiserver/query"
+	aiscope "aiscope/pkg/client/clientset/versioned"
+	fakeaiscope "aiscope/pkg/client/clientset/versioned/fake"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/models/auth"
+	"aiscope/pkg/models/iam/am"
+	"aiscope/pkg/models/iam/im"
+	"aiscope/pkg/simple/client/cache"
+)
+
+// userGetter reads the users from the client, the informers are not needed
+type userGetter struct {
+	aiClient aiscope.Interface
+}
+
+func (g *userGetter) Get(_, name string) (runtime.Object, error) {
+	return g.aiClient.IamV1alpha2().Users().Get(context.Background(), name, metav1.GetOptions{})
+}
+
+func (g *userGetter) List(_ string, _ *query.Query) (*api.ListResult, error) {
+	return &api.ListResult{}, nil
+}
+
+// globalRoles binds no global role to the users, the other operations are not needed
+type globalRoles struct {
+	am.AccessManagementInterface
+}
+
+func (g *globalRoles) GetGlobalRoleOfUser(_ string, _ []string) ([]*iamv1alpha2.GlobalRole, error) {
+	return nil, nil
+}
+
+// newTestServer serves the iam APIs behind the authentication filter, the requests are authenticated as alice,
+// and the permissions to manage other users are denied.
+func newTestServer(t *testing.T, client aiscope.Interface) (http.Handler, auth.TokenManagementInterface) {
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	options.OAuthOptions.AccessTokenMaxAge = time.Hour
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deny := authorizer.AuthorizerFunc(func(a authorizer.Attributes) (authorizer.Decision, string, error) {
+		return authorizer.DecisionDeny, "", nil
+	})
+	cacheClient := cache.NewSimpleCache()
+	tokenOperator := auth.NewTokenOperator(cacheClient, issuer, options)
+	users := k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{})
+	for _, name := range []string{"alice", "bob"} {
+		if err = users.Add(&iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: name}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mfaOperator := auth.NewMFAOperator(fakek8s.NewSimpleClientset(), cacheClient, iamv1alpha2listers.NewUserLister(users),
+		iamv1alpha2listers.NewGroupLister(k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{})), &globalRoles{})
+	container := restful.NewContainer()
+	err = AddToContainer(container, im.NewOperator(client, &userGetter{aiClient: client}, nil, options), nil, nil,
+		auth.NewAccessTokenOperator(cacheClient, issuer), tokenOperator, mfaOperator, deny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+	})
+	return filters.WithAuthentication(container, alice), tokenOperator
+}
+
+func serve(server http.Handler, method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/aiapis/iam.aiscope/v1alpha2"+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", restful.MIME_JSON)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestModifyPassword(t *testing.T) {
+	encryptedPassword, err := bcrypt.GenerateFromPassword([]byte("P@88w0rd"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fakeaiscope.NewSimpleClientset()
+	for _, name := range []string{"alice", "bob"} {
+		u := &iamv1alpha2.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       iamv1alpha2.UserSpec{EncryptedPassword: string(encryptedPassword)},
+		}
+		if _, err = client.IamV1alpha2().Users().Create(context.Background(), u, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server, tokenOperator := newTestServer(t, client)
+	recorder := serve(server, http.MethodPost, "/users/alice/accesstokens", `{"name":"ci"}`)
+	var accessToken auth.AccessToken
+	if err = json.Unmarshal(recorder.Body.Bytes(), &accessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		username    string
+		body        string
+		expected    int
+	}{
+		{"wrong current password", "alice", `{"currentPassword":"wrong","password":"N3wP@88w0rd"}`, http.StatusBadRequest},
+		{"own password", "alice", `{"currentPassword":"P@88w0rd","password":"N3wP@88w0rd"}`, http.StatusOK},
+		{"password of others", "bob", `{"password":"N3wP@88w0rd"}`, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			recorder := serve(server, http.MethodPut, "/users/"+test.username+"/password", test.body)
+			if recorder.Code != test.expected {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, test.expected, recorder.Body.String())
+			}
+		})
+	}
+
+	alice, err := client.IamV1alpha2().Users().Get(context.Background(), "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = auth.PasswordVerify(alice.Spec.EncryptedPassword, "N3wP@88w0rd"); err != nil {
+		t.Errorf("the password of alice should be changed")
+	}
+	if _, err = tokenOperator.Verify(accessToken.Token); err == nil {
+		t.Errorf("the access tokens of alice should be revoked")
+	}
+	bob, err := client.IamV1alpha2().Users().Get(context.Background(), "bob", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Spec.EncryptedPassword != string(encryptedPassword) {
+		t.Errorf("the password of bob should not be changed")
+	}
+}
+
+func TestAccessTokens(t *testing.T) {
+	server, _ := newTestServer(t, fakeaiscope.NewSimpleClientset())
+
+	tests := []struct {
+		description string
+		method      string
+		path        string
+		body        string
+		expected    int
+	}{
+		{"create own token", http.MethodPost, "/users/alice/accesstokens", `{"name":"ci","scopes":["user:read"]}`, http.StatusOK},
+		{"list own tokens", http.MethodGet, "/users/alice/accesstokens", "", http.StatusOK},
+		{"create token of others", http.MethodPost, "/users/bob/accesstokens", `{"name":"ci"}`, http.StatusForbidden},
+		{"list tokens of others", http.MethodGet, "/users/bob/accesstokens", "", http.StatusForbidden},
+		{"revoke own token", http.MethodDelete, "/users/alice/accesstokens/ci", "", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			recorder := serve(server, test.method, test.path, test.body)
+			if recorder.Code != test.expected {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, test.expected, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	server, tokenOperator := newTestServer(t, fakeaiscope.NewSimpleClientset())
+	session := &auth.Session{}
+	if _, _, err := tokenOperator.IssueSessionTo(&user.DefaultInfo{Name: "alice"}, session); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tokenOperator.IssueSessionTo(&user.DefaultInfo{Name: "bob"}, &auth.Session{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		method      string
+		path        string
+		expected    int
+	}{
+		{"list own sessions", http.MethodGet, "/users/alice/sessions", http.StatusOK},
+		{"list sessions of others", http.MethodGet, "/users/bob/sessions", http.StatusForbidden},
+		{"revoke own session", http.MethodDelete, "/users/alice/sessions/" + session.ID, http.StatusOK},
+		{"revoke revoked session", http.MethodDelete, "/users/alice/sessions/" + session.ID, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			recorder := serve(server, test.method, test.path, "")
+			if recorder.Code != test.expected {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, test.expected, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateUserState(t *testing.T) {
+	client := fakeaiscope.NewSimpleClientset()
+	if _, err := client.IamV1alpha2().Users().Create(context.Background(),
+		&iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: "bob"}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	server, tokenOperator := newTestServer(t, client)
+	accessToken, _, err := tokenOperator.IssueSessionTo(&user.DefaultInfo{Name: "bob"}, &auth.Session{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := serve(server, http.MethodPut, "/users/bob/status", `{"state":"Disabled"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	if _, err = tokenOperator.Verify(accessToken); err == nil {
+		t.Errorf("the tokens of the disabled user should be revoked")
+	}
+}
+
+func TestMFA(t *testing.T) {
+	server, _ := newTestServer(t, fakeaiscope.NewSimpleClientset())
+
+	tests := []struct {
+		description string
+		method      string
+		path        string
+		body        string
+		expected    int
+	}{
+		{"enroll own mfa", http.MethodPost, "/users/alice/mfa", "", http.StatusOK},
+		{"describe own mfa", http.MethodGet, "/users/alice/mfa", "", http.StatusOK},
+		{"activate with incorrect code", http.MethodPut, "/users/alice/mfa", `{"code":"000000x"}`, http.StatusBadRequest},
+		{"enroll mfa of others", http.MethodPost, "/users/bob/mfa", "", http.StatusForbidden},
+		{"activate mfa of others", http.MethodPut, "/users/bob/mfa", `{"code":"000000"}`, http.StatusForbidden},
+		{"disable mfa of others", http.MethodDelete, "/users/bob/mfa", "", http.StatusForbidden},
+		{"disable own pending mfa", http.MethodDelete, "/users/alice/mfa", "", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			recorder := serve(server, test.method, test.path, test.body)
+			if recorder.Code != test.expected {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, test.expected, recorder.Body.String())
+			}
+		})
+	}
+}
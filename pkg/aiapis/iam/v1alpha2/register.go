@@ -3,17 +3,26 @@ package v1alpha2
 import (
 	"aiscope/pkg/api"
 	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authorization/authorizer"
 	"aiscope/pkg/apiserver/runtime"
 	"aiscope/pkg/constants"
+	"aiscope/pkg/models/auth"
+	"aiscope/pkg/models/iam/am"
+	"aiscope/pkg/models/iam/group"
 	"aiscope/pkg/models/iam/im"
+	"aiscope/pkg/server/errors"
 	"github.com/emicklei/go-restful"
 	restfulspec "github.com/emicklei/go-restful-openapi"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"net/http"
 )
 
-func AddToContainer(container *restful.Container, im im.IdentityManagementInterface) error {
+func AddToContainer(container *restful.Container, im im.IdentityManagementInterface, am am.AccessManagementInterface, group group.GroupOperator, accessToken auth.AccessTokenOperator,
+	tokenOperator auth.TokenManagementInterface, mfaOperator auth.MFAOperator, authorizer authorizer.Authorizer) error {
 	ws := runtime.NewWebService(iamv1alpha2.SchemeGroupVersion)
-	handler := newIAMHandler(im)
+	handler := newIAMHandler(im, am, accessToken, tokenOperator, mfaOperator, authorizer)
+	groupHandler := newGroupHandler(group)
+	mimePatch := []string{restful.MIME_JSON, runtime.MimeMergePatchJson, runtime.MimeJsonPatchJson}
 
 	// users
 	ws.Route(ws.POST("/users").
@@ -29,6 +38,363 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.User{}}}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
 
+	ws.Route(ws.GET("/users/{user}").
+		To(handler.DescribeUser).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Retrieve user details.").
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.PUT("/users/{user}").
+		To(handler.UpdateUser).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Update user profile, the password and the status are not changed.").
+		Reads(iamv1alpha2.User{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.PATCH("/users/{user}").
+		To(handler.PatchUser).
+		Param(ws.PathParameter("user", "username")).
+		Consumes(mimePatch...).
+		Doc("Update user profile partially, the password and the status are not changed.").
+		Reads(iamv1alpha2.User{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.DELETE("/users/{user}").
+		To(handler.DeleteUser).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Delete the specified user.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.PUT("/users/{user}/password").
+		To(handler.ModifyPassword).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Modify the password of the user, the current password is required when modifying your own password.").
+		Reads(PasswordReset{}).
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.PUT("/users/{user}/status").
+		To(handler.UpdateUserState).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Enable or disable the user, allowed states: Active, Disabled.").
+		Reads(UserState{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.POST("/users/{user}/unlock").
+		To(handler.UnlockUser).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Unlock the user blocked by too many failed login attempts.").
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.GET("/users/{user}/loginrecords").
+		To(handler.ListUserLoginRecords).
+		Param(ws.PathParameter("user", "username")).
+		Doc("List login records of the user.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.LoginRecord{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.GET("/users/{user}/roles").
+		To(handler.ListUserRoles).
+		Param(ws.PathParameter("user", "username")).
+		Param(ws.QueryParameter("workspace", "only list the workspace roles in the workspace").Required(false)).
+		Param(ws.QueryParameter("namespace", "only list the roles in the namespace").Required(false)).
+		Doc("List the global roles, workspace roles and namespace roles of the user.").
+		Returns(http.StatusOK, api.StatusOK, UserRoles{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.GET("/users/{user}/accesstokens").
+		To(handler.ListAccessTokens).
+		Param(ws.PathParameter("user", "username")).
+		Doc("List the personal access tokens of the user, the tokens themselves are not returned.").
+		Returns(http.StatusOK, api.StatusOK, []auth.AccessToken{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.POST("/users/{user}/accesstokens").
+		To(handler.CreateAccessToken).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Create a personal access token for API and CLI automation, the token is returned only once. "+
+			"Allowed scopes: user:full, user:read.").
+		Reads(auth.AccessToken{}).
+		Returns(http.StatusOK, api.StatusOK, auth.AccessToken{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.DELETE("/users/{user}/accesstokens/{accesstoken}").
+		To(handler.RevokeAccessToken).
+		Param(ws.PathParameter("user", "username")).
+		Param(ws.PathParameter("accesstoken", "the name of the access token")).
+		Doc("Revoke the personal access token.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.GET("/users/{user}/sessions").
+		To(handler.ListSessions).
+		Param(ws.PathParameter("user", "username")).
+		Doc("List the active login sessions of the user.").
+		Returns(http.StatusOK, api.StatusOK, []auth.Session{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.DELETE("/users/{user}/sessions/{session}").
+		To(handler.RevokeSession).
+		Param(ws.PathParameter("user", "username")).
+		Param(ws.PathParameter("session", "the id of the session")).
+		Doc("Revoke the access token and the refresh token of the login session.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.GET("/users/{user}/mfa").
+		To(handler.DescribeMFA).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Retrieve the two-factor authentication state of the user.").
+		Returns(http.StatusOK, api.StatusOK, auth.MFAStatus{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.POST("/users/{user}/mfa").
+		To(handler.EnrollMFA).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Enroll the TOTP two-factor authentication, the secret is imported into the authenticator app and activated with a code.").
+		Returns(http.StatusOK, api.StatusOK, auth.MFAEnrollment{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.PUT("/users/{user}/mfa").
+		To(handler.ActivateMFA).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Activate the enrolled two-factor authentication, the recovery codes are returned only once. "+
+			"The tokens of the user are revoked, the user logs in again with the second factor.").
+		Reads(MFAActivation{}).
+		Returns(http.StatusOK, api.StatusOK, MFARecoveryCodes{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.DELETE("/users/{user}/mfa").
+		To(handler.DisableMFA).
+		Param(ws.PathParameter("user", "username")).
+		Param(ws.QueryParameter("code", "the TOTP code or a recovery code, required to disable the enabled two-factor authentication of the user's own").
+			Required(false)).
+		Doc("Disable the two-factor authentication of the user.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	// workspace members
+	ws.Route(ws.GET("/workspaces/{workspace}/workspacemembers").
+		To(handler.ListWorkspaceMembers).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.QueryParameter("workspacerole", "only list the members bound to the workspace role").Required(false)).
+		Doc("List all members in the specified workspace.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.User{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceMemberTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/workspacemembers/{workspacemember}").
+		To(handler.DescribeWorkspaceMember).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("workspacemember", "workspace member's username")).
+		Doc("Retrieve the workspace member and its workspace role.").
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceMemberTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/workspacemembers").
+		To(handler.CreateWorkspaceMembers).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("Invite users to the specified workspace with the given workspace roles.").
+		Reads([]Member{}).
+		Returns(http.StatusOK, api.StatusOK, []Member{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceMemberTag}))
+
+	ws.Route(ws.PUT("/workspaces/{workspace}/workspacemembers/{workspacemember}").
+		To(handler.UpdateWorkspaceMember).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("workspacemember", "workspace member's username")).
+		Doc("Change the workspace role of the workspace member.").
+		Reads(Member{}).
+		Returns(http.StatusOK, api.StatusOK, Member{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceMemberTag}))
+
+	ws.Route(ws.DELETE("/workspaces/{workspace}/workspacemembers/{workspacemember}").
+		To(handler.RemoveUserFromWorkspace).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("workspacemember", "workspace member's username")).
+		Doc("Remove the member from the workspace.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceMemberTag}))
+
+	// workspace roles
+	ws.Route(ws.GET("/workspaces/{workspace}/workspaceroles").
+		To(handler.ListWorkspaceRoles).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("List all workspace roles of the specified workspace.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.WorkspaceRole{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessManagementTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/workspaceroles/{workspacerole}").
+		To(handler.DescribeWorkspaceRole).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("workspacerole", "workspace role name")).
+		Doc("Retrieve the workspace role.").
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.WorkspaceRole{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessManagementTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/workspaceroles").
+		To(handler.CreateWorkspaceRole).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("Create a custom workspace role in the specified workspace.").
+		Reads(iamv1alpha2.WorkspaceRole{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.WorkspaceRole{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessManagementTag}))
+
+	ws.Route(ws.PUT("/workspaces/{workspace}/workspaceroles/{workspacerole}").
+		To(handler.UpdateWorkspaceRole).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("workspacerole", "workspace role name")).
+		Doc("Update the workspace role.").
+		Reads(iamv1alpha2.WorkspaceRole{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.WorkspaceRole{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessManagementTag}))
+
+	ws.Route(ws.PATCH("/workspaces/{workspace}/workspaceroles/{workspacerole}").
+		To(handler.PatchWorkspaceRole).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("workspacerole", "workspace role name")).
+		Consumes(mimePatch...).
+		Doc("Patch the workspace role.").
+		Reads(iamv1alpha2.WorkspaceRole{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.WorkspaceRole{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessManagementTag}))
+
+	ws.Route(ws.DELETE("/workspaces/{workspace}/workspaceroles/{workspacerole}").
+		To(handler.DeleteWorkspaceRole).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("workspacerole", "workspace role name")).
+		Doc("Delete the workspace role.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessManagementTag}))
+
+	// groups
+	ws.Route(ws.GET("/workspaces/{workspace}/groups").
+		To(groupHandler.ListGroups).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("List groups of the workspace.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.Group{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/groups/{group}").
+		To(groupHandler.DescribeGroup).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("group", "group name")).
+		Doc("Retrieve group details.").
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.Group{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/groups").
+		To(groupHandler.CreateGroup).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("Create a group in the workspace, set the label iam.aiscope/group-parent to nest it in another group.").
+		Reads(iamv1alpha2.Group{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.Group{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.PUT("/workspaces/{workspace}/groups/{group}").
+		To(groupHandler.UpdateGroup).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("group", "group name")).
+		Doc("Update the group.").
+		Reads(iamv1alpha2.Group{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.Group{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.PATCH("/workspaces/{workspace}/groups/{group}").
+		To(groupHandler.PatchGroup).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("group", "group name")).
+		Consumes(mimePatch...).
+		Doc("Update the group partially.").
+		Reads(iamv1alpha2.Group{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.Group{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.DELETE("/workspaces/{workspace}/groups/{group}").
+		To(groupHandler.DeleteGroup).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("group", "group name")).
+		Doc("Delete the group, its child groups and bindings are deleted as well.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/groupbindings").
+		To(groupHandler.ListGroupBindings).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("List the group bindings of the workspace, filter by the labels iam.aiscope/group-ref or iam.aiscope.io/user-ref.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.GroupBinding{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/groupbindings").
+		To(groupHandler.CreateGroupBindings).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("Add users to groups.").
+		Reads([]GroupMember{}).
+		Returns(http.StatusOK, api.StatusOK, []iamv1alpha2.GroupBinding{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.DELETE("/workspaces/{workspace}/groupbindings/{groupbinding}").
+		To(groupHandler.DeleteGroupBinding).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("groupbinding", "group binding name")).
+		Doc("Remove the user from the group.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/workspacerolebindings").
+		To(groupHandler.ListWorkspaceRoleBindings).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("List the workspace role bindings of groups.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.WorkspaceRoleBinding{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/workspacerolebindings").
+		To(groupHandler.CreateWorkspaceRoleBindings).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("Bind groups to workspace roles.").
+		Reads([]GroupRoleBinding{}).
+		Returns(http.StatusOK, api.StatusOK, []iamv1alpha2.WorkspaceRoleBinding{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.DELETE("/workspaces/{workspace}/workspacerolebindings/{workspacerolebinding}").
+		To(groupHandler.DeleteWorkspaceRoleBinding).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("workspacerolebinding", "workspace role binding name")).
+		Doc("Delete the workspace role binding of a group.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/namespaces/{namespace}/rolebindings").
+		To(groupHandler.ListRoleBindings).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Doc("List the role bindings of groups in the namespace.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{rbacv1.RoleBinding{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/namespaces/{namespace}/rolebindings").
+		To(groupHandler.CreateRoleBindings).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Doc("Bind groups to roles of the namespace.").
+		Reads([]GroupRoleBinding{}).
+		Returns(http.StatusOK, api.StatusOK, []rbacv1.RoleBinding{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
+	ws.Route(ws.DELETE("/workspaces/{workspace}/namespaces/{namespace}/rolebindings/{rolebinding}").
+		To(groupHandler.DeleteRoleBinding).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Param(ws.PathParameter("rolebinding", "role binding name")).
+		Doc("Delete the role binding of a group.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.GroupTag}))
+
 	container.Add(ws)
 	return nil
 }
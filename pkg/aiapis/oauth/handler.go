@@ -11,22 +11,34 @@ import (
 	"aiscope/pkg/models/auth"
 	"aiscope/pkg/models/iam/im"
 	"aiscope/pkg/server/errors"
+	"aiscope/pkg/utils/sliceutil"
 	"fmt"
 	"github.com/emicklei/go-restful"
 	"github.com/form3tech-oss/jwt-go"
+	"gopkg.in/square/go-jose.v2"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/klog/v2"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 const (
 	KindTokenReview       = "TokenReview"
 	grantTypePassword     = "password"
 	grantTypeRefreshToken = "refresh_token"
-	grantTypeCode         = "code"
+	grantTypeCode         = "authorization_code"
+	grantTypeMFAOTP       = "mfa_otp"
+
+	// A maximum authorization code lifetime of 10 minutes is RECOMMENDED.
+	authorizationCodeMaxAge = 10 * time.Minute
+	mfaChallengeMaxAge      = 5 * time.Minute
 )
 
 type LoginRequest struct {
@@ -34,22 +46,109 @@ type LoginRequest struct {
 	Password string `json:"password" description:"password"`
 }
 
+// RegistrationConfirm confirms the registration of the identity pre-registered at login,
+// the identity is linked to the existing account if the password is specified.
+type RegistrationConfirm struct {
+	Username string `json:"username,omitempty" description:"username of the new account, default to the username of the identity, or the username or email of the existing account to link"`
+	Email    string `json:"email,omitempty" description:"email of the new account, default to the email of the identity"`
+	Password string `json:"password,omitempty" description:"password of the existing account to link"`
+}
+
+// MFAChallenge is returned when the user authenticated with the password has to complete the login with the second factor.
+// The code is posted with the MFA token to the token endpoint with the mfa_otp grant type,
+// or to the MFA endpoint if the challenge is returned by the login API.
+type MFAChallenge struct {
+	oauth.Error
+	MFAToken  string `json:"mfa_token"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// MFAVerification completes the login challenged by the login API
+type MFAVerification struct {
+	MFAToken string `json:"mfaToken" description:"the MFA token of the challenge"`
+	Code     string `json:"code" description:"the TOTP code or a recovery code"`
+}
+
+// AuthorizeConsent is returned when the user is prompted to approve the authorization request of the client,
+// the request is approved or denied by posting its parameters back with approve set to true or false.
+type AuthorizeConsent struct {
+	ClientID    string   `json:"client_id" description:"the client requesting the authorization"`
+	RedirectURI string   `json:"redirect_uri" description:"the URI the authorization response is sent to"`
+	Scopes      []string `json:"scopes,omitempty" description:"the requested scopes"`
+	State       string   `json:"state,omitempty" description:"the opaque value used by the client to maintain state"`
+}
+
+// discovery is the OpenID Provider Metadata,
+// see also https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type discovery struct {
+	Issuer                   string   `json:"issuer"`
+	Auth                     string   `json:"authorization_endpoint"`
+	Token                    string   `json:"token_endpoint"`
+	Keys                     string   `json:"jwks_uri"`
+	UserInfo                 string   `json:"userinfo_endpoint"`
+	EndSession               string   `json:"end_session_endpoint"`
+	ResponseTypes            []string `json:"response_types_supported"`
+	GrantTypes               []string `json:"grant_types_supported"`
+	Subjects                 []string `json:"subject_types_supported"`
+	IDTokenAlgs              []string `json:"id_token_signing_alg_values_supported"`
+	CodeChallengeMethods     []string `json:"code_challenge_methods_supported"`
+	Scopes                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethods []string `json:"token_endpoint_auth_methods_supported"`
+	Claims                   []string `json:"claims_supported"`
+}
+
+// Introspection is the Introspection Response, see also https://datatracker.ietf.org/doc/html/rfc7662#section-2.2
+type Introspection struct {
+	// Active indicates whether the presented token is currently active.
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+}
+
+// idTokenRequest describes the ID Token issued alongside the access token
+type idTokenRequest struct {
+	// the client the ID Token is intended for
+	clientID string
+	// the nonce of the authentication request
+	nonce string
+}
+
 type handler struct {
-	im 					  im.IdentityManagementInterface
+	im                    im.IdentityManagementInterface
 	oauthAuthenticator    auth.OAuthAuthenticator
 	passwordAuthenticator auth.PasswordAuthenticator
 	tokenOperator         auth.TokenManagementInterface
+	tokenAuthenticator    authenticator.Token
+	grantOperator         auth.GrantOperator
+	registrationOperator  auth.RegistrationOperator
+	mfaOperator           auth.MFAOperator
 	loginRecorder         auth.LoginRecorder
 	options               *authentication.Options
 }
 
-func newHandler(tokenOperator auth.TokenManagementInterface,
+func newHandler(im im.IdentityManagementInterface,
+	tokenOperator auth.TokenManagementInterface,
+	tokenAuthenticator authenticator.Token,
+	grantOperator auth.GrantOperator,
+	registrationOperator auth.RegistrationOperator,
+	mfaOperator auth.MFAOperator,
 	oauthAuthenticator auth.OAuthAuthenticator,
 	passwordAuthenticator auth.PasswordAuthenticator,
 	loginRecorder auth.LoginRecorder,
 	options *authentication.Options) *handler {
 	return &handler{
+		im:                    im,
 		tokenOperator:         tokenOperator,
+		tokenAuthenticator:    tokenAuthenticator,
+		grantOperator:         grantOperator,
+		registrationOperator:  registrationOperator,
+		mfaOperator:           mfaOperator,
 		oauthAuthenticator:    oauthAuthenticator,
 		passwordAuthenticator: passwordAuthenticator,
 		loginRecorder:         loginRecorder,
@@ -65,7 +164,7 @@ func (h *handler) oauthCallback(req *restful.Request, response *restful.Response
 		return
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	result, err := h.issueTokenTo(authenticated, newSession(req, ""), nil)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -79,20 +178,20 @@ func (h *handler) oauthCallback(req *restful.Request, response *restful.Response
 	response.WriteEntity(result)
 }
 
-func (h *handler) issueTokenTo(user user.Info) (*oauth.Token, error) {
-	accessToken, err := h.tokenOperator.IssueTo(&token.IssueRequest{
-		User:      user,
-		Claims:    token.Claims{TokenType: token.AccessToken},
-		ExpiresIn: h.options.OAuthOptions.AccessTokenMaxAge,
-	})
-	if err != nil {
-		return nil, err
+// newSession returns the login session of the request
+func newSession(req *restful.Request, clientID string) *auth.Session {
+	session := &auth.Session{ClientID: clientID}
+	if requestInfo, ok := request.RequestInfoFrom(req.Request.Context()); ok {
+		session.SourceIP = requestInfo.SourceIP
+		session.UserAgent = requestInfo.UserAgent
 	}
-	refreshToken, err := h.tokenOperator.IssueTo(&token.IssueRequest{
-		User:      user,
-		Claims:    token.Claims{TokenType: token.RefreshToken},
-		ExpiresIn: h.options.OAuthOptions.AccessTokenMaxAge + h.options.OAuthOptions.AccessTokenInactivityTimeout,
-	})
+	return session
+}
+
+// issueTokenTo issues the access token and the refresh token of the session to the user,
+// the ID Token is issued as well if idToken is not nil.
+func (h *handler) issueTokenTo(user user.Info, session *auth.Session, idToken *idTokenRequest) (*oauth.Token, error) {
+	accessToken, refreshToken, err := h.tokenOperator.IssueSessionTo(user, session)
 	if err != nil {
 		return nil, err
 	}
@@ -105,59 +204,318 @@ func (h *handler) issueTokenTo(user user.Info) (*oauth.Token, error) {
 		RefreshToken: refreshToken,
 		ExpiresIn:    int(h.options.OAuthOptions.AccessTokenMaxAge.Seconds()),
 	}
+
+	if idToken != nil {
+		result.IDToken, err = h.issueIDToken(user, idToken)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &result, nil
 }
 
+// issueIDToken issues the ID Token signed with the RSA key of the issuer, which can be verified with the published JWKS.
+// See also https://openid.net/specs/openid-connect-core-1_0.html#IDToken
+func (h *handler) issueIDToken(user user.Info, idToken *idTokenRequest) (string, error) {
+	detail, err := h.im.DescribeUser(user.GetName())
+	if err != nil {
+		return "", err
+	}
+	return h.tokenOperator.IssueTo(&token.IssueRequest{
+		User: user,
+		Claims: token.Claims{
+			StandardClaims: jwt.StandardClaims{
+				Audience: []string{idToken.clientID},
+			},
+			TokenType:         token.IDToken,
+			Nonce:             idToken.nonce,
+			Name:              detail.Name,
+			Email:             detail.Spec.Email,
+			Locale:            detail.Spec.Lang,
+			PreferredUsername: detail.Name,
+		},
+		ExpiresIn: h.options.OAuthOptions.AccessTokenMaxAge,
+	})
+}
+
 // To obtain an Access Token, an ID Token, and optionally a Refresh Token,
 // the RP (Client) sends a Token Request to the Token Endpoint to obtain a Token Response,
 // as described in Section 3.2 of OAuth 2.0 [RFC6749], when using the Authorization Code Flow.
 // Communication with the Token Endpoint MUST utilize TLS.
 func (h *handler) token(req *restful.Request, response *restful.Response) {
+	client, err := h.authenticateClient(req)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
+		return
+	}
+	clientID := client.Name
+
+	grantType, err := req.BodyParameter("grant_type")
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
+		return
+	}
+
+	switch grantType {
+	case grantTypePassword:
+		username, _ := req.BodyParameter("username")
+		password, _ := req.BodyParameter("password")
+		h.passwordGrant(clientID, username, password, &idTokenRequest{clientID: clientID}, req, response)
+		return
+	case grantTypeRefreshToken:
+		h.refreshTokenGrant(clientID, req, response)
+		return
+	case grantTypeCode:
+		h.codeGrant(clientID, req, response)
+		return
+	case grantTypeMFAOTP:
+		mfaToken, _ := req.BodyParameter("mfa_token")
+		code, _ := req.BodyParameter("otp")
+		h.mfaGrant(clientID, mfaToken, code, req, response)
+		return
+	default:
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorUnsupportedGrantType)
+		return
+	}
+}
+
+// authenticateClient authenticates the client with the client credentials included in the request-body,
+// the public clients are registered without secret.
+func (h *handler) authenticateClient(req *restful.Request) (*oauth.Client, error) {
 	// TODO(hongming) support basic auth
 	// https://datatracker.ietf.org/doc/html/rfc6749#section-2.3
 	clientID, err := req.BodyParameter("client_id")
 	if err != nil {
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
-		return
+		return nil, err
 	}
 	clientSecret, err := req.BodyParameter("client_secret")
 	if err != nil {
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
-		return
+		return nil, err
 	}
 
 	client, err := h.options.OAuthOptions.OAuthClient(clientID)
 	if err != nil {
-		oauthError := oauth.NewInvalidClient(err)
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauthError)
-		return
+		return nil, err
 	}
 
 	if client.Secret != clientSecret {
-		oauthError := oauth.NewInvalidClient(fmt.Errorf("invalid client credential"))
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauthError)
+		return nil, fmt.Errorf("invalid client credential")
+	}
+	return &client, nil
+}
+
+// authorize handles the Authorization Request of the Authorization Code Grant,
+// for more details: https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.1
+// The client may bind the authorization code to a code verifier with PKCE,
+// which is required for the public clients: https://datatracker.ietf.org/doc/html/rfc7636#section-4.3
+func (h *handler) authorize(req *restful.Request, response *restful.Response) {
+	// Authorization Servers MUST support the use of the HTTP GET and POST methods
+	parameter := func(name string) string {
+		if req.Request.Method == http.MethodPost {
+			value, _ := req.BodyParameter(name)
+			return value
+		}
+		return req.QueryParameter(name)
+	}
+	clientID := parameter("client_id")
+	redirectURI := parameter("redirect_uri")
+	responseType := parameter("response_type")
+	state := parameter("state")
+	nonce := parameter("nonce")
+	scopes := strings.Fields(parameter("scope"))
+	codeChallenge := parameter("code_challenge")
+	codeChallengeMethod := parameter("code_challenge_method")
+
+	client, err := h.options.OAuthOptions.OAuthClient(clientID)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidClient(err))
 		return
 	}
 
-	grantType, err := req.BodyParameter("grant_type")
+	// If the request fails due to a missing, invalid, or mismatching redirection URI,
+	// the authorization server MUST NOT automatically redirect the user-agent to the invalid redirection URI.
+	redirectURL, err := client.ResolveRedirectURL(redirectURI)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
 		return
 	}
 
-	switch grantType {
-	case grantTypePassword:
-		username, _ := req.BodyParameter("username")
-		password, _ := req.BodyParameter("password")
-		h.passwordGrant(username, password, req, response)
+	authenticated, _ := request.UserFrom(req.Request.Context())
+	if authenticated == nil || authenticated.GetName() == user.Anonymous ||
+		authenticated.GetName() == iamv1alpha2.PreRegistrationUser {
+		response.Header().Add("WWW-Authenticate", "Basic")
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.ErrorLoginRequired)
 		return
-	case grantTypeRefreshToken:
-		h.refreshTokenGrant(req, response)
+	}
+
+	// The other errors are returned to the client by adding the parameters to the query component of the redirection URI.
+	informsError := func(err oauth.Error) {
+		values := redirectURL.Query()
+		values.Set("error", err.Type)
+		if err.Description != "" {
+			values.Set("error_description", err.Description)
+		}
+		if state != "" {
+			values.Set("state", state)
+		}
+		redirectURL.RawQuery = values.Encode()
+		http.Redirect(response, req.Request, redirectURL.String(), http.StatusFound)
+	}
+
+	if responseType != oauth.ResponseTypeCode {
+		informsError(oauth.ErrorUnsupportedResponseType)
 		return
-	default:
-		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorUnsupportedGrantType)
+	}
+
+	for _, scope := range scopes {
+		if !client.IsScopeAllowed(scope) {
+			informsError(oauth.NewInvalidScope(fmt.Errorf("%s: %s", oauth.ErrorScopeNotAllowed, scope)))
+			return
+		}
+	}
+
+	if codeChallenge == "" {
+		// the public clients cannot keep a secret, the authorization code MUST be bound to a code verifier
+		if client.Secret == "" {
+			informsError(oauth.NewInvalidRequest(fmt.Errorf("code challenge required")))
+			return
+		}
+	} else {
+		// Defaults to "plain" if not present in the request.
+		if codeChallengeMethod == "" {
+			codeChallengeMethod = oauth.CodeChallengeMethodPlain
+		}
+		if !oauth.IsValidCodeChallengeMethod(codeChallengeMethod) {
+			informsError(oauth.NewInvalidRequest(fmt.Errorf("transform algorithm not supported")))
+			return
+		}
+		if !oauth.IsValidCodeChallenge(codeChallenge) {
+			informsError(oauth.NewInvalidRequest(fmt.Errorf("invalid code challenge")))
+			return
+		}
+	}
+
+	switch client.Method() {
+	case oauth.GrantHandlerDeny:
+		informsError(oauth.ErrorAccessDenied)
+		return
+	case oauth.GrantHandlerPrompt:
+		granted, err := h.grantOperator.Granted(authenticated.GetName(), clientID, scopes)
+		if err != nil {
+			informsError(oauth.NewServerError(err))
+			return
+		}
+		if !granted {
+			approve := parameter("approve")
+			if req.Request.Method != http.MethodPost || approve == "" {
+				response.WriteEntity(AuthorizeConsent{
+					ClientID:    clientID,
+					RedirectURI: redirectURL.String(),
+					Scopes:      scopes,
+					State:       state,
+				})
+				return
+			}
+			if approve != "true" {
+				informsError(oauth.ErrorAccessDenied)
+				return
+			}
+			if err = h.grantOperator.Grant(authenticated.GetName(), clientID, scopes); err != nil {
+				informsError(oauth.NewServerError(err))
+				return
+			}
+		}
+	}
+
+	code, err := h.tokenOperator.IssueTo(&token.IssueRequest{
+		User: authenticated,
+		Claims: token.Claims{
+			StandardClaims: jwt.StandardClaims{
+				Audience: []string{clientID},
+			},
+			TokenType:           token.AuthorizationCode,
+			Nonce:               nonce,
+			Scopes:              scopes,
+			RedirectURI:         redirectURI,
+			CodeChallenge:       codeChallenge,
+			CodeChallengeMethod: codeChallengeMethod,
+		},
+		ExpiresIn: authorizationCodeMaxAge,
+	})
+	if err != nil {
+		informsError(oauth.NewServerError(err))
+		return
+	}
+
+	values := redirectURL.Query()
+	values.Set("code", code)
+	if state != "" {
+		values.Set("state", state)
+	}
+	redirectURL.RawQuery = values.Encode()
+	http.Redirect(response, req.Request, redirectURL.String(), http.StatusFound)
+}
+
+// codeGrant handle the Access Token Request of the Authorization Code Grant
+// for more details: https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.3
+func (h *handler) codeGrant(clientID string, req *restful.Request, response *restful.Response) {
+	code, _ := req.BodyParameter("code")
+	if code == "" {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(fmt.Errorf("code required")))
 		return
 	}
+
+	authorizeContext, err := h.tokenOperator.Verify(code)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+		return
+	}
+
+	if authorizeContext.TokenType != token.AuthorizationCode {
+		err = fmt.Errorf("ivalid token type %v want %v", authorizeContext.TokenType, token.AuthorizationCode)
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+		return
+	}
+
+	// The client MUST NOT use the authorization code more than once.
+	if err = h.tokenOperator.Revoke(code); err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	if len(authorizeContext.Audience) == 0 || authorizeContext.Audience[0] != clientID {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(fmt.Errorf("code was issued to another client")))
+		return
+	}
+
+	// the redirect_uri is REQUIRED if it was included in the authorization request, and the values MUST be identical
+	redirectURI, _ := req.BodyParameter("redirect_uri")
+	if authorizeContext.RedirectURI != "" && authorizeContext.RedirectURI != redirectURI {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(fmt.Errorf("redirect URI mismatch")))
+		return
+	}
+
+	if authorizeContext.CodeChallenge != "" {
+		codeVerifier, _ := req.BodyParameter("code_verifier")
+		if !oauth.VerifyCodeChallenge(authorizeContext.CodeChallengeMethod, authorizeContext.CodeChallenge, codeVerifier) {
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(fmt.Errorf("invalid code verifier")))
+			return
+		}
+	}
+
+	// If no openid scope value is present, the request is not an OpenID Connect request.
+	var idToken *idTokenRequest
+	if sliceutil.HasString(authorizeContext.Scopes, oauth.ScopeOpenID) {
+		idToken = &idTokenRequest{clientID: clientID, nonce: authorizeContext.Nonce}
+	}
+
+	result, err := h.issueTokenTo(authorizeContext.User, newSession(req, clientID), idToken)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	response.WriteEntity(result)
 }
 
 // passwordGrant handle Resource Owner Password Credentials Grant
@@ -167,7 +525,7 @@ func (h *handler) token(req *restful.Request, response *restful.Response) {
 // such as the device operating system or a highly privileged application.
 // The authorization server should take special care when enabling this
 // grant type and only allow it when other flows are not viable.
-func (h *handler) passwordGrant(username string, password string, req *restful.Request, response *restful.Response) {
+func (h *handler) passwordGrant(clientID string, username string, password string, idToken *idTokenRequest, req *restful.Request, response *restful.Response) {
 	authenticated, provider, err := h.passwordAuthenticator.Authenticate(req.Request.Context(), username, password)
 	if err != nil {
 		switch err {
@@ -187,7 +545,21 @@ func (h *handler) passwordGrant(username string, password string, req *restful.R
 		}
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	// the second factor of the aiscope accounts is verified before the tokens are issued
+	if provider == "" {
+		var challenged bool
+		authenticated, challenged, err = h.mfaOperator.Challenge(authenticated)
+		if err != nil {
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+			return
+		}
+		if challenged {
+			h.challengeMFA(authenticated, clientID, response)
+			return
+		}
+	}
+
+	result, err := h.issueTokenTo(authenticated, newSession(req, clientID), idToken)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -201,7 +573,99 @@ func (h *handler) passwordGrant(username string, password string, req *restful.R
 	response.WriteEntity(result)
 }
 
-func (h *handler) refreshTokenGrant(req *restful.Request, response *restful.Response) {
+// challengeMFA issues the MFA token identifying the login challenged by the second factor,
+// the token is bound to the client and can only be used once.
+func (h *handler) challengeMFA(authenticated user.Info, clientID string, response *restful.Response) {
+	claims := token.Claims{TokenType: token.MFAChallenge}
+	if clientID != "" {
+		claims.Audience = []string{clientID}
+	}
+	mfaToken, err := h.tokenOperator.IssueTo(&token.IssueRequest{
+		User:      authenticated,
+		Claims:    claims,
+		ExpiresIn: mfaChallengeMaxAge,
+	})
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusForbidden, MFAChallenge{
+		Error:     oauth.ErrorMFARequired,
+		MFAToken:  mfaToken,
+		ExpiresIn: int(mfaChallengeMaxAge.Seconds()),
+	})
+}
+
+// mfaGrant completes the login challenged by the second factor, the tokens are issued once the code is verified.
+// The MFA token is revoked whatever the result, the failed attempts have to start over with the password.
+func (h *handler) mfaGrant(clientID string, mfaToken string, code string, req *restful.Request, response *restful.Response) {
+	if mfaToken == "" || code == "" {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(fmt.Errorf("mfa token and code required")))
+		return
+	}
+
+	challenge, err := h.tokenOperator.Verify(mfaToken)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+		return
+	}
+
+	if challenge.TokenType != token.MFAChallenge {
+		err = fmt.Errorf("invalid token type %v want %v", challenge.TokenType, token.MFAChallenge)
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+		return
+	}
+
+	if err = h.tokenOperator.Revoke(mfaToken); err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	// the ID Token is issued to the client the login was challenged for
+	var idToken *idTokenRequest
+	if clientID != "" {
+		if len(challenge.Audience) == 0 || challenge.Audience[0] != clientID {
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(fmt.Errorf("mfa token was issued to another client")))
+			return
+		}
+		idToken = &idTokenRequest{clientID: clientID}
+	} else if len(challenge.Audience) > 0 {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(fmt.Errorf("mfa token was issued to client %s", challenge.Audience[0])))
+		return
+	}
+
+	username := challenge.User.GetName()
+	requestInfo, _ := request.RequestInfoFrom(req.Request.Context())
+	if err = h.mfaOperator.Verify(username, code); err != nil {
+		switch {
+		case err == auth.IncorrectMFACodeError:
+			// counted by the brute-force lockout of the account
+			if err := h.loginRecorder.RecordLogin(username, iamv1alpha2.Token, "", requestInfo.SourceIP, requestInfo.UserAgent, err); err != nil {
+				klog.Errorf("Failed to record unsuccessful login attempt for user %s, error: %v", username, err)
+			}
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+		case apierrors.IsBadRequest(err):
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+		default:
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		}
+		return
+	}
+
+	result, err := h.issueTokenTo(challenge.User, newSession(req, clientID), idToken)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	if err = h.loginRecorder.RecordLogin(username, iamv1alpha2.Token, "", requestInfo.SourceIP, requestInfo.UserAgent, nil); err != nil {
+		klog.Errorf("Failed to record successful login for user %s, error: %v", username, err)
+	}
+
+	response.WriteEntity(result)
+}
+
+func (h *handler) refreshTokenGrant(clientID string, req *restful.Request, response *restful.Response) {
 	refreshToken, err := req.BodyParameter("refresh_token")
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
@@ -246,7 +710,10 @@ func (h *handler) refreshTokenGrant(req *restful.Request, response *restful.Resp
 		authenticated = &user.DefaultInfo{Name: result.Items[0].(*iamv1alpha2.User).Name}
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	// the refreshed tokens replace the tokens of the session
+	session := newSession(req, clientID)
+	session.ID = verified.Id
+	result, err := h.issueTokenTo(authenticated, session, &idTokenRequest{clientID: clientID})
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -317,5 +784,262 @@ func (h *handler) login(request *restful.Request, response *restful.Response) {
 		api.HandleBadRequest(response, request, err)
 		return
 	}
-	h.passwordGrant(loginRequest.Username, loginRequest.Password, request, response)
-}
\ No newline at end of file
+	h.passwordGrant("", loginRequest.Username, loginRequest.Password, nil, request, response)
+}
+
+// mfa completes the login challenged by the login API with the second factor
+func (h *handler) mfa(req *restful.Request, response *restful.Response) {
+	var verification MFAVerification
+	if err := req.ReadEntity(&verification); err != nil {
+		api.HandleBadRequest(response, req, err)
+		return
+	}
+	h.mfaGrant("", verification.MFAToken, verification.Code, req, response)
+}
+
+// confirm completes the registration of the identity pre-registered at login, the identity is mapped to a new account,
+// or linked to the existing account with the password as proof. The tokens of the account are issued once confirmed.
+func (h *handler) confirm(req *restful.Request, response *restful.Response) {
+	preRegistration, ok := request.UserFrom(req.Request.Context())
+	if !ok || preRegistration.GetName() != iamv1alpha2.PreRegistrationUser {
+		api.HandleUnauthorized(response, req, apierrors.NewUnauthorized("registration token required"))
+		return
+	}
+
+	var confirm RegistrationConfirm
+	if err := req.ReadEntity(&confirm); err != nil {
+		api.HandleBadRequest(response, req, err)
+		return
+	}
+
+	var registered *iamv1alpha2.User
+	var err error
+	if confirm.Password != "" {
+		registered, err = h.registrationOperator.Link(preRegistration, confirm.Username, confirm.Password)
+	} else {
+		registered, err = h.registrationOperator.Register(preRegistration, confirm.Username, confirm.Email)
+	}
+	requestInfo, _ := request.RequestInfoFrom(req.Request.Context())
+	if err != nil {
+		switch err {
+		case auth.IncorrectPasswordError:
+			// counted by the brute-force lockout of the account
+			if err := h.loginRecorder.RecordLogin(confirm.Username, iamv1alpha2.Token, "", requestInfo.SourceIP, requestInfo.UserAgent, err); err != nil {
+				klog.Errorf("Failed to record unsuccessful login attempt for user %s, error: %v", confirm.Username, err)
+			}
+			api.HandleUnauthorized(response, req, apierrors.NewUnauthorized(err.Error()))
+		case auth.RateLimitExceededError:
+			api.HandleError(response, req, apierrors.NewTooManyRequests(err.Error(), 0))
+		case auth.AccountIsNotActiveError:
+			api.HandleForbidden(response, req, err)
+		default:
+			api.HandleError(response, req, err)
+		}
+		return
+	}
+
+	// the linked account may have the second factor enabled, the password alone does not complete the login
+	var authenticated user.Info = &user.DefaultInfo{Name: registered.Name, Groups: append([]string{}, registered.Spec.Groups...)}
+	authenticated, challenged, err := h.mfaOperator.Challenge(authenticated)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+	if challenged {
+		h.challengeMFA(authenticated, "", response)
+		return
+	}
+
+	result, err := h.issueTokenTo(authenticated, newSession(req, ""), nil)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	provider := preRegistration.GetExtra()[iamv1alpha2.ExtraIdentityProvider][0]
+	if err = h.loginRecorder.RecordLogin(registered.Name, iamv1alpha2.Token, provider, requestInfo.SourceIP, requestInfo.UserAgent, nil); err != nil {
+		klog.Errorf("Failed to record successful login for user %s, error: %v", registered.Name, err)
+	}
+
+	response.WriteEntity(result)
+}
+
+// introspect returns the meta-information of the token to the protected resources,
+// for more details: https://datatracker.ietf.org/doc/html/rfc7662
+func (h *handler) introspect(req *restful.Request, response *restful.Response) {
+	client, err := h.authenticateClient(req)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
+		return
+	}
+	// the protected resources MUST be authorized, the public clients cannot be authenticated
+	if client.Secret == "" {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(fmt.Errorf("client authentication required")))
+		return
+	}
+
+	tokenStr, _ := req.BodyParameter("token")
+	if tokenStr == "" {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(fmt.Errorf("token required")))
+		return
+	}
+
+	// If the introspection call is properly authorized but the token is not active,
+	// the authorization server MUST return {"active": false}, the other information SHOULD NOT be included.
+	verified, err := h.tokenOperator.Verify(tokenStr)
+	if err != nil || (verified.TokenType != token.AccessToken && verified.TokenType != token.StaticToken) ||
+		verified.User.GetName() == iamv1alpha2.PreRegistrationUser {
+		response.WriteEntity(Introspection{Active: false})
+		return
+	}
+	if _, err = h.im.DescribeUser(verified.User.GetName()); err != nil {
+		if !apierrors.IsNotFound(err) {
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+			return
+		}
+		response.WriteEntity(Introspection{Active: false})
+		return
+	}
+
+	response.WriteEntity(Introspection{
+		Active:    true,
+		Scope:     strings.Join(verified.Scopes, " "),
+		Username:  verified.User.GetName(),
+		TokenType: string(verified.TokenType),
+		ExpiresAt: verified.ExpiresAt,
+		IssuedAt:  verified.IssuedAt,
+		Subject:   verified.Subject,
+		Audience:  verified.Audience,
+		Issuer:    verified.Issuer,
+	})
+}
+
+// revoke invalidates the token, the client is notified of success even if the token is invalid,
+// the public clients can only revoke the tokens issued to them.
+// For more details: https://datatracker.ietf.org/doc/html/rfc7009
+func (h *handler) revoke(req *restful.Request, response *restful.Response) {
+	client, err := h.authenticateClient(req)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
+		return
+	}
+
+	tokenStr, _ := req.BodyParameter("token")
+	if tokenStr == "" {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(fmt.Errorf("token required")))
+		return
+	}
+
+	verified, err := h.tokenOperator.Verify(tokenStr)
+	if err != nil {
+		response.WriteHeader(http.StatusOK)
+		return
+	}
+	// the token bound to a client can only be revoked by that client,
+	// the personal access tokens are not issued to any client.
+	if len(verified.Audience) > 0 && verified.Audience[0] != client.Name {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewUnauthorizedClient(fmt.Errorf("token was issued to another client")))
+		return
+	}
+
+	if err = h.tokenOperator.Revoke(tokenStr); err != nil {
+		response.WriteHeaderAndEntity(http.StatusServiceUnavailable, oauth.NewServerError(err))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+// tokenReview authenticates the bearer token for the webhook token authentication of Kubernetes,
+// for more details: https://kubernetes.io/docs/reference/access-authn-authz/authentication/#webhook-token-authentication
+func (h *handler) tokenReview(req *restful.Request, response *restful.Response) {
+	var tokenReview authenticationv1.TokenReview
+	if err := req.ReadEntity(&tokenReview); err != nil {
+		api.HandleBadRequest(response, req, err)
+		return
+	}
+	if tokenReview.Spec.Token == "" {
+		api.HandleBadRequest(response, req, fmt.Errorf("token must not be empty"))
+		return
+	}
+
+	result := authenticationv1.TokenReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: authenticationv1.SchemeGroupVersion.String(),
+			Kind:       KindTokenReview,
+		},
+	}
+	if tokenReview.APIVersion != "" {
+		result.APIVersion = tokenReview.APIVersion
+	}
+
+	authenticated, ok, err := h.tokenAuthenticator.AuthenticateToken(req.Request.Context(), tokenReview.Spec.Token)
+	if err != nil || !ok || authenticated.User.GetName() == iamv1alpha2.PreRegistrationUser {
+		if err != nil {
+			result.Status.Error = err.Error()
+		}
+		response.WriteEntity(result)
+		return
+	}
+
+	extra := make(map[string]authenticationv1.ExtraValue)
+	for key, value := range authenticated.User.GetExtra() {
+		extra[key] = value
+	}
+	result.Status = authenticationv1.TokenReviewStatus{
+		Authenticated: true,
+		User: authenticationv1.UserInfo{
+			Username: authenticated.User.GetName(),
+			UID:      authenticated.User.GetUID(),
+			Groups:   authenticated.User.GetGroups(),
+			Extra:    extra,
+		},
+		Audiences: tokenReview.Spec.Audiences,
+	}
+	response.WriteEntity(result)
+}
+
+// discovery publishes the OpenID Provider Metadata, the relying parties can verify the ID Tokens offline.
+// See also https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfig
+func (h *handler) discovery(req *restful.Request, response *restful.Response) {
+	issuer := h.options.OAuthOptions.Issuer
+	result := discovery{
+		Issuer:                   issuer,
+		Auth:                     h.endpoint(req.Request, "/oauth/authorize"),
+		Token:                    h.endpoint(req.Request, "/oauth/token"),
+		Keys:                     h.endpoint(req.Request, "/oauth/keys"),
+		UserInfo:                 h.endpoint(req.Request, "/oauth/userinfo"),
+		EndSession:               h.endpoint(req.Request, "/oauth/logout"),
+		ResponseTypes:            []string{oauth.ResponseTypeCode},
+		GrantTypes:               []string{grantTypeCode, grantTypeRefreshToken, grantTypePassword, grantTypeMFAOTP},
+		Subjects:                 []string{"public"},
+		IDTokenAlgs:              []string{string(jose.RS256)},
+		CodeChallengeMethods:     []string{oauth.CodeChallengeMethodPlain, oauth.CodeChallengeMethodS256},
+		Scopes:                   []string{oauth.ScopeOpenID, oauth.ScopeEmail, oauth.ScopeProfile},
+		TokenEndpointAuthMethods: []string{"client_secret_post"},
+		Claims:                   []string{"iss", "sub", "aud", "iat", "exp", "nonce", "name", "email", "locale", "preferred_username"},
+	}
+	response.WriteEntity(result)
+}
+
+// keys publishes the JSON Web Key Set used to verify the ID Tokens
+func (h *handler) keys(req *restful.Request, response *restful.Response) {
+	jwks := jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{*h.tokenOperator.Keys().SigningKeyPub},
+	}
+	response.WriteEntity(jwks)
+}
+
+// endpoint returns the URL of the endpoint, the issuer is expected to be the external URL of the server,
+// otherwise the URL is resolved from the request.
+func (h *handler) endpoint(req *http.Request, path string) string {
+	issuer, err := url.Parse(h.options.OAuthOptions.Issuer)
+	if err == nil && issuer.IsAbs() {
+		return strings.TrimSuffix(issuer.String(), "/") + path
+	}
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, req.Host, path)
+}
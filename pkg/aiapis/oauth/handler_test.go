@@ -0,0 +1,152 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"golang.org/x/crypto/bcrypt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	k8scache "k8s.io/client-go/tools/cache"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authentication"
+	"aiscope/pkg/apiserver/authentication/oauth"
+	"aiscope/pkg/apiserver/authentication/token"
+	"aiscope/pkg/apiserver/request"
+	fakeaiscope "aiscope/pkg/client/clientset/versioned/fake"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/models/auth"
+	"aiscope/pkg/simple/client/cache"
+)
+
+// mfaEnabled challenges every user, the other operations are not needed
+type mfaEnabled struct {
+	auth.MFAOperator
+}
+
+func (m *mfaEnabled) Challenge(user user.Info) (user.Info, bool, error) {
+	return user, true, nil
+}
+
+func TestConfirmLinkChallengesMFA(t *testing.T) {
+	encryptedPassword, err := bcrypt.GenerateFromPassword([]byte("P@88w0rd"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active := iamv1alpha2.UserActive
+	admin := &iamv1alpha2.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+		Spec:       iamv1alpha2.UserSpec{EncryptedPassword: string(encryptedPassword)},
+		Status:     iamv1alpha2.UserStatus{State: &active},
+	}
+	indexer := k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{})
+	if err = indexer.Add(admin); err != nil {
+		t.Fatal(err)
+	}
+	client := fakeaiscope.NewSimpleClientset()
+	if _, err = client.IamV1alpha2().Users().Create(context.Background(), admin, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenOperator := auth.NewTokenOperator(cache.NewSimpleCache(), issuer, options)
+	h := newHandler(nil, tokenOperator, nil, nil,
+		auth.NewRegistrationOperator(client, iamv1alpha2listers.NewUserLister(indexer)), &mfaEnabled{},
+		nil, nil, nil, options)
+
+	preRegistration := &user.DefaultInfo{
+		Name: iamv1alpha2.PreRegistrationUser,
+		Extra: map[string][]string{
+			iamv1alpha2.ExtraIdentityProvider: {"github"},
+			iamv1alpha2.ExtraUID:              {"100"},
+		},
+	}
+	httpRequest := httptest.NewRequest(http.MethodPost, "/oauth/confirm", strings.NewReader(`{"username":"admin","password":"P@88w0rd"}`))
+	httpRequest.Header.Set("Content-Type", restful.MIME_JSON)
+	ctx := request.WithRequestInfo(httpRequest.Context(), &request.RequestInfo{})
+	httpRequest = httpRequest.WithContext(request.WithUser(ctx, preRegistration))
+	recorder := httptest.NewRecorder()
+	response := restful.NewResponse(recorder)
+	response.SetRequestAccepts(restful.MIME_JSON)
+
+	h.confirm(restful.NewRequest(httpRequest), response)
+
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusForbidden, recorder.Body.String())
+	}
+	var challenge MFAChallenge
+	if err = json.Unmarshal(recorder.Body.Bytes(), &challenge); err != nil {
+		t.Fatal(err)
+	}
+	if challenge.Type != "mfa_required" || challenge.MFAToken == "" {
+		t.Fatalf("unexpected challenge %s", recorder.Body.String())
+	}
+	verified, err := tokenOperator.Verify(challenge.MFAToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.TokenType != token.MFAChallenge || verified.User.GetName() != "admin" {
+		t.Errorf("the challenge should be issued to the linked account, got %+v", verified)
+	}
+}
+
+func TestRevokeChecksIssuedClient(t *testing.T) {
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	options.OAuthOptions.AccessTokenMaxAge = time.Hour
+	options.OAuthOptions.Clients = []oauth.Client{{Name: "kubectl"}, {Name: "console", Secret: "secret"}}
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenOperator := auth.NewTokenOperator(cache.NewSimpleCache(), issuer, options)
+	h := newHandler(nil, tokenOperator, nil, nil, nil, nil, nil, nil, nil, options)
+
+	accessToken, _, err := tokenOperator.IssueSessionTo(&user.DefaultInfo{Name: "admin"}, &auth.Session{ClientID: "kubectl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		form        url.Values
+		code        int
+		revoked     bool
+	}{
+		{"wildcard", url.Values{"client_id": {"kubectl"}, "token": {"*"}}, http.StatusOK, false},
+		{"another client", url.Values{"client_id": {"console"}, "client_secret": {"secret"}, "token": {accessToken}}, http.StatusBadRequest, false},
+		{"issued client", url.Values{"client_id": {"kubectl"}, "token": {accessToken}}, http.StatusOK, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			httpRequest := httptest.NewRequest(http.MethodPost, "/oauth/revoke", strings.NewReader(test.form.Encode()))
+			httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			recorder := httptest.NewRecorder()
+			response := restful.NewResponse(recorder)
+			response.SetRequestAccepts(restful.MIME_JSON)
+
+			h.revoke(restful.NewRequest(httpRequest), response)
+
+			if recorder.Code != test.code {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, test.code, recorder.Body.String())
+			}
+			if _, err := tokenOperator.Verify(accessToken); (err != nil) != test.revoked {
+				t.Errorf("revoked = %v, want %v", err != nil, test.revoked)
+			}
+		})
+	}
+}
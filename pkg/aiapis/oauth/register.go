@@ -4,11 +4,15 @@ import (
 	"aiscope/pkg/api"
 	"aiscope/pkg/apiserver/authentication"
 	"aiscope/pkg/apiserver/authentication/oauth"
+	"aiscope/pkg/apiserver/authentication/token"
 	"aiscope/pkg/constants"
 	"aiscope/pkg/models/auth"
 	"aiscope/pkg/models/iam/im"
 	"github.com/emicklei/go-restful"
 	restfulspec "github.com/emicklei/go-restful-openapi"
+	"gopkg.in/square/go-jose.v2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"net/http"
 )
 
@@ -16,6 +20,10 @@ const contentTypeFormData = "application/x-www-form-urlencoded"
 
 func AddToContainer(container *restful.Container, im im.IdentityManagementInterface,
 	tokenOperator auth.TokenManagementInterface,
+	tokenAuthenticator authenticator.Token,
+	grantOperator auth.GrantOperator,
+	registrationOperator auth.RegistrationOperator,
+	mfaOperator auth.MFAOperator,
 	oauth2Authenticator auth.OAuthAuthenticator,
 	passwordAuthenticator auth.PasswordAuthenticator,
 	loginRecorder auth.LoginRecorder,
@@ -25,7 +33,30 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Consumes(restful.MIME_JSON).
 		Produces(restful.MIME_JSON)
 
-	handler := newHandler(tokenOperator, oauth2Authenticator, passwordAuthenticator, loginRecorder, options)
+	handler := newHandler(im, tokenOperator, tokenAuthenticator, grantOperator, registrationOperator, mfaOperator, oauth2Authenticator, passwordAuthenticator, loginRecorder, options)
+
+	// https://datatracker.ietf.org/doc/html/rfc6749#section-3.1
+	authorizeParams := func(builder *restful.RouteBuilder, parameter func(name, description string) *restful.Parameter) *restful.RouteBuilder {
+		return builder.
+			Param(parameter("response_type", "The value MUST be set to \"code\".").Required(true)).
+			Param(parameter("client_id", "The client identifier.").Required(true)).
+			Param(parameter("redirect_uri", "The redirection URI, MUST match one of the URIs registered for the client.").Required(false)).
+			Param(parameter("scope", "The scope of the access request, a list of space-delimited strings.").Required(false)).
+			Param(parameter("state", "An opaque value used by the client to maintain state between the request and callback.").Required(false)).
+			Param(parameter("code_challenge", "The PKCE code challenge, required for the clients without secret.").Required(false)).
+			Param(parameter("code_challenge_method", "The PKCE code challenge method, plain or S256. Defaults to plain.").Required(false)).
+			To(handler.authorize).
+			Returns(http.StatusFound, http.StatusText(http.StatusFound), nil).
+			Returns(http.StatusOK, "The user is prompted to approve the request.", AuthorizeConsent{}).
+			Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag})
+	}
+	ws.Route(authorizeParams(ws.GET("/authorize"), ws.QueryParameter).
+		Doc("The authorization endpoint is used to interact with the resource owner and obtain an authorization grant."))
+	ws.Route(authorizeParams(ws.POST("/authorize"), ws.FormParameter).
+		Consumes(contentTypeFormData).
+		Doc("The authorization endpoint is used to interact with the resource owner and obtain an authorization grant. " +
+			"The user approves or denies the request of the client by posting it with approve set to true or false.").
+		Param(ws.FormParameter("approve", "Whether the user approves the request of the client.").Required(false)))
 
 	ws.Route(ws.GET("/callback/{callback}").
 		Doc("OAuth callback API, the path param callback is config by identity provider").
@@ -46,6 +77,22 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Returns(http.StatusOK, api.StatusOK, oauth.Token{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
 
+	ws.Route(ws.POST("/confirm").
+		Doc("Confirm the registration of the identity pre-registered at login with the pre-registration token. "+
+			"The identity is mapped to a new account, or linked to the existing account if the password is specified.").
+		Reads(RegistrationConfirm{}).
+		To(handler.confirm).
+		Returns(http.StatusOK, api.StatusOK, oauth.Token{}).
+		Returns(http.StatusForbidden, "The linked account requires the second factor to complete the login.", MFAChallenge{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	ws.Route(ws.POST("/mfa").
+		Doc("Complete the login challenged by the second factor with the MFA token returned by the login API.").
+		Reads(MFAVerification{}).
+		To(handler.mfa).
+		Returns(http.StatusOK, api.StatusOK, oauth.Token{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
 	// https://datatracker.ietf.org/doc/html/rfc6749#section-3.2
 	ws.Route(ws.POST("/token").
 		Consumes(contentTypeFormData).
@@ -60,12 +107,73 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Param(ws.FormParameter("username", "The resource owner username.").Required(false)).
 		Param(ws.FormParameter("password", "The resource owner password.").Required(false)).
 		Param(ws.FormParameter("code", "Valid authorization code.").Required(false)).
+		Param(ws.FormParameter("redirect_uri", "The redirection URI included in the authorization request.").Required(false)).
+		Param(ws.FormParameter("code_verifier", "The PKCE code verifier.").Required(false)).
+		Param(ws.FormParameter("mfa_token", "The MFA token returned by the password grant challenged by the second factor.").Required(false)).
+		Param(ws.FormParameter("otp", "The TOTP code or a recovery code of the user.").Required(false)).
 		To(handler.token).
 		Returns(http.StatusOK, http.StatusText(http.StatusOK), &oauth.Token{}).
+		Returns(http.StatusForbidden, "The second factor is required to complete the login.", MFAChallenge{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	// https://datatracker.ietf.org/doc/html/rfc7662#section-2
+	ws.Route(ws.POST("/introspect").
+		Consumes(contentTypeFormData).
+		Doc("Token introspection, the protected resources query the state of the token with the client credentials.").
+		Param(ws.FormParameter("token", "The string value of the token.").Required(true)).
+		Param(ws.FormParameter("token_type_hint", "A hint about the type of the token submitted for introspection.").Required(false)).
+		Param(ws.FormParameter("client_id", "Valid client credential.").Required(true)).
+		Param(ws.FormParameter("client_secret", "Valid client credential.").Required(true)).
+		To(handler.introspect).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), Introspection{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	// https://datatracker.ietf.org/doc/html/rfc7009#section-2
+	ws.Route(ws.POST("/revoke").
+		Consumes(contentTypeFormData).
+		Doc("Token revocation, the client notifies the server that the token is no longer needed.").
+		Param(ws.FormParameter("token", "The token that the client wants to get revoked.").Required(true)).
+		Param(ws.FormParameter("token_type_hint", "A hint about the type of the token submitted for revocation.").Required(false)).
+		Param(ws.FormParameter("client_id", "Valid client credential.").Required(true)).
+		Param(ws.FormParameter("client_secret", "Valid client credential.").Required(false)).
+		To(handler.revoke).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	// https://kubernetes.io/docs/reference/access-authn-authz/authentication/#webhook-token-authentication
+	ws.Route(ws.POST("/authenticate").
+		Doc("TokenReview webhook, authenticates the bearer token for Kubernetes and the proxies.").
+		Reads(authenticationv1.TokenReview{}).
+		To(handler.tokenReview).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), authenticationv1.TokenReview{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	ws.Route(ws.GET("/keys").
+		Doc("The JSON Web Key Set used to verify the ID Tokens issued by the server.").
+		To(handler.keys).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), jose.JSONWebKeySet{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	// https://openid.net/specs/openid-connect-core-1_0.html#UserInfo
+	ws.Route(ws.GET("/userinfo").
+		Doc("UserInfo Endpoint is an OAuth 2.0 Protected Resource that returns Claims about the authenticated End-User.").
+		To(handler.userinfo).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), token.Claims{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
 
 	container.Add(ws)
 
+	// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderConfig
+	wellKnown := &restful.WebService{}
+	wellKnown.Path("/.well-known").
+		Produces(restful.MIME_JSON)
+	wellKnown.Route(wellKnown.GET("/openid-configuration").
+		Doc("The OpenID Provider Metadata.").
+		To(handler.discovery).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), discovery{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+	container.Add(wellKnown)
+
 	// legacy auth API
 	legacy := &restful.WebService{}
 	legacy.Path("/aiapis/iam.aiscope/v1alpha2/login").
@@ -77,6 +185,7 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Doc("Aiscope APIs support token-based authentication via the Authtoken request header. The POST Login API is used to retrieve the authentication token. After the authentication token is obtained, it must be inserted into the Authtoken header for all requests.").
 		Reads(LoginRequest{}).
 		Returns(http.StatusOK, api.StatusOK, oauth.Token{}).
+		Returns(http.StatusForbidden, "The second factor is required to complete the login.", MFAChallenge{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
 	container.Add(legacy)
 
@@ -0,0 +1,60 @@
+package v1alpha2
+
+import (
+	"aiscope/pkg/api"
+	"aiscope/pkg/apiserver/request"
+	"aiscope/pkg/models/iam/im"
+	"aiscope/pkg/models/kubeconfig"
+	servererr "aiscope/pkg/server/errors"
+	"fmt"
+	"github.com/emicklei/go-restful"
+)
+
+type resourceHandler struct {
+	im                 im.IdentityManagementInterface
+	kubeconfigOperator kubeconfig.Interface
+}
+
+func newResourceHandler(im im.IdentityManagementInterface, kubeconfigOperator kubeconfig.Interface) *resourceHandler {
+	return &resourceHandler{
+		im:                 im,
+		kubeconfigOperator: kubeconfigOperator,
+	}
+}
+
+func (h *resourceHandler) GetKubeconfig(req *restful.Request, resp *restful.Response) {
+	username := req.PathParameter("user")
+
+	// the kubeconfig carries the client credential, only the owner can download it
+	operator, ok := request.UserFrom(req.Request.Context())
+	if !ok || operator.GetName() != username {
+		api.HandleForbidden(resp, req, fmt.Errorf("the kubeconfig of user %s is only available to the user itself", username))
+		return
+	}
+
+	kubectlConfig, err := h.kubeconfigOperator.GetKubeConfig(username)
+	if err != nil {
+		api.HandleError(resp, req, err)
+		return
+	}
+
+	resp.Header().Set(restful.HEADER_ContentType, "text/plain")
+	resp.Write([]byte(kubectlConfig))
+}
+
+func (h *resourceHandler) RegenerateKubeconfig(req *restful.Request, resp *restful.Response) {
+	username := req.PathParameter("user")
+
+	user, err := h.im.DescribeUser(username)
+	if err != nil {
+		api.HandleError(resp, req, err)
+		return
+	}
+
+	if err = h.kubeconfigOperator.RegenerateKubeConfig(user); err != nil {
+		api.HandleError(resp, req, err)
+		return
+	}
+
+	resp.WriteEntity(servererr.None)
+}
@@ -0,0 +1,54 @@
+package v1alpha2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"aiscope/pkg/apiserver/filters"
+	"aiscope/pkg/models/kubeconfig"
+)
+
+// kubeconfigs returns the kubeconfig of any user, the other operations are not needed
+type kubeconfigs struct {
+	kubeconfig.Interface
+}
+
+func (k *kubeconfigs) GetKubeConfig(username string) (string, error) {
+	return "kubeconfig of " + username, nil
+}
+
+func TestGetKubeconfig(t *testing.T) {
+	container := restful.NewContainer()
+	if err := AddToContainer(container, nil, &kubeconfigs{}); err != nil {
+		t.Fatal(err)
+	}
+	alice := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+	})
+	server := filters.WithAuthentication(container, alice)
+
+	tests := []struct {
+		description string
+		username    string
+		expected    int
+	}{
+		{"own kubeconfig", "alice", http.StatusOK},
+		{"kubeconfig of others", "bob", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/aiapis/resources.aiscope/v1alpha2/users/"+test.username+"/kubeconfig", nil)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+			if recorder.Code != test.expected {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, test.expected, recorder.Body.String())
+			}
+		})
+	}
+}
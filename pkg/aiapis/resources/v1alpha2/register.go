@@ -0,0 +1,43 @@
+package v1alpha2
+
+import (
+	"aiscope/pkg/api"
+	"aiscope/pkg/apiserver/runtime"
+	"aiscope/pkg/constants"
+	"aiscope/pkg/models/iam/im"
+	"aiscope/pkg/models/kubeconfig"
+	"aiscope/pkg/server/errors"
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"net/http"
+)
+
+const (
+	GroupName = "resources.aiscope"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
+
+func AddToContainer(container *restful.Container, im im.IdentityManagementInterface, kubeconfigOperator kubeconfig.Interface) error {
+	ws := runtime.NewWebService(GroupVersion)
+	handler := newResourceHandler(im, kubeconfigOperator)
+
+	ws.Route(ws.GET("/users/{user}/kubeconfig").
+		To(handler.GetKubeconfig).
+		Produces("text/plain", restful.MIME_JSON).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Get the kubeconfig of the current user.").
+		Returns(http.StatusOK, api.StatusOK, "").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	ws.Route(ws.POST("/users/{user}/kubeconfig").
+		To(handler.RegenerateKubeconfig).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Regenerate the client certificate in the kubeconfig of the specified user.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+
+	container.Add(ws)
+	return nil
+}
@@ -3,26 +3,35 @@ package v1alpha2
 import (
 	"aiscope/pkg/api"
 	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
+	"aiscope/pkg/apiserver/authorization/authorizer"
 	"aiscope/pkg/apiserver/query"
+	"aiscope/pkg/apiserver/request"
 	aiscope "aiscope/pkg/client/clientset/versioned"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/informers"
+	"aiscope/pkg/models/iam/group"
 	"aiscope/pkg/models/tenant"
+	servererr "aiscope/pkg/server/errors"
 	"fmt"
 	"github.com/emicklei/go-restful"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apiserver/pkg/authentication/user"
-	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 )
 
 type tenantHandler struct {
 	tenant       tenant.Interface
+	userLister   iamv1alpha2listers.UserLister
+	groupLister  iamv1alpha2listers.GroupLister
 }
 
-func newTenantHandler(aiclient aiscope.Interface, k8sclient kubernetes.Interface) *tenantHandler {
+func newTenantHandler(aiclient aiscope.Interface, k8sclient kubernetes.Interface, informers informers.InformerFactory, authorizer authorizer.Authorizer) *tenantHandler {
 	return &tenantHandler{
-		tenant: tenant.NewOperator(aiclient, k8sclient),
+		tenant:      tenant.NewOperator(aiclient, k8sclient, informers, authorizer),
+		userLister:  informers.AIScopeSharedInformerFactory().Iam().V1alpha2().Users().Lister(),
+		groupLister: informers.AIScopeSharedInformerFactory().Iam().V1alpha2().Groups().Lister(),
 	}
 }
 
@@ -52,6 +61,107 @@ func (h *tenantHandler) CreateWorkspace(request *restful.Request, response *rest
 	response.WriteEntity(created)
 }
 
+func (h *tenantHandler) ListWorkspaces(req *restful.Request, resp *restful.Response) {
+	queryParam := query.ParseQueryParameter(req)
+
+	requestUser, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		err := fmt.Errorf("cannot obtain user info")
+		klog.Errorln(err)
+		api.HandleForbidden(resp, nil, err)
+		return
+	}
+
+	result, err := h.tenant.ListWorkspaces(requestUser, queryParam)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteEntity(result)
+}
+
+func (h *tenantHandler) DescribeWorkspace(req *restful.Request, resp *restful.Response) {
+	workspaceName := req.PathParameter("workspace")
+
+	workspace, err := h.tenant.DescribeWorkspace(workspaceName)
+	if err != nil {
+		klog.Error(err)
+		if errors.IsNotFound(err) {
+			api.HandleNotFound(resp, req, err)
+			return
+		}
+		api.HandleInternalError(resp, req, err)
+		return
+	}
+
+	resp.WriteEntity(workspace)
+}
+
+func (h *tenantHandler) UpdateWorkspace(req *restful.Request, resp *restful.Response) {
+	workspaceName := req.PathParameter("workspace")
+	var workspace tenantv1alpha2.Workspace
+
+	err := req.ReadEntity(&workspace)
+	if err != nil {
+		klog.Error(err)
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	if workspaceName != workspace.Name {
+		err := fmt.Errorf("the name of the object (%s) does not match the name on the URL (%s)", workspace.Name, workspaceName)
+		klog.Errorf("%+v", err)
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	updated, err := h.tenant.UpdateWorkspace(&workspace)
+	if err != nil {
+		klog.Error(err)
+		api.HandleError(resp, req, err)
+		return
+	}
+
+	resp.WriteEntity(updated)
+}
+
+func (h *tenantHandler) PatchWorkspace(req *restful.Request, resp *restful.Response) {
+	workspaceName := req.PathParameter("workspace")
+	var workspace tenantv1alpha2.Workspace
+
+	err := req.ReadEntity(&workspace)
+	if err != nil {
+		klog.Error(err)
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	workspace.Name = workspaceName
+
+	patched, err := h.tenant.PatchWorkspace(&workspace)
+	if err != nil {
+		klog.Error(err)
+		api.HandleError(resp, req, err)
+		return
+	}
+
+	resp.WriteEntity(patched)
+}
+
+func (h *tenantHandler) DeleteWorkspace(req *restful.Request, resp *restful.Response) {
+	workspaceName := req.PathParameter("workspace")
+
+	err := h.tenant.DeleteWorkspace(workspaceName)
+	if err != nil {
+		klog.Error(err)
+		api.HandleError(resp, req, err)
+		return
+	}
+
+	resp.WriteEntity(servererr.None)
+}
+
 func (h *tenantHandler) CreateNamespace(request *restful.Request, response *restful.Response) {
 	workspace := request.PathParameter("workspace")
 	var namespace corev1.Namespace
@@ -86,8 +196,16 @@ func (h *tenantHandler) ListNamespaces(req *restful.Request, resp *restful.Respo
 
 	var workspaceMember user.Info
 	if username := req.PathParameter("workspacemember"); username != "" {
+		member, err := h.userLister.Get(username)
+		if err != nil {
+			klog.Error(err)
+			api.HandleError(resp, req, err)
+			return
+		}
+		// the namespaces bound to the groups of the member are listed as well
 		workspaceMember = &user.DefaultInfo{
-			Name: username,
+			Name:   member.Name,
+			Groups: append(group.ResolveGroups(h.groupLister, member.Spec.Groups), user.AllAuthenticated),
 		}
 	} else {
 		requestUser, ok := request.UserFrom(req.Request.Context())
@@ -3,9 +3,12 @@ package v1alpha2
 import (
 	"aiscope/pkg/api"
 	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
+	"aiscope/pkg/apiserver/authorization/authorizer"
 	"aiscope/pkg/apiserver/runtime"
 	aiscope "aiscope/pkg/client/clientset/versioned"
 	"aiscope/pkg/constants"
+	"aiscope/pkg/informers"
+	"aiscope/pkg/server/errors"
 	"github.com/emicklei/go-restful"
 	restfulspec "github.com/emicklei/go-restful-openapi"
 	corev1 "k8s.io/api/core/v1"
@@ -13,9 +16,10 @@ import (
 	"net/http"
 )
 
-func AddToContainer(container *restful.Container, aiscope aiscope.Interface, k8sclient kubernetes.Interface) error {
+func AddToContainer(container *restful.Container, aiscope aiscope.Interface, k8sclient kubernetes.Interface, informers informers.InformerFactory, authorizer authorizer.Authorizer) error {
 	ws := runtime.NewWebService(tenantv1alpha2.SchemeGroupVersion)
-	handler := newTenantHandler(aiscope, k8sclient)
+	handler := newTenantHandler(aiscope, k8sclient, informers, authorizer)
+	mimePatch := []string{restful.MIME_JSON, runtime.MimeMergePatchJson, runtime.MimeJsonPatchJson}
 
 	ws.Route(ws.POST("/workspaces").
 		To(handler.CreateWorkspace).
@@ -24,6 +28,43 @@ func AddToContainer(container *restful.Container, aiscope aiscope.Interface, k8s
 		Doc("Create workspace.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceTag}))
 
+	ws.Route(ws.GET("/workspaces").
+		To(handler.ListWorkspaces).
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{tenantv1alpha2.Workspace{}}}).
+		Doc("List all workspaces that belongs to the current user").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}").
+		To(handler.DescribeWorkspace).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Returns(http.StatusOK, api.StatusOK, tenantv1alpha2.Workspace{}).
+		Doc("Describe workspace.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceTag}))
+
+	ws.Route(ws.PUT("/workspaces/{workspace}").
+		To(handler.UpdateWorkspace).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Reads(tenantv1alpha2.Workspace{}).
+		Returns(http.StatusOK, api.StatusOK, tenantv1alpha2.Workspace{}).
+		Doc("Update workspace.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceTag}))
+
+	ws.Route(ws.PATCH("/workspaces/{workspace}").
+		To(handler.PatchWorkspace).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Consumes(mimePatch...).
+		Reads(tenantv1alpha2.Workspace{}).
+		Returns(http.StatusOK, api.StatusOK, tenantv1alpha2.Workspace{}).
+		Doc("Update the specified workspace partially.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceTag}))
+
+	ws.Route(ws.DELETE("/workspaces/{workspace}").
+		To(handler.DeleteWorkspace).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Doc("Delete workspace.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceTag}))
+
 	ws.Route(ws.POST("/workspaces/{workspace}/namespaces").
 		To(handler.CreateNamespace).
 		Param(ws.PathParameter("workspace", "workspace name")).
@@ -44,8 +85,7 @@ func AddToContainer(container *restful.Container, aiscope aiscope.Interface, k8s
 		Param(ws.PathParameter("workspace", "workspace name")).
 		Param(ws.PathParameter("workspacemember", "workspacemember username")).
 		Doc("List the namespaces of the specified workspace for the workspace member").
-		Reads(corev1.Namespace{}).
-		Returns(http.StatusOK, api.StatusOK, corev1.Namespace{}).
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceTag}))
 
 	container.Add(ws)
@@ -0,0 +1,99 @@
+package v1alpha2
+
+import (
+	"aiscope/pkg/api"
+	"aiscope/pkg/apiserver/authorization/authorizer"
+	"aiscope/pkg/apiserver/request"
+	"aiscope/pkg/models/terminal"
+	"fmt"
+	"github.com/emicklei/go-restful"
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"net/http"
+)
+
+// terminalProtocol is the subprotocol the web terminal speaks, browsers can't set the authorization header
+// of websocket requests, the bearer token is passed along as the base64url.bearer.authorization.k8s.io subprotocol.
+const terminalProtocol = "terminal.aiscope"
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{terminalProtocol},
+	// credentials are not carried by cookies, cross origin requests are safe,
+	// the sessions are authorized explicitly before the upgrade
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+type terminalHandler struct {
+	terminaler terminal.Interface
+	authorizer authorizer.Authorizer
+}
+
+func newTerminalHandler(terminaler terminal.Interface, authorizer authorizer.Authorizer) *terminalHandler {
+	return &terminalHandler{terminaler: terminaler, authorizer: authorizer}
+}
+
+func (h *terminalHandler) HandleTerminalSession(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	podName := req.PathParameter("pod")
+	containerName := req.QueryParameter("container")
+	shell := req.QueryParameter("shell")
+
+	// the session execs in the container on behalf of the user, the user must be allowed to create pods/exec like kubectl
+	operator, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		api.HandleForbidden(resp, req, fmt.Errorf("cannot obtain user info"))
+		return
+	}
+	decision, _, err := h.authorizer.Authorize(authorizer.AttributesRecord{
+		User:            operator,
+		Verb:            "create",
+		APIGroup:        corev1.GroupName,
+		APIVersion:      corev1.SchemeGroupVersion.Version,
+		Namespace:       namespace,
+		Resource:        "pods",
+		Subresource:     "exec",
+		Name:            podName,
+		ResourceRequest: true,
+		ResourceScope:   request.NamespaceScope,
+	})
+	if err != nil {
+		api.HandleInternalError(resp, req, err)
+		return
+	}
+	if decision != authorizer.DecisionAllow {
+		api.HandleForbidden(resp, req, fmt.Errorf("user %s is not allowed to exec in pod %s/%s", operator.GetName(), namespace, podName))
+		return
+	}
+
+	conn, err := upgrader.Upgrade(resp.ResponseWriter, req.Request, nil)
+	if err != nil {
+		klog.Warning(err)
+		return
+	}
+
+	h.terminaler.HandleSession(shell, namespace, podName, containerName, conn)
+}
+
+func (h *terminalHandler) HandleKubectlSession(req *restful.Request, resp *restful.Response) {
+	username := req.PathParameter("user")
+
+	// the kubectl pod runs with the credential of the user, only the user itself can attach to it
+	operator, ok := request.UserFrom(req.Request.Context())
+	if !ok || operator.GetName() != username {
+		api.HandleForbidden(resp, req, fmt.Errorf("the kubectl terminal of user %s is only available to the user itself", username))
+		return
+	}
+
+	conn, err := upgrader.Upgrade(resp.ResponseWriter, req.Request, nil)
+	if err != nil {
+		klog.Warning(err)
+		return
+	}
+
+	h.terminaler.HandleKubectlSession(username, conn)
+}
@@ -0,0 +1,58 @@
+package v1alpha2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"aiscope/pkg/apiserver/authorization/authorizer"
+	"aiscope/pkg/apiserver/filters"
+	"aiscope/pkg/models/terminal"
+)
+
+func TestTerminalSessions(t *testing.T) {
+	// alice is only allowed to exec in the pods of the namespace dev
+	exec := authorizer.AuthorizerFunc(func(a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetVerb() == "create" && a.GetResource() == "pods" && a.GetSubresource() == "exec" && a.GetNamespace() == "dev" {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionDeny, "", nil
+	})
+	container := restful.NewContainer()
+	// the sessions are never established, the requests are not websocket handshakes
+	var terminaler terminal.Interface
+	if err := AddToContainer(container, terminaler, exec); err != nil {
+		t.Fatal(err)
+	}
+	alice := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+	})
+	server := filters.WithAuthentication(container, alice)
+
+	tests := []struct {
+		description string
+		path        string
+		expected    int
+	}{
+		// the upgrade of the authorized requests fails
+		{"exec in allowed namespace", "/namespaces/dev/pods/app/exec", http.StatusBadRequest},
+		{"exec in other namespace", "/namespaces/prod/pods/app/exec", http.StatusForbidden},
+		{"own kubectl", "/users/alice/kubectl", http.StatusBadRequest},
+		{"kubectl of others", "/users/bob/kubectl", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/aiapis/terminal.aiscope/v1alpha2"+test.path, nil)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+			if recorder.Code != test.expected {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, test.expected, recorder.Body.String())
+			}
+		})
+	}
+}
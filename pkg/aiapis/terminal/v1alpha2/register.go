@@ -0,0 +1,44 @@
+package v1alpha2
+
+import (
+	"aiscope/pkg/apiserver/authorization/authorizer"
+	"aiscope/pkg/apiserver/runtime"
+	"aiscope/pkg/constants"
+	"aiscope/pkg/models/terminal"
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"net/http"
+)
+
+const (
+	GroupName = "terminal.aiscope"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
+
+func AddToContainer(container *restful.Container, terminaler terminal.Interface, authorizer authorizer.Authorizer) error {
+	ws := runtime.NewWebService(GroupVersion)
+	handler := newTerminalHandler(terminaler, authorizer)
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pods/{pod}/exec").
+		To(handler.HandleTerminalSession).
+		Param(ws.PathParameter("namespace", "namespace of the pod")).
+		Param(ws.PathParameter("pod", "name of the pod")).
+		Param(ws.QueryParameter("container", "name of the container, defaults to the first container of the pod").Required(false)).
+		Param(ws.QueryParameter("shell", "shell to exec, bash or sh").Required(false).DefaultValue("sh")).
+		Doc("Open a web terminal session in the container of the pod over websocket.").
+		Returns(http.StatusSwitchingProtocols, http.StatusText(http.StatusSwitchingProtocols), nil).
+		Returns(http.StatusForbidden, "The user is not allowed to create pods/exec in the pod.", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}))
+
+	ws.Route(ws.GET("/users/{user}/kubectl").
+		To(handler.HandleKubectlSession).
+		Param(ws.PathParameter("user", "username")).
+		Doc("Open a web kubectl session of the current user over websocket.").
+		Returns(http.StatusSwitchingProtocols, http.StatusText(http.StatusSwitchingProtocols), nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}))
+
+	container.Add(ws)
+	return nil
+}
@@ -17,9 +17,27 @@ limitations under the License.
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	ResourceKindCodeServer     = "CodeServer"
+	ResourceSingularCodeServer = "codeserver"
+	ResourcePluralCodeServer   = "codeservers"
+	CodeServerLabel            = "aiscope.io/codeserver"
+)
+
+type CodeServerAuthType string
+
+const (
+	// CodeServerAuthPassword protects the code server with a password, the password is
+	// read from PasswordSecretRef or generated into a secret named after the CodeServer
+	CodeServerAuthPassword CodeServerAuthType = "password"
+	// CodeServerAuthNone disables the authentication of the code server
+	CodeServerAuthNone CodeServerAuthType = "none"
+)
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -27,9 +45,19 @@ import (
 type CodeServerSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-
-	// Foo is an example field of CodeServer. Edit codeserver_types.go to remove/update
-	Foo string `json:"foo,omitempty"`
+	Image            string                      `json:"image,omitempty"`
+	Resources        corev1.ResourceRequirements `json:"resources,omitempty"`
+	VolumeSize       string                      `json:"volumeSize,omitempty"`
+	StorageClassName string                      `json:"storageClassName,omitempty"`
+	// GitRepository is cloned into the workspace on start if the workspace is empty
+	GitRepository string `json:"gitRepository,omitempty"`
+	GitBranch     string `json:"gitBranch,omitempty"`
+	// AuthType is password or none, code-server has no token authentication,
+	// the password generated when PasswordSecretRef is not set serves as the access token.
+	// +kubebuilder:validation:Enum=password;none
+	AuthType          CodeServerAuthType        `json:"authType,omitempty"`
+	PasswordSecretRef *corev1.SecretKeySelector `json:"passwordSecretRef,omitempty"`
+	URL               string                    `json:"url,omitempty"`
 }
 
 // CodeServerStatus defines the observed state of CodeServer
@@ -41,6 +69,8 @@ type CodeServerStatus struct {
 // +genclient
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="IMAGE",type="string",JSONPath=".spec.image"
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url"
 
 // CodeServer is the Schema for the codeservers API
 type CodeServer struct {
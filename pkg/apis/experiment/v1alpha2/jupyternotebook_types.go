@@ -17,9 +17,17 @@ limitations under the License.
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	ResourceKindJupyterNotebook     = "JupyterNotebook"
+	ResourceSingularJupyterNotebook = "jupyternotebook"
+	ResourcePluralJupyterNotebook   = "jupyternotebooks"
+	JupyterNotebookLabel            = "aiscope.io/jupyternotebook"
+)
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -27,9 +35,17 @@ import (
 type JupyterNotebookSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-
-	// Foo is an example field of JupyterNotebook. Edit jupyternotebook_types.go to remove/update
-	Foo string `json:"foo,omitempty"`
+	Image            string                      `json:"image,omitempty"`
+	Resources        corev1.ResourceRequirements `json:"resources,omitempty"`
+	VolumeSize       string                      `json:"volumeSize,omitempty"`
+	StorageClassName string                      `json:"storageClassName,omitempty"`
+	Env              []corev1.EnvVar             `json:"env,omitempty"`
+	// URL is served by the ingress, the notebook serves under the path of the url,
+	// the base url is passed as NB_PREFIX for the kubeflow images and NOTEBOOK_ARGS for the jupyter docker stacks images
+	URL string `json:"url,omitempty"`
+	// TLSSecretName is the name of a kubernetes.io/tls secret used by the ingress of a https url,
+	// the default certificate of the ingress controller is used if it is empty
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
 }
 
 // JupyterNotebookStatus defines the observed state of JupyterNotebook
@@ -41,6 +57,8 @@ type JupyterNotebookStatus struct {
 // +genclient
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="IMAGE",type="string",JSONPath=".spec.image"
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url"
 
 // JupyterNotebook is the Schema for the jupyternotebooks API
 type JupyterNotebook struct {
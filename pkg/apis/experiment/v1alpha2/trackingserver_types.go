@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -36,21 +37,59 @@ type TrackingServerSpec struct {
 	Size                int32       `json:"size,omitempty"`
 	Image               string      `json:"image,omitempty"`
 	S3_ENDPOINT_URL     string      `json:"s3_endpoint_url,omitempty"`
+	// Deprecated: use AWSAccessKeyRef, the controller moves it into a secret
 	AWS_ACCESS_KEY      string      `json:"aws_access_key,omitempty"`
+	// Deprecated: use AWSSecretKeyRef, the controller moves it into a secret
 	AWS_SECRET_KEY      string      `json:"aws_secret_key,omitempty"`
 	ARTIFACT_ROOT       string      `json:"artifact_root,omitempty"`
+	// BACKEND_URI should not contain the password when BackendPasswordRef is set,
+	// a password in BACKEND_URI is moved into a secret by the controller.
+	// BackendPasswordRef is ignored if BACKEND_URI has no user.
 	BACKEND_URI         string      `json:"backend_uri,omitempty"`
 	URL                 string      `json:"url,omitempty"`
 	VolumeSize          string      `json:"volumeSize,omitempty"`
 	StorageClassName    string      `json:"storageClassName,omitempty"`
+	// Deprecated: use TLSSecretName, the controller moves it into a secret
 	Cert	            string      `json:"cert,omitempty"`
+	// Deprecated: use TLSSecretName, the controller moves it into a secret
 	Key	                string      `json:"key,omitempty"`
+
+	AWSAccessKeyRef     *corev1.SecretKeySelector `json:"aws_access_key_ref,omitempty"`
+	AWSSecretKeyRef     *corev1.SecretKeySelector `json:"aws_secret_key_ref,omitempty"`
+	BackendPasswordRef  *corev1.SecretKeySelector `json:"backend_password_ref,omitempty"`
+	// TLSSecretName is the name of a kubernetes.io/tls secret used by the ingress
+	TLSSecretName       string                    `json:"tls_secret_name,omitempty"`
 }
 
+type TrackingServerPhase string
+
+const (
+	TrackingServerPending TrackingServerPhase = "Pending"
+	TrackingServerRunning TrackingServerPhase = "Running"
+	TrackingServerFailed  TrackingServerPhase = "Failed"
+)
+
+const (
+	// TrackingServerPersistentVolumeClaimBound means the sqlite data volume is bound,
+	// it is absent when the tracking server has no volume
+	TrackingServerPersistentVolumeClaimBound = "PersistentVolumeClaimBound"
+	// TrackingServerDeploymentAvailable means the mlflow deployment has minimum availability
+	TrackingServerDeploymentAvailable = "DeploymentAvailable"
+	// TrackingServerIngressReady means the tracking server is exposed by the ingress controller
+	TrackingServerIngressReady = "IngressReady"
+)
+
 // TrackingServerStatus defines the observed state of TrackingServer
 type TrackingServerStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+	Phase TrackingServerPhase `json:"phase,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+	ReadyReplicas      int32              `json:"readyReplicas,omitempty"`
+	URL                string             `json:"url,omitempty"`
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
 }
 
 // +genclient
@@ -58,6 +97,10 @@ type TrackingServerStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="S3_ENDPOINT_URL",type="string",JSONPath=".spec.s3_endpoint_url"
 // +kubebuilder:printcolumn:name="ARTIFACT_ROOT",type="string",JSONPath=".spec.artifact_root"
+// +kubebuilder:printcolumn:name="PHASE",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="READY",type="integer",JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".status.url"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 
 // TrackingServer is the Schema for the trackingservers API
 type TrackingServer struct {
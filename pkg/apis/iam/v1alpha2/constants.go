@@ -37,4 +37,12 @@ const (
 	ClusterAdmin                          = "cluster-admin"
 
 	UserReferenceLabel                    = "iam.aiscope.io/user-ref"
+	RoleTemplateLabel                     = "iam.aiscope.io/role-template"
+	WorkspaceRoleLabel                    = "iam.aiscope.io/workspacerole"
+	WorkspaceRoleBindingLabel             = "iam.aiscope.io/workspacerolebinding"
+
+	WorkspaceAdmin                        = "admin"
+	WorkspaceRegular                      = "regular"
+	WorkspaceViewer                       = "viewer"
+	WorkspaceSelfProvisioner              = "self-provisioner"
 )
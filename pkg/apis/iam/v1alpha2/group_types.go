@@ -21,9 +21,12 @@ import (
 )
 
 const (
+	ResourceKindGroup   = "Group"
 	ResourcePluralGroup = "groups"
 	GroupReferenceLabel = "iam.aiscope/group-ref"
 	GroupParent         = "iam.aiscope/group-parent"
+	// OriginGroupAnnotation records the name of a group mirrored from an identity provider
+	OriginGroupAnnotation = "iam.aiscope.io/origin-group"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
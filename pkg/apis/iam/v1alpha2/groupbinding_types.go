@@ -23,6 +23,11 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+const (
+	ResourceKindGroupBinding   = "GroupBinding"
+	ResourcePluralGroupBinding = "groupbindings"
+)
+
 // GroupRef defines the desired relation of GroupBinding
 type GroupRef struct {
 	APIGroup string `json:"apiGroup,omitempty"`
@@ -25,6 +25,7 @@ import (
 
 const (
 	LastPasswordChangeTimeAnnotation      = "iam.aiscope.io/last-password-change-time"
+	PasswordHistoryAnnotation             = "iam.aiscope.io/password-history"
 	IdentifyProviderLabel                 = "iam.aiscope.io/identify-provider"
 	OriginUIDLabel                        = "iam.aiscope.io/origin-uid"
 	FieldEmail                            = "email"
@@ -34,6 +35,8 @@ const (
 	ExtraUsername                         = "username"
 	ExtraDisplayName                      = "displayName"
 	ExtraUninitialized                    = "uninitialized"
+	ExtraPasswordExpired                  = "password-expired"
+	ExtraScopes                           = "scopes"
 	PreRegistrationUser                   = "system:pre-registration"
 	PreRegistrationUserGroup              = "pre-registration"
 	UninitializedAnnotation               = "iam.aiscope.io/uninitialized"
@@ -43,7 +46,11 @@ const (
 	ScopeDevOps                           = "devops"
 
 	AggregationRolesAnnotation            = "iam.aiscope.io/aggregation-roles"
+	// AggregatedRulesAnnotation records the rules added to the global role by the aggregation of its role templates
+	AggregatedRulesAnnotation             = "iam.aiscope.io/aggregated-rules"
 	GlobalRoleAnnotation                  = "iam.aiscope.io/globalrole"
+	// MFARequiredAnnotation set to "true" on the global role requires its users to log in with the two-factor authentication
+	MFARequiredAnnotation                 = "iam.aiscope.io/mfa-required"
 	WorkspaceRoleAnnotation               = "iam.aiscope.io/workspacerole"
 	ClusterRoleAnnotation                 = "iam.aiscope.io/clusterrole"
 
@@ -85,6 +92,9 @@ const (
 	UserAuthLimitExceeded UserState = "AuthLimitExceeded"
 
 	AuthenticatedSuccessfully = "authenticated successfully"
+	// UserDisabledByAdministrator is the reason of users disabled through the API,
+	// these users are not activated again by the controller.
+	UserDisabledByAdministrator = "disabled by administrator"
 )
 
 // UserStatus defines the observed state of User
@@ -4,6 +4,8 @@ import (
 	experimentapi "aiscope/pkg/aiapis/experiment/v1alpha2"
 	iamapi "aiscope/pkg/aiapis/iam/v1alpha2"
 	"aiscope/pkg/aiapis/oauth"
+	resourcesapi "aiscope/pkg/aiapis/resources/v1alpha2"
+	terminalapi "aiscope/pkg/aiapis/terminal/v1alpha2"
 	tenantapi "aiscope/pkg/aiapis/tenant/v1alpha2"
 	"aiscope/pkg/aiapis/version"
 	"aiscope/pkg/apiserver/authentication/authenticators/basic"
@@ -12,23 +14,37 @@ import (
 	"aiscope/pkg/apiserver/authentication/request/basictoken"
 	"aiscope/pkg/apiserver/authentication/request/bearertoken"
 	"aiscope/pkg/apiserver/authentication/token"
+	"aiscope/pkg/apiserver/authorization"
+	"aiscope/pkg/apiserver/authorization/authorizer"
+	"aiscope/pkg/apiserver/authorization/authorizerfactory"
+	"aiscope/pkg/apiserver/authorization/path"
+	"aiscope/pkg/apiserver/authorization/rbac"
+	"aiscope/pkg/apiserver/authorization/scope"
+	unionauthorizer "aiscope/pkg/apiserver/authorization/union"
 	apiserverconfig "aiscope/pkg/apiserver/config"
 	"aiscope/pkg/apiserver/filters"
 	"aiscope/pkg/apiserver/request"
 	"aiscope/pkg/informers"
 	"aiscope/pkg/models/auth"
 	"aiscope/pkg/models/experiment"
+	"aiscope/pkg/models/iam/am"
+	"aiscope/pkg/models/iam/group"
 	"aiscope/pkg/models/iam/im"
+	"aiscope/pkg/models/kubeconfig"
+	"aiscope/pkg/models/kubectl"
 	"aiscope/pkg/models/resources/v1alpha2/loginrecord"
 	"aiscope/pkg/models/resources/v1alpha2/user"
+	"aiscope/pkg/models/terminal"
 	"aiscope/pkg/simple/client/cache"
 	"aiscope/pkg/simple/client/k8s"
 	"context"
+	"fmt"
 	"github.com/emicklei/go-restful"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	urlruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	unionauth "k8s.io/apiserver/pkg/authentication/request/union"
+	"k8s.io/apiserver/pkg/authentication/request/websocket"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/klog/v2"
 	"net/http"
@@ -52,6 +68,9 @@ type APIServer struct {
 	Issuer token.Issuer
 
 	InformerFactory informers.InformerFactory
+
+	// authorizer decides whether an authenticated request is allowed
+	authorizer authorizer.Authorizer
 }
 
 func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
@@ -65,6 +84,10 @@ func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
 
 	s.Server.Handler = s.container
 
+	if err := s.buildAuthorizer(); err != nil {
+		return err
+	}
+
 	s.installAIscopeAPIs()
 
 	s.buildHandlerChain(stopCh)
@@ -76,20 +99,47 @@ func (s *APIServer) installAIscopeAPIs() {
 	imOperator := im.NewOperator(s.KubernetesClient.AIScope(),
 		user.New(s.InformerFactory.AIScopeSharedInformerFactory(), s.InformerFactory.KubernetesSharedInformerFactory()),
 		loginrecord.New(s.InformerFactory.AIScopeSharedInformerFactory()),
+		s.Config.AuthenticationOptions,
 		)
 	epOperator := experiment.New(s.KubernetesClient.AIScope(), s.InformerFactory)
 
 	urlruntime.Must(version.AddToContainer(s.container, s.KubernetesClient.Discovery()))
 
-	urlruntime.Must(iamapi.AddToContainer(s.container, imOperator))
-	urlruntime.Must(experimentapi.AddToContainer(s.container, epOperator))
-	urlruntime.Must(tenantapi.AddToContainer(s.container, s.KubernetesClient.AIScope(), s.KubernetesClient.Kubernetes()))
+	amOperator := am.NewOperator(s.KubernetesClient.AIScope(), s.InformerFactory)
+
+	groupOperator := group.New(s.KubernetesClient.Kubernetes(), s.KubernetesClient.AIScope(), s.InformerFactory)
 
 	userLister := s.InformerFactory.AIScopeSharedInformerFactory().Iam().V1alpha2().Users().Lister()
+	groupLister := s.InformerFactory.AIScopeSharedInformerFactory().Iam().V1alpha2().Groups().Lister()
+	mfaOperator := auth.NewMFAOperator(s.KubernetesClient.Kubernetes(), s.CacheClient, userLister, groupLister, amOperator)
+
+	urlruntime.Must(iamapi.AddToContainer(s.container, imOperator, amOperator, groupOperator,
+		auth.NewAccessTokenOperator(s.CacheClient, s.Issuer),
+		auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions), mfaOperator, s.authorizer))
+	urlruntime.Must(experimentapi.AddToContainer(s.container, epOperator))
+	urlruntime.Must(tenantapi.AddToContainer(s.container, s.KubernetesClient.AIScope(), s.KubernetesClient.Kubernetes(), s.InformerFactory, s.authorizer))
+	urlruntime.Must(resourcesapi.AddToContainer(s.container, imOperator,
+		kubeconfig.NewOperator(s.KubernetesClient.Kubernetes(),
+			s.InformerFactory.KubernetesSharedInformerFactory().Core().V1().Secrets().Lister(),
+			s.KubernetesClient.Config(), s.KubernetesClient.Master())))
+
+	kubernetesInformer := s.InformerFactory.KubernetesSharedInformerFactory()
+	userInformer := s.InformerFactory.AIScopeSharedInformerFactory().Iam().V1alpha2().Users()
+	kubectlOperator := kubectl.NewOperator(s.KubernetesClient.Kubernetes(), kubernetesInformer.Apps().V1().Deployments(),
+		kubernetesInformer.Core().V1().Pods(), userInformer, s.Config.AuthenticationOptions.KubectlImage)
+	urlruntime.Must(terminalapi.AddToContainer(s.container, terminal.NewTerminaler(s.KubernetesClient.Kubernetes(),
+		s.KubernetesClient.Config(), s.Config.TerminalOptions, kubectlOperator, userInformer.Lister()), s.authorizer))
+
 	urlruntime.Must(oauth.AddToContainer(s.container, imOperator,
 		auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions),
+		jwt.NewTokenAuthenticator(
+			auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions),
+			userLister, groupLister),
+		auth.NewGrantOperator(s.CacheClient),
+		auth.NewRegistrationOperator(s.KubernetesClient.AIScope(), userLister),
+		mfaOperator,
 		auth.NewOAuthAuthenticator(s.KubernetesClient.AIScope(), userLister, s.Config.AuthenticationOptions),
-		auth.NewPasswordAuthenticator(s.KubernetesClient.AIScope(), userLister, s.Config.AuthenticationOptions),
+		auth.NewPasswordAuthenticator(s.KubernetesClient.AIScope(), userLister, s.CacheClient, s.Config.AuthenticationOptions),
 		auth.NewLoginRecorder(s.KubernetesClient.AIScope(), userLister),
 		s.Config.AuthenticationOptions))
 }
@@ -143,11 +193,44 @@ func (s *APIServer) waitForResourceSync(ctx context.Context) error {
 		return false
 	}
 
+	k8sGVRs := []schema.GroupVersionResource{
+		{Group: "", Version: "v1", Resource: "namespaces"},
+		{Group: "", Version: "v1", Resource: "secrets"},
+		{Group: "", Version: "v1", Resource: "pods"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+	}
+
+	k8sInformerFactory := s.InformerFactory.KubernetesSharedInformerFactory()
+
+	for _, gvr := range k8sGVRs {
+		if !isResourceExists(gvr) {
+			klog.Warningf("resource %s not exists in the cluster", gvr)
+		} else {
+			_, err = k8sInformerFactory.ForResource(gvr)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	k8sInformerFactory.Start(stopCh)
+	k8sInformerFactory.WaitForCacheSync(stopCh)
+
 	aiGVRs := []schema.GroupVersionResource{
 		{Group: "tenant.aiscope", Version: "v1alpha2", Resource: "workspaces"},
 		{Group: "iam.aiscope", Version: "v1alpha2", Resource: "users"},
+		{Group: "iam.aiscope", Version: "v1alpha2", Resource: "globalroles"},
+		{Group: "iam.aiscope", Version: "v1alpha2", Resource: "globalrolebindings"},
+		{Group: "iam.aiscope", Version: "v1alpha2", Resource: "workspaceroles"},
+		{Group: "iam.aiscope", Version: "v1alpha2", Resource: "workspacerolebindings"},
+		{Group: "iam.aiscope", Version: "v1alpha2", Resource: "groups"},
+		{Group: "iam.aiscope", Version: "v1alpha2", Resource: "groupbindings"},
 		{Group: "experiment.aiscope", Version: "v1alpha2", Resource: "jupyternotebooks"},
 		{Group: "experiment.aiscope", Version: "v1alpha2", Resource: "trackingservers"},
+		{Group: "experiment.aiscope", Version: "v1alpha2", Resource: "codeservers"},
 	}
 
 	aiInformerFactory := s.InformerFactory.AIScopeSharedInformerFactory()
@@ -172,24 +255,34 @@ func (s *APIServer) waitForResourceSync(ctx context.Context) error {
 
 func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 	requestInfoResolver := &request.RequestInfoFactory{
-		APIPrefixes:          sets.NewString("api", "apis"),
+		APIPrefixes:          sets.NewString("api", "apis", "aiapis"),
 	}
 
 	handler := s.Server.Handler
 	handler = filters.WithKubeAPIServer(handler, s.KubernetesClient.Config(), &errorResponder{})
+	handler = filters.WithAuthorization(handler, s.authorizer)
 
 	userLister := s.InformerFactory.AIScopeSharedInformerFactory().Iam().V1alpha2().Users().Lister()
+	groupLister := s.InformerFactory.AIScopeSharedInformerFactory().Iam().V1alpha2().Groups().Lister()
 	loginRecorder := auth.NewLoginRecorder(s.KubernetesClient.AIScope(), userLister)
+	mfaOperator := auth.NewMFAOperator(s.KubernetesClient.Kubernetes(), s.CacheClient, userLister, groupLister,
+		am.NewReadOnlyOperator(s.InformerFactory))
 
-	authn := unionauth.New(anonymous.NewAuthenticator(),
-		basictoken.New(basic.NewBasicAuthenticator(auth.NewPasswordAuthenticator(
+	// anonymous authenticator goes last, only requests without credentials fall back to it
+	authn := unionauth.New(basictoken.New(basic.NewBasicAuthenticator(auth.NewPasswordAuthenticator(
 			s.KubernetesClient.AIScope(),
 			userLister,
+			s.CacheClient,
 			s.Config.AuthenticationOptions),
-			loginRecorder)),
+			loginRecorder, mfaOperator, groupLister)),
 		bearertoken.New(jwt.NewTokenAuthenticator(
 			auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions),
-			userLister)))
+			userLister, groupLister)),
+		// websocket clients carry the bearer token in the subprotocol
+		websocket.NewProtocolAuthenticator(jwt.NewTokenAuthenticator(
+			auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions),
+			userLister, groupLister)),
+		anonymous.NewAuthenticator())
 	handler = filters.WithAuthentication(handler, authn)
 
 	handler = filters.WithRequestInfo(handler, requestInfoResolver)
@@ -197,6 +290,28 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 	s.Server.Handler = handler
 }
 
+func (s *APIServer) buildAuthorizer() error {
+	switch s.Config.AuthorizationOptions.Mode {
+	case authorization.AlwaysAllow:
+		s.authorizer = authorizerfactory.NewAlwaysAllowAuthorizer()
+	case authorization.RBAC:
+		// the endpoints of the authorization server authenticate the clients themselves,
+		// the TokenReview webhook /oauth/authenticate is authorized as a non-resource URL
+		excludedPaths := []string{"/oauth/authorize", "/oauth/callback/*", "/oauth/confirm", "/oauth/mfa",
+			"/oauth/token", "/oauth/introspect", "/oauth/revoke", "/oauth/keys", "/oauth/userinfo",
+			"/version", "/aiapis/version", "/aiapis/iam.aiscope/v1alpha2/login", "/.well-known/*"}
+		pathAuthorizer, err := path.NewAuthorizer(excludedPaths)
+		if err != nil {
+			return err
+		}
+		amOperator := am.NewReadOnlyOperator(s.InformerFactory)
+		s.authorizer = unionauthorizer.New(pathAuthorizer, scope.NewAuthorizer(), rbac.NewRBACAuthorizer(amOperator))
+	default:
+		return fmt.Errorf("authorization mode %s not support", s.Config.AuthorizationOptions.Mode)
+	}
+	return nil
+}
+
 type errorResponder struct{}
 
 func (e *errorResponder) Error(w http.ResponseWriter, req *http.Request, err error) {
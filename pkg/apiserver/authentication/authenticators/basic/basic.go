@@ -9,7 +9,9 @@ import (
 
 	"aiscope/pkg/apiserver/authentication/request/basictoken"
 	"aiscope/pkg/apiserver/request"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
 	"aiscope/pkg/models/auth"
+	"aiscope/pkg/models/iam/group"
 
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -23,12 +25,16 @@ import (
 type basicAuthenticator struct {
 	authenticator auth.PasswordAuthenticator
 	loginRecorder auth.LoginRecorder
+	mfaOperator   auth.MFAOperator
+	groupLister   iamv1alpha2listers.GroupLister
 }
 
-func NewBasicAuthenticator(authenticator auth.PasswordAuthenticator, loginRecorder auth.LoginRecorder) basictoken.Password {
+func NewBasicAuthenticator(authenticator auth.PasswordAuthenticator, loginRecorder auth.LoginRecorder, mfaOperator auth.MFAOperator, groupLister iamv1alpha2listers.GroupLister) basictoken.Password {
 	return &basicAuthenticator{
 		authenticator: authenticator,
 		loginRecorder: loginRecorder,
+		mfaOperator:   mfaOperator,
+		groupLister:   groupLister,
 	}
 }
 
@@ -47,10 +53,23 @@ func (t *basicAuthenticator) AuthenticatePassword(ctx context.Context, username,
 		}
 		return nil, false, err
 	}
+	// the second factor can not be carried by basic auth, the users with MFA enabled have to log in for tokens
+	if provider == "" && t.mfaOperator != nil {
+		var challenged bool
+		authenticated, challenged, err = t.mfaOperator.Challenge(authenticated)
+		if err != nil {
+			return nil, false, err
+		}
+		if challenged {
+			return nil, false, auth.MFARequiredError
+		}
+	}
 	return &authenticator.Response{
 		User: &user.DefaultInfo{
 			Name:   authenticated.GetName(),
-			Groups: append(authenticated.GetGroups(), user.AllAuthenticated),
+			Groups: append(group.ResolveGroups(t.groupLister, authenticated.GetGroups()), user.AllAuthenticated),
+			// the scopes are enforced by the scope authorizer
+			Extra: authenticated.GetExtra(),
 		},
 	}, true, nil
 }
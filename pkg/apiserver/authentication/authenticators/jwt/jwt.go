@@ -2,14 +2,18 @@ package jwt
 
 import (
 	"context"
+	"fmt"
 
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/klog"
 
 	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	tokenissuer "aiscope/pkg/apiserver/authentication/token"
 
 	"aiscope/pkg/models/auth"
+	"aiscope/pkg/models/iam/group"
+	"aiscope/pkg/utils/sliceutil"
 
 	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
 )
@@ -22,12 +26,14 @@ import (
 type tokenAuthenticator struct {
 	tokenOperator auth.TokenManagementInterface
 	userLister    iamv1alpha2listers.UserLister
+	groupLister   iamv1alpha2listers.GroupLister
 }
 
-func NewTokenAuthenticator(tokenOperator auth.TokenManagementInterface, userLister iamv1alpha2listers.UserLister) authenticator.Token {
+func NewTokenAuthenticator(tokenOperator auth.TokenManagementInterface, userLister iamv1alpha2listers.UserLister, groupLister iamv1alpha2listers.GroupLister) authenticator.Token {
 	return &tokenAuthenticator{
 		tokenOperator: tokenOperator,
 		userLister:    userLister,
+		groupLister:   groupLister,
 	}
 }
 
@@ -38,6 +44,12 @@ func (t *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string
 		return nil, false, err
 	}
 
+	// the other tokens are not bearer credentials, for example,
+	// ID tokens carry no scopes and authorization codes can only be exchanged for tokens
+	if verified.TokenType != tokenissuer.AccessToken && verified.TokenType != tokenissuer.StaticToken {
+		return nil, false, fmt.Errorf("invalid token type %v", verified.TokenType)
+	}
+
 	if verified.User.GetName() == iamv1alpha2.PreRegistrationUser {
 		return &authenticator.Response{
 			User: verified.User,
@@ -48,11 +60,29 @@ func (t *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string
 	if err != nil {
 		return nil, false, err
 	}
+	// the tokens issued before the user is disabled or blocked are rejected
+	if u.Status.State != nil && *u.Status.State != iamv1alpha2.UserActive {
+		return nil, false, fmt.Errorf("user %s is %s", u.Name, *u.Status.State)
+	}
+
+	// the groups mapped at login take effect before the group bindings are reconciled
+	groups := append([]string{}, u.Spec.Groups...)
+	for _, g := range verified.User.GetGroups() {
+		if !sliceutil.HasString(groups, g) {
+			groups = append(groups, g)
+		}
+	}
+
+	info := &user.DefaultInfo{
+		Name:   u.GetName(),
+		Groups: append(group.ResolveGroups(t.groupLister, groups), user.AllAuthenticated),
+	}
+	// the scopes of the token are enforced by the scope authorizer
+	if len(verified.Scopes) > 0 {
+		info.Extra = map[string][]string{iamv1alpha2.ExtraScopes: verified.Scopes}
+	}
 
 	return &authenticator.Response{
-		User: &user.DefaultInfo{
-			Name:   u.GetName(),
-			Groups: append(u.Spec.Groups, user.AllAuthenticated),
-		},
+		User: info,
 	}, true, nil
 }
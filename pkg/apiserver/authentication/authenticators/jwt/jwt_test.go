@@ -0,0 +1,90 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	k8scache "k8s.io/client-go/tools/cache"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authentication"
+	"aiscope/pkg/apiserver/authentication/token"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/models/auth"
+	"aiscope/pkg/simple/client/cache"
+)
+
+func TestAuthenticateToken(t *testing.T) {
+	users := k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{})
+	for name, state := range map[string]iamv1alpha2.UserState{
+		"admin": iamv1alpha2.UserActive,
+		"bob":   iamv1alpha2.UserDisabled,
+		"carol": iamv1alpha2.UserAuthLimitExceeded,
+	} {
+		state := state
+		if err := users.Add(&iamv1alpha2.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     iamv1alpha2.UserStatus{State: &state},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	options.OAuthOptions.AccessTokenMaxAge = time.Hour
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenOperator := auth.NewTokenOperator(cache.NewSimpleCache(), issuer, options)
+	authenticator := NewTokenAuthenticator(tokenOperator, iamv1alpha2listers.NewUserLister(users),
+		iamv1alpha2listers.NewGroupLister(k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{})))
+
+	admin := &user.DefaultInfo{Name: "admin"}
+	accessToken, refreshToken, err := tokenOperator.IssueSessionTo(admin, &auth.Session{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	idToken, err := tokenOperator.IssueTo(&token.IssueRequest{
+		User:      admin,
+		Claims:    token.Claims{TokenType: token.IDToken},
+		ExpiresIn: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	disabledAccessToken, _, err := tokenOperator.IssueSessionTo(&user.DefaultInfo{Name: "bob"}, &auth.Session{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockedAccessToken, _, err := tokenOperator.IssueSessionTo(&user.DefaultInfo{Name: "carol"}, &auth.Session{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description   string
+		token         string
+		authenticated bool
+	}{
+		{"access token", accessToken, true},
+		{"refresh token", refreshToken, false},
+		{"id token", idToken, false},
+		{"disabled user", disabledAccessToken, false},
+		{"user blocked by failed logins", blockedAccessToken, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, ok, _ := authenticator.AuthenticateToken(context.Background(), test.token)
+			if ok != test.authenticated {
+				t.Errorf("authenticated = %v, want %v", ok, test.authenticated)
+			}
+		})
+	}
+}
@@ -15,3 +15,24 @@ type GenericProviderFactory interface {
 	// Apply the dynamic options from aiscope-config
 	Create(options oauth.DynamicOptions) (GenericProvider, error)
 }
+
+// GroupIdentity is implemented by identities which know the groups the account is a member of.
+type GroupIdentity interface {
+	Identity
+	// GetGroups returns the names of the groups in the identity provider
+	GetGroups() []string
+}
+
+// Group represents a group of the identity provider.
+type Group struct {
+	// Name of the group in the identity provider
+	Name string
+	// Members contains the user IDs of the group members
+	Members []string
+}
+
+// GroupSyncProvider is implemented by providers which are able to list all the groups and their members,
+// the groups are periodically mirrored into aiscope.
+type GroupSyncProvider interface {
+	ListGroups() ([]Group, error)
+}
@@ -6,11 +6,13 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/go-ldap/ldap"
 	"github.com/mitchellh/mapstructure"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog"
 
 	"aiscope/pkg/apiserver/authentication/identityprovider"
@@ -18,8 +20,12 @@ import (
 )
 
 const (
-	ldapIdentityProvider = "LDAPIdentityProvider"
-	defaultReadTimeout   = 15000
+	ldapIdentityProvider        = "LDAPIdentityProvider"
+	defaultReadTimeout          = 15000
+	defaultGroupSearchFilter    = "(|(objectClass=groupOfNames)(objectClass=groupOfUniqueNames)(objectClass=posixGroup)(objectClass=group))"
+	defaultGroupMemberAttribute = "member"
+	defaultGroupNameAttribute   = "cn"
+	pagingSize                  = 500
 )
 
 func init() {
@@ -56,6 +62,8 @@ type ldapProvider struct {
 	UserMemberAttribute string `json:"userMemberAttribute,omitempty" yaml:"userMemberAttribute"`
 	// Attribute on a group object storing the information for primary group membership.
 	GroupMemberAttribute string `json:"groupMemberAttribute,omitempty" yaml:"groupMemberAttribute"`
+	// Attribute on a group object storing the name of the group, default to cn.
+	GroupNameAttribute string `json:"groupNameAttribute,omitempty" yaml:"groupNameAttribute"`
 	// The following three fields are direct mappings of attributes on the user entry.
 	// login attribute used for comparing user entries.
 	LoginAttribute string `json:"loginAttribute" yaml:"loginAttribute"`
@@ -77,12 +85,22 @@ func (l *ldapProviderFactory) Create(options oauth.DynamicOptions) (identityprov
 	if ldapProvider.ReadTimeout <= 0 {
 		ldapProvider.ReadTimeout = defaultReadTimeout
 	}
+	if ldapProvider.GroupSearchFilter == "" {
+		ldapProvider.GroupSearchFilter = defaultGroupSearchFilter
+	}
+	if ldapProvider.GroupMemberAttribute == "" {
+		ldapProvider.GroupMemberAttribute = defaultGroupMemberAttribute
+	}
+	if ldapProvider.GroupNameAttribute == "" {
+		ldapProvider.GroupNameAttribute = defaultGroupNameAttribute
+	}
 	return &ldapProvider, nil
 }
 
 type ldapIdentity struct {
 	Username string
 	Email    string
+	Groups   []string
 }
 
 func (l *ldapIdentity) GetUserID() string {
@@ -97,6 +115,10 @@ func (l *ldapIdentity) GetEmail() string {
 	return l.Email
 }
 
+func (l *ldapIdentity) GetGroups() []string {
+	return l.Groups
+}
+
 func (l ldapProvider) Authenticate(username string, password string) (identityprovider.Identity, error) {
 	conn, err := l.newConn()
 	if err != nil {
@@ -124,7 +146,7 @@ func (l ldapProvider) Authenticate(username string, password string) (identitypr
 		TimeLimit:    0,
 		TypesOnly:    false,
 		Filter:       filter,
-		Attributes:   []string{l.LoginAttribute, l.MailAttribute},
+		Attributes:   l.userAttributes(),
 	})
 	if err != nil {
 		klog.Error(err)
@@ -151,12 +173,147 @@ func (l ldapProvider) Authenticate(username string, password string) (identitypr
 	}
 	email := entry.GetAttributeValue(l.MailAttribute)
 	uid := entry.GetAttributeValue(l.LoginAttribute)
+
+	// the user is authenticated, search the groups with the manager account
+	if err = conn.Bind(l.ManagerDN, l.ManagerPassword); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	groups, err := l.searchUserGroups(conn, entry)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
 	return &ldapIdentity{
 		Username: uid,
 		Email:    email,
+		Groups:   groups,
 	}, nil
 }
 
+// ListGroups returns all the groups under the GroupSearchBase, members are identified by the login attribute.
+func (l ldapProvider) ListGroups() ([]identityprovider.Group, error) {
+	if l.GroupSearchBase == "" {
+		return nil, nil
+	}
+
+	conn, err := l.newConn()
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	conn.SetTimeout(time.Duration(l.ReadTimeout) * time.Millisecond)
+	defer conn.Close()
+
+	if err = conn.Bind(l.ManagerDN, l.ManagerPassword); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	// group members are usually referenced by DN, build the index of user DNs
+	userFilter := fmt.Sprintf("(%s=*)", l.LoginAttribute)
+	if l.UserSearchFilter != "" {
+		userFilter = fmt.Sprintf("(&%s%s)", userFilter, l.UserSearchFilter)
+	}
+	users, err := conn.SearchWithPaging(&ldap.SearchRequest{
+		BaseDN:       l.UserSearchBase,
+		Scope:        ldap.ScopeWholeSubtree,
+		DerefAliases: ldap.NeverDerefAliases,
+		Filter:       userFilter,
+		Attributes:   []string{l.LoginAttribute},
+	}, pagingSize)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	uids := make(map[string]string, len(users.Entries))
+	for _, entry := range users.Entries {
+		uids[strings.ToLower(entry.DN)] = entry.GetAttributeValue(l.LoginAttribute)
+	}
+
+	result, err := conn.SearchWithPaging(&ldap.SearchRequest{
+		BaseDN:       l.GroupSearchBase,
+		Scope:        ldap.ScopeWholeSubtree,
+		DerefAliases: ldap.NeverDerefAliases,
+		Filter:       l.GroupSearchFilter,
+		Attributes:   []string{l.GroupNameAttribute, l.GroupMemberAttribute},
+	}, pagingSize)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	groups := make([]identityprovider.Group, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		name := entry.GetAttributeValue(l.GroupNameAttribute)
+		if name == "" {
+			continue
+		}
+		group := identityprovider.Group{Name: name}
+		for _, member := range entry.GetAttributeValues(l.GroupMemberAttribute) {
+			// posixGroup references the members by uid
+			if uid, ok := uids[strings.ToLower(member)]; ok {
+				member = uid
+			} else if strings.Contains(member, "=") {
+				continue
+			}
+			group.Members = append(group.Members, member)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// searchUserGroups returns the groups of the user entry, both the groups referencing the user
+// and the groups referenced by the UserMemberAttribute of the user are included.
+func (l ldapProvider) searchUserGroups(conn *ldap.Conn, entry *ldap.Entry) ([]string, error) {
+	groups := sets.NewString()
+
+	if l.UserMemberAttribute != "" {
+		for _, groupDN := range entry.GetAttributeValues(l.UserMemberAttribute) {
+			dn, err := ldap.ParseDN(groupDN)
+			if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+				klog.V(4).Infof("ldap: invalid group dn %s", groupDN)
+				continue
+			}
+			groups.Insert(dn.RDNs[0].Attributes[0].Value)
+		}
+	}
+
+	if l.GroupSearchBase != "" {
+		memberFilter := fmt.Sprintf("(|(%s=%s)(%s=%s))",
+			l.GroupMemberAttribute, ldap.EscapeFilter(entry.DN),
+			l.GroupMemberAttribute, ldap.EscapeFilter(entry.GetAttributeValue(l.LoginAttribute)))
+		result, err := conn.Search(&ldap.SearchRequest{
+			BaseDN:       l.GroupSearchBase,
+			Scope:        ldap.ScopeWholeSubtree,
+			DerefAliases: ldap.NeverDerefAliases,
+			Filter:       fmt.Sprintf("(&%s%s)", l.GroupSearchFilter, memberFilter),
+			Attributes:   []string{l.GroupNameAttribute},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range result.Entries {
+			if name := group.GetAttributeValue(l.GroupNameAttribute); name != "" {
+				groups.Insert(name)
+			}
+		}
+	}
+
+	return groups.List(), nil
+}
+
+func (l ldapProvider) userAttributes() []string {
+	attributes := []string{l.LoginAttribute, l.MailAttribute}
+	if l.UserMemberAttribute != "" {
+		attributes = append(attributes, l.UserMemberAttribute)
+	}
+	return attributes
+}
+
 func (l *ldapProvider) newConn() (*ldap.Conn, error) {
 	if !l.StartTLS {
 		return ldap.Dial("tcp", l.Host)
@@ -61,6 +61,12 @@ type oidcProvider struct {
 	// Configurable key which contains the preferred username claims
 	PreferredUsernameKey string `json:"preferredUsernameKey" yaml:"preferredUsernameKey"`
 
+	// Configurable key which contains the groups claims, default to groups
+	GroupsKey string `json:"groupsKey" yaml:"groupsKey"`
+
+	// AllowedGroups restricts the groups mapped from the groups claims, all the groups are mapped if empty
+	AllowedGroups []string `json:"allowedGroups" yaml:"allowedGroups"`
+
 	Provider     *oidc.Provider        `json:"-" yaml:"-"`
 	OAuth2Config *oauth2.Config        `json:"-" yaml:"-"`
 	Verifier     *oidc.IDTokenVerifier `json:"-" yaml:"-"`
@@ -96,6 +102,8 @@ type oidcIdentity struct {
 	// Its value MUST conform to the RFC 5322 [RFC5322] addr-spec syntax.
 	// The RP MUST NOT rely upon this value being unique.
 	Email string `json:"email"`
+	// Groups the End-User belongs to, as listed in the groups claims.
+	Groups []string `json:"groups"`
 }
 
 func (o oidcIdentity) GetUserID() string {
@@ -110,6 +118,10 @@ func (o oidcIdentity) GetEmail() string {
 	return o.Email
 }
 
+func (o oidcIdentity) GetGroups() []string {
+	return o.Groups
+}
+
 type oidcProviderFactory struct {
 }
 
@@ -272,5 +284,39 @@ func (o *oidcProvider) IdentityExchangeCallback(req *http.Request) (identityprov
 		Sub:               subject,
 		PreferredUsername: preferredUsername,
 		Email:             email,
+		Groups:            o.groups(claims),
 	}, nil
 }
+
+// groups returns the allowed groups in the groups claims,
+// the claims can be either an array of strings or a single string.
+func (o *oidcProvider) groups(claims jwt.MapClaims) []string {
+	groupsKey := "groups"
+	if o.GroupsKey != "" {
+		groupsKey = o.GroupsKey
+	}
+
+	var groups []string
+	switch value := claims[groupsKey].(type) {
+	case string:
+		groups = []string{value}
+	case []interface{}:
+		for _, item := range value {
+			if group, ok := item.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	result := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if group == "" || sliceutil.HasString(result, group) {
+			continue
+		}
+		if len(o.AllowedGroups) > 0 && !sliceutil.HasString(o.AllowedGroups, group) {
+			continue
+		}
+		result = append(result, group)
+	}
+	return result
+}
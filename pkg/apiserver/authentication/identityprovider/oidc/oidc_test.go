@@ -113,6 +113,7 @@ var _ = BeforeSuite(func(done Done) {
 				"email":          "test@aiscope.io",
 				"email_verified": "true",
 				"name":           "test",
+				"groups":         []string{"developers", "admins"},
 				"iat":            time.Now().Unix(),
 				"exp":            time.Now().Add(10 * time.Hour).Unix(),
 			}
@@ -183,6 +184,17 @@ var _ = Describe("OIDC", func() {
 			Expect(identity.GetUserID()).Should(Equal("110169484474386276334"))
 			Expect(identity.GetUsername()).Should(Equal("test"))
 			Expect(identity.GetEmail()).Should(Equal("test@aiscope.io"))
+			groupIdentity, ok := identity.(identityprovider.GroupIdentity)
+			Expect(ok).Should(BeTrue())
+			Expect(groupIdentity.GetGroups()).Should(Equal([]string{"developers", "admins"}))
+		})
+		It("should filter the groups not allowed", func() {
+			provider.(*oidcProvider).AllowedGroups = []string{"developers"}
+			url, _ := url.Parse("https://ks-console.aiscope-system.svc/oauth/redirect/oidc?code=00000")
+			req := &http.Request{URL: url}
+			identity, err := provider.IdentityExchangeCallback(req)
+			Expect(err).Should(BeNil())
+			Expect(identity.(identityprovider.GroupIdentity).GetGroups()).Should(Equal([]string{"developers"}))
 		})
 	})
 })
@@ -45,12 +45,19 @@ var (
 	// or exceeds the scope granted by the resource owner.
 	ErrorInvalidScope = Error{Type: "invalid_scope"}
 
+	// ErrorAccessDenied The resource owner or authorization server denied the request.
+	ErrorAccessDenied = Error{Type: "access_denied"}
+
 	// ErrorLoginRequired The Authorization Server requires End-User authentication.
 	// This error MAY be returned when the prompt parameter value in the Authentication Request is none,
 	// but the Authentication Request cannot be completed without displaying a user interface
 	// for End-User authentication.
 	ErrorLoginRequired = Error{Type: "login_required"}
 
+	// ErrorMFARequired The End-User authenticated with the password has to complete the login with the second factor,
+	// the MFA token identifying the challenge is returned alongside the error.
+	ErrorMFARequired = Error{Type: "mfa_required"}
+
 	// ErrorServerError
 	// The authorization server encountered an unexpected
 	// condition that prevented it from fulfilling the request.
@@ -78,6 +85,12 @@ func NewInvalidClient(error error) Error {
 	return err
 }
 
+func NewUnauthorizedClient(error error) Error {
+	err := ErrorUnauthorizedClient
+	err.Description = error.Error()
+	return err
+}
+
 func NewInvalidGrant(error error) Error {
 	err := ErrorInvalidGrant
 	err.Description = error.Error()
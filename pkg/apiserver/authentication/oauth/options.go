@@ -3,8 +3,11 @@ package oauth
 import (
 	"encoding/json"
 	"errors"
+	"net/url"
 	"strings"
 	"time"
+
+	"aiscope/pkg/utils/sliceutil"
 )
 
 type GrantHandlerType string
@@ -30,12 +33,21 @@ const (
 	MappingMethodMixed MappingMethod = "mixed"
 
 	DefaultIssuer string = "aiscope"
+
+	// ScopeOpenID requests an ID Token, see also https://openid.net/specs/openid-connect-core-1_0.html#ScopeClaims
+	ScopeOpenID  = "openid"
+	ScopeEmail   = "email"
+	ScopeProfile = "profile"
+
+	// ResponseTypeCode requests an authorization code, see also https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.1
+	ResponseTypeCode = "code"
 )
 
 var (
 	ErrorClientNotFound        = errors.New("the OAuth client was not found")
 	ErrorProviderNotFound      = errors.New("the identity provider was not found")
 	ErrorRedirectURLNotAllowed = errors.New("redirect URL is not allowed")
+	ErrorScopeNotAllowed       = errors.New("scope is not allowed")
 )
 
 type Options struct {
@@ -140,6 +152,11 @@ type IdentityProviderOptions struct {
 	// Username from IDP must math [a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*
 	DisableLoginConfirmation bool `json:"disableLoginConfirmation" yaml:"disableLoginConfirmation"`
 
+	// GroupsPrefix is prepended to the names of the groups mirrored from the identity provider,
+	// which keeps them apart from the groups of other providers and the workspaces.
+	// Default to the provider name followed by a dash.
+	GroupsPrefix string `json:"groupsPrefix,omitempty" yaml:"groupsPrefix,omitempty"`
+
 	// The type of identify provider
 	// OpenIDIdentityProvider LDAPIdentityProvider GitHubIdentityProvider
 	Type string `json:"type" yaml:"type"`
@@ -204,6 +221,54 @@ type Client struct {
 	AccessTokenInactivityTimeout *time.Duration `json:"accessTokenInactivityTimeout,omitempty" yaml:"accessTokenInactivityTimeout,omitempty"`
 }
 
+// ResolveRedirectURL returns the redirection URL of the authorization response, the redirection URI
+// MUST exactly match one of the registered URIs, the first one is used if the client does not specify one.
+// See also https://datatracker.ietf.org/doc/html/rfc6749#section-3.1.2
+func (c Client) ResolveRedirectURL(expectURL string) (*url.URL, error) {
+	if len(c.RedirectURIs) == 0 {
+		return nil, ErrorRedirectURLNotAllowed
+	}
+	if expectURL == "" {
+		expectURL = c.RedirectURIs[0]
+	} else if !sliceutil.HasString(c.RedirectURIs, expectURL) {
+		return nil, ErrorRedirectURLNotAllowed
+	}
+	redirectURL, err := url.Parse(expectURL)
+	if err != nil {
+		return nil, err
+	}
+	// The endpoint URI MUST NOT include a fragment component.
+	if !redirectURL.IsAbs() || redirectURL.Fragment != "" {
+		return nil, ErrorRedirectURLNotAllowed
+	}
+	return redirectURL, nil
+}
+
+// IsScopeAllowed returns whether the client can request the scope, a restriction ending with "*" matches
+// all the scopes with the prefix. All the scopes are allowed if there is no restriction.
+func (c Client) IsScopeAllowed(scope string) bool {
+	if len(c.ScopeRestrictions) == 0 {
+		return true
+	}
+	for _, restriction := range c.ScopeRestrictions {
+		if restriction == scope {
+			return true
+		}
+		if strings.HasSuffix(restriction, "*") && strings.HasPrefix(scope, strings.TrimSuffix(restriction, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Method returns the grant handling method of the client, the grant requests are approved automatically by default.
+func (c Client) Method() GrantHandlerType {
+	if c.GrantMethod == "" {
+		return GrantHandlerAuto
+	}
+	return c.GrantMethod
+}
+
 func (o *Options) OAuthClient(name string) (Client, error) {
 	for _, found := range o.Clients {
 		if found.Name == name {
@@ -213,6 +278,14 @@ func (o *Options) OAuthClient(name string) (Client, error) {
 	return Client{}, ErrorClientNotFound
 }
 
+// GroupPrefix returns the prefix of the groups mirrored from the identity provider
+func (o *IdentityProviderOptions) GroupPrefix() string {
+	if o.GroupsPrefix != "" {
+		return o.GroupsPrefix
+	}
+	return o.Name + "-"
+}
+
 func (o *Options) IdentityProviderOptions(name string) (*IdentityProviderOptions, error) {
 	for _, found := range o.IdentityProviders {
 		if found.Name == name {
@@ -0,0 +1,44 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// Proof Key for Code Exchange, see also https://datatracker.ietf.org/doc/html/rfc7636
+const (
+	CodeChallengeMethodPlain = "plain"
+	CodeChallengeMethodS256  = "S256"
+)
+
+// code verifier and code challenge are both high-entropy strings of unreserved characters
+var codeVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// IsValidCodeChallengeMethod returns whether the code challenge method is supported.
+func IsValidCodeChallengeMethod(method string) bool {
+	return method == CodeChallengeMethodPlain || method == CodeChallengeMethodS256
+}
+
+// IsValidCodeChallenge returns whether the code challenge or the code verifier is well-formed.
+func IsValidCodeChallenge(challenge string) bool {
+	return codeVerifierPattern.MatchString(challenge)
+}
+
+// VerifyCodeChallenge verifies the code verifier against the code challenge of the authorization request,
+// see also https://datatracker.ietf.org/doc/html/rfc7636#section-4.6
+func VerifyCodeChallenge(method, challenge, verifier string) bool {
+	if !IsValidCodeChallenge(verifier) {
+		return false
+	}
+	switch method {
+	case CodeChallengeMethodS256:
+		hash := sha256.Sum256([]byte(verifier))
+		verifier = base64.RawURLEncoding.EncodeToString(hash[:])
+	case CodeChallengeMethodPlain, "":
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(verifier), []byte(challenge)) == 1
+}
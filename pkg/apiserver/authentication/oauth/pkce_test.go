@@ -0,0 +1,81 @@
+package oauth
+
+import "testing"
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// https://datatracker.ietf.org/doc/html/rfc7636#appendix-B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	tests := []struct {
+		description string
+		method      string
+		challenge   string
+		verifier    string
+		expected    bool
+	}{
+		{"S256", CodeChallengeMethodS256, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", verifier, true},
+		{"S256 mismatch", CodeChallengeMethodS256, verifier, verifier, false},
+		{"plain", CodeChallengeMethodPlain, verifier, verifier, true},
+		{"plain by default", "", verifier, verifier, true},
+		{"verifier too short", CodeChallengeMethodPlain, "abc", "abc", false},
+		{"unsupported method", "S512", verifier, verifier, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if got := VerifyCodeChallenge(test.method, test.challenge, test.verifier); got != test.expected {
+				t.Errorf("VerifyCodeChallenge() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestClient_ResolveRedirectURL(t *testing.T) {
+	client := Client{
+		Name:         "notebook",
+		RedirectURIs: []string{"https://notebook.aiscope.io/callback", "http://localhost:8000/callback"},
+	}
+	tests := []struct {
+		description string
+		expectURL   string
+		expected    string
+		wantErr     bool
+	}{
+		{"default", "", "https://notebook.aiscope.io/callback", false},
+		{"registered", "http://localhost:8000/callback", "http://localhost:8000/callback", false},
+		{"not registered", "https://evil.io/callback", "", true},
+		{"prefix of registered", "https://notebook.aiscope.io/callback/../", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got, err := client.ResolveRedirectURL(test.expectURL)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ResolveRedirectURL() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err == nil && got.String() != test.expected {
+				t.Errorf("ResolveRedirectURL() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestClient_IsScopeAllowed(t *testing.T) {
+	client := Client{ScopeRestrictions: []string{"openid", "mlflow:*"}}
+	tests := []struct {
+		scope    string
+		expected bool
+	}{
+		{"openid", true},
+		{"mlflow:read", true},
+		{"email", false},
+	}
+
+	for _, test := range tests {
+		if got := client.IsScopeAllowed(test.scope); got != test.expected {
+			t.Errorf("IsScopeAllowed(%q) = %v, want %v", test.scope, got, test.expected)
+		}
+	}
+	if !(Client{}).IsScopeAllowed("email") {
+		t.Errorf("all the scopes should be allowed without restrictions")
+	}
+}
@@ -37,8 +37,10 @@ type Options struct {
 	JwtSecret string `json:"-" yaml:"jwtSecret"`
 	// OAuthOptions defines options needed for integrated oauth plugins
 	OAuthOptions *oauth.Options `json:"oauthOptions" yaml:"oauthOptions"`
-	// KubectlImage is the image address we use to create kubectl pod for users who have admin access to the cluster.
+	// KubectlImage is the image address we use to create the kubectl pod of the web terminal.
 	KubectlImage string `json:"kubectlImage" yaml:"kubectlImage"`
+	// PasswordPolicy defines the rules of the passwords, enforced when the passwords are set
+	PasswordPolicy *PasswordPolicy `json:"passwordPolicy" yaml:"passwordPolicy"`
 }
 
 func NewOptions() *Options {
@@ -52,6 +54,7 @@ func NewOptions() *Options {
 		MultipleLogin:                   false,
 		JwtSecret:                       "",
 		KubectlImage:                    "aiscope/kubectl:v1.0.0",
+		PasswordPolicy:                  NewPasswordPolicy(),
 	}
 }
 
@@ -63,6 +66,9 @@ func (options *Options) Validate() []error {
 	if options.AuthenticateRateLimiterMaxTries > options.LoginHistoryMaximumEntries {
 		errs = append(errs, errors.New("authenticateRateLimiterMaxTries MUST not be greater than loginHistoryMaximumEntries"))
 	}
+	if options.PasswordPolicy.MinLength < 0 || options.PasswordPolicy.HistorySize < 0 || options.PasswordPolicy.MaxAge < 0 {
+		errs = append(errs, errors.New("passwordPolicy minLength, historySize and maxAge MUST not be negative"))
+	}
 	if err := identityprovider.SetupWithOptions(options.OAuthOptions.IdentityProviders); err != nil {
 		errs = append(errs, err)
 	}
@@ -78,5 +84,8 @@ func (options *Options) AddFlags(fs *pflag.FlagSet, s *Options) {
 	fs.IntVar(&options.LoginHistoryMaximumEntries, "login-history-maximum-entries", s.LoginHistoryMaximumEntries, "login-history-maximum-entries defines how many entries of login history should be kept.")
 	fs.DurationVar(&options.OAuthOptions.AccessTokenMaxAge, "access-token-max-age", s.OAuthOptions.AccessTokenMaxAge, "access-token-max-age control the lifetime of access tokens, 0 means no expiration.")
 	fs.StringVar(&s.KubectlImage, "kubectl-image", s.KubectlImage, "Setup the image used by kubectl terminal pod")
+	fs.IntVar(&options.PasswordPolicy.MinLength, "password-min-length", s.PasswordPolicy.MinLength, "The minimum length of the passwords.")
+	fs.IntVar(&options.PasswordPolicy.HistorySize, "password-history-size", s.PasswordPolicy.HistorySize, "The number of the recent passwords which can not be reused, 0 means the passwords can be reused.")
+	fs.DurationVar(&options.PasswordPolicy.MaxAge, "password-max-age", s.PasswordPolicy.MaxAge, "The maximum age of the passwords, 0 means the passwords never expire.")
 	fs.DurationVar(&options.MaximumClockSkew, "maximum-clock-skew", s.MaximumClockSkew, "The maximum time difference between the system clocks of the ks-apiserver that issued a JWT and the ks-apiserver that verified the JWT.")
 }
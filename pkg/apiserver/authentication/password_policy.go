@@ -0,0 +1,73 @@
+package authentication
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PasswordPolicy defines the rules of the passwords of the aiscope accounts,
+// the passwords managed by the identity providers are not restricted.
+type PasswordPolicy struct {
+	// MinLength is the minimum number of characters of the password
+	MinLength        int  `json:"minLength" yaml:"minLength"`
+	RequireUppercase bool `json:"requireUppercase" yaml:"requireUppercase"`
+	RequireLowercase bool `json:"requireLowercase" yaml:"requireLowercase"`
+	RequireDigit     bool `json:"requireDigit" yaml:"requireDigit"`
+	RequireSymbol    bool `json:"requireSymbol" yaml:"requireSymbol"`
+	// HistorySize is the number of the recent passwords, including the current one, which can not be reused.
+	// Zero means the passwords can be reused.
+	HistorySize int `json:"historySize" yaml:"historySize"`
+	// MaxAge is the maximum age of the password, the user can only change the password after it expires.
+	// Zero means the password never expires.
+	MaxAge time.Duration `json:"maxAge" yaml:"maxAge"`
+}
+
+func NewPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength: 6,
+	}
+}
+
+// Validate returns an error describing all the rules the password violates
+func (p *PasswordPolicy) Validate(password string) error {
+	var violations []string
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if p.RequireUppercase && !upper {
+		violations = append(violations, "an uppercase letter")
+	}
+	if p.RequireLowercase && !lower {
+		violations = append(violations, "a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "a symbol")
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("the password must contain %s", strings.Join(violations, ", "))
+	}
+	return nil
+}
+
+// IsExpired returns whether the password changed at the given time has expired
+func (p *PasswordPolicy) IsExpired(lastChangeTime time.Time) bool {
+	return p.MaxAge > 0 && time.Since(lastChangeTime) > p.MaxAge
+}
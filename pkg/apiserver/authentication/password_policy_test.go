@@ -0,0 +1,48 @@
+package authentication
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := &PasswordPolicy{
+		MinLength:        8,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	}
+
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"P@88w0rd", false},
+		{"P@8w0rd", true},
+		{"p@88w0rd", true},
+		{"P@88W0RD", true},
+		{"P@ssword", true},
+		{"P888w0rd", true},
+		{"密码P@88w0rd", false},
+	}
+
+	for _, test := range tests {
+		if err := policy.Validate(test.password); (err != nil) != test.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", test.password, err, test.wantErr)
+		}
+	}
+}
+
+func TestPasswordPolicyIsExpired(t *testing.T) {
+	if NewPasswordPolicy().IsExpired(time.Now().Add(-time.Hour * 24 * 365)) {
+		t.Errorf("the password should never expire by default")
+	}
+	policy := &PasswordPolicy{MaxAge: time.Hour}
+	if policy.IsExpired(time.Now()) {
+		t.Errorf("the password changed now should not expire")
+	}
+	if !policy.IsExpired(time.Now().Add(-2 * time.Hour)) {
+		t.Errorf("the password should expire after max age")
+	}
+}
@@ -28,6 +28,7 @@ const (
 	StaticToken       Type   = "static_token"
 	AuthorizationCode Type   = "code"
 	IDToken           Type   = "id_token"
+	MFAChallenge      Type   = "mfa_challenge"
 	headerKeyID       string = "kid"
 	headerAlgorithm   string = "alg"
 )
@@ -72,10 +73,19 @@ type Claims struct {
 	Username string `json:"username,omitempty"`
 	// Extra contains the additional information
 	Extra map[string][]string `json:"extra,omitempty"`
+	// Groups mapped from the identity provider at login
+	Groups []string `json:"groups,omitempty"`
 
 	// Used for issuing authorization code
 	// Scopes can be used to request that specific sets of information be made available as Claim Values.
 	Scopes []string `json:"scopes,omitempty"`
+	// RedirectURI is the redirection URI included in the authorization request,
+	// the token request MUST include the identical value.
+	RedirectURI string `json:"redirect_uri,omitempty"`
+	// CodeChallenge binds the authorization code to the code verifier of the client.
+	CodeChallenge string `json:"code_challenge,omitempty"`
+	// CodeChallengeMethod is the transformation applied to the code verifier.
+	CodeChallengeMethod string `json:"code_challenge_method,omitempty"`
 
 	// The following is well-known ID Token fields
 
@@ -109,6 +119,7 @@ func (s *issuer) IssueTo(request *IssueRequest) (string, error) {
 	claims := Claims{
 		Username:  request.User.GetName(),
 		Extra:     request.User.GetExtra(),
+		Groups:    request.User.GetGroups(),
 		TokenType: request.TokenType,
 		StandardClaims: jwt.StandardClaims{
 			IssuedAt: issueAt,
@@ -120,6 +131,9 @@ func (s *issuer) IssueTo(request *IssueRequest) (string, error) {
 	if len(request.Audience) > 0 {
 		claims.Audience = request.Audience
 	}
+	if request.Id != "" {
+		claims.Id = request.Id
+	}
 	if request.Name != "" {
 		claims.Name = request.Name
 	}
@@ -138,6 +152,13 @@ func (s *issuer) IssueTo(request *IssueRequest) (string, error) {
 	if len(request.Scopes) > 0 {
 		claims.Scopes = request.Scopes
 	}
+	if request.RedirectURI != "" {
+		claims.RedirectURI = request.RedirectURI
+	}
+	if request.CodeChallenge != "" {
+		claims.CodeChallenge = request.CodeChallenge
+		claims.CodeChallengeMethod = request.CodeChallengeMethod
+	}
 	if request.ExpiresIn > 0 {
 		claims.ExpiresAt = claims.IssuedAt + int64(request.ExpiresIn.Seconds())
 	}
@@ -190,8 +211,9 @@ func (s *issuer) Verify(token string) (*VerifiedResponse, error) {
 
 	verified := &VerifiedResponse{
 		User: &user.DefaultInfo{
-			Name:  claims.Username,
-			Extra: claims.Extra,
+			Name:   claims.Username,
+			Extra:  claims.Extra,
+			Groups: claims.Groups,
 		},
 		Claims: claims,
 	}
@@ -0,0 +1,35 @@
+package authorization
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// AlwaysAllow allows every request, it should only be used for debugging.
+	AlwaysAllow = "AlwaysAllow"
+	// RBAC authorizes requests against GlobalRoles, WorkspaceRoles and namespace Roles.
+	RBAC = "RBAC"
+)
+
+type Options struct {
+	Mode string `json:"mode" yaml:"mode"`
+}
+
+func NewOptions() *Options {
+	return &Options{Mode: RBAC}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet, s *Options) {
+	fs.StringVar(&o.Mode, "authorization", s.Mode, "Authorization setting, allowed values: AlwaysAllow, RBAC.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+	if !sets.NewString(AlwaysAllow, RBAC).Has(o.Mode) {
+		errs = append(errs, fmt.Errorf("authorization mode %s not support", o.Mode))
+	}
+	return errs
+}
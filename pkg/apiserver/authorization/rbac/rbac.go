@@ -0,0 +1,195 @@
+package rbac
+
+import (
+	"bytes"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"aiscope/pkg/apiserver/authorization/authorizer"
+	"aiscope/pkg/apiserver/request"
+	"aiscope/pkg/models/iam/am"
+)
+
+// RBACAuthorizer authorizes requests against the rules of the GlobalRoles, WorkspaceRoles
+// and namespace Roles bound to the requesting user or one of its groups.
+type RBACAuthorizer struct {
+	am am.AccessManagementInterface
+}
+
+// authorizingVisitor short-circuits once allowed, and collects any resolution errors encountered
+type authorizingVisitor struct {
+	requestAttributes authorizer.Attributes
+
+	allowed bool
+	reason  string
+	errors  []error
+}
+
+func (v *authorizingVisitor) visit(source fmt.Stringer, rule *rbacv1.PolicyRule, err error) bool {
+	if rule != nil && ruleAllows(v.requestAttributes, rule) {
+		v.allowed = true
+		v.reason = fmt.Sprintf("RBAC: allowed by %s", source.String())
+		return false
+	}
+	if err != nil {
+		v.errors = append(v.errors, err)
+	}
+	return true
+}
+
+func NewRBACAuthorizer(am am.AccessManagementInterface) *RBACAuthorizer {
+	return &RBACAuthorizer{am: am}
+}
+
+func (r *RBACAuthorizer) Authorize(requestAttributes authorizer.Attributes) (authorizer.Decision, string, error) {
+	ruleCheckingVisitor := &authorizingVisitor{requestAttributes: requestAttributes}
+
+	r.visitRulesFor(requestAttributes, ruleCheckingVisitor.visit)
+
+	if ruleCheckingVisitor.allowed {
+		return authorizer.DecisionAllow, ruleCheckingVisitor.reason, nil
+	}
+
+	// Build a detailed log of the denial.
+	// Make the whole block conditional so we don't do a lot of string-building we won't use.
+	if klog.V(4).Enabled() {
+		var operation string
+		if requestAttributes.IsResourceRequest() {
+			b := &bytes.Buffer{}
+			b.WriteString(`"`)
+			b.WriteString(requestAttributes.GetVerb())
+			b.WriteString(`" resource "`)
+			b.WriteString(requestAttributes.GetResource())
+			if len(requestAttributes.GetAPIGroup()) > 0 {
+				b.WriteString(`.`)
+				b.WriteString(requestAttributes.GetAPIGroup())
+			}
+			if len(requestAttributes.GetSubresource()) > 0 {
+				b.WriteString(`/`)
+				b.WriteString(requestAttributes.GetSubresource())
+			}
+			b.WriteString(`"`)
+			if len(requestAttributes.GetName()) > 0 {
+				b.WriteString(` named "`)
+				b.WriteString(requestAttributes.GetName())
+				b.WriteString(`"`)
+			}
+			operation = b.String()
+		} else {
+			operation = fmt.Sprintf("%q nonResourceURL %q", requestAttributes.GetVerb(), requestAttributes.GetPath())
+		}
+
+		var scope string
+		if ns := requestAttributes.GetNamespace(); len(ns) > 0 {
+			scope = fmt.Sprintf("in namespace %q", ns)
+		} else if ws := requestAttributes.GetWorkspace(); len(ws) > 0 {
+			scope = fmt.Sprintf("in workspace %q", ws)
+		} else {
+			scope = "globally"
+		}
+
+		klog.Infof("RBAC: no rules authorize user %q with groups %q to %s %s", requestAttributes.GetUser().GetName(), requestAttributes.GetUser().GetGroups(), operation, scope)
+	}
+
+	reason := ""
+	if len(ruleCheckingVisitor.errors) > 0 {
+		reason = fmt.Sprintf("RBAC: %v", utilerrors.NewAggregate(ruleCheckingVisitor.errors))
+	}
+	return authorizer.DecisionNoOpinion, reason, nil
+}
+
+// visitRulesFor walks the rules bound to the user from the widest scope to the narrowest:
+// global role bindings first, then the workspace role bindings of the requested workspace,
+// then the role bindings of the requested namespace.
+func (r *RBACAuthorizer) visitRulesFor(requestAttributes authorizer.Attributes, visitor func(source fmt.Stringer, rule *rbacv1.PolicyRule, err error) bool) {
+	requestUser := requestAttributes.GetUser()
+	if requestUser == nil {
+		return
+	}
+	username := requestUser.GetName()
+	groups := requestUser.GetGroups()
+
+	globalRoleBindings, err := r.am.ListGlobalRoleBindings(username, groups)
+	if !visitor(nil, nil, err) {
+		return
+	}
+	for _, globalRoleBinding := range globalRoleBindings {
+		if !r.visitRoleRef(globalRoleBinding.Name, globalRoleBinding.RoleRef, "", visitor) {
+			return
+		}
+	}
+
+	scope := requestAttributes.GetResourceScope()
+	if scope != request.WorkspaceScope && scope != request.NamespaceScope {
+		return
+	}
+
+	namespace := requestAttributes.GetNamespace()
+	workspace := requestAttributes.GetWorkspace()
+	if workspace == "" && namespace != "" {
+		workspace, err = r.am.GetNamespaceControlledWorkspace(namespace)
+		if err != nil && !errors.IsNotFound(err) {
+			if !visitor(nil, nil, err) {
+				return
+			}
+		}
+	}
+
+	if workspace != "" {
+		workspaceRoleBindings, err := r.am.ListWorkspaceRoleBindings(username, groups, workspace)
+		if !visitor(nil, nil, err) {
+			return
+		}
+		for _, workspaceRoleBinding := range workspaceRoleBindings {
+			if !r.visitRoleRef(workspaceRoleBinding.Name, workspaceRoleBinding.RoleRef, "", visitor) {
+				return
+			}
+		}
+	}
+
+	if scope != request.NamespaceScope || namespace == "" {
+		return
+	}
+
+	roleBindings, err := r.am.ListRoleBindings(username, groups, namespace)
+	if !visitor(nil, nil, err) {
+		return
+	}
+	for _, roleBinding := range roleBindings {
+		if !r.visitRoleRef(roleBinding.Name, roleBinding.RoleRef, namespace, visitor) {
+			return
+		}
+	}
+}
+
+func (r *RBACAuthorizer) visitRoleRef(bindingName string, roleRef rbacv1.RoleRef, namespace string, visitor func(source fmt.Stringer, rule *rbacv1.PolicyRule, err error) bool) bool {
+	rules, err := r.am.GetRoleReferenceRules(roleRef, namespace)
+	if err != nil {
+		return visitor(nil, nil, err)
+	}
+	source := &bindingDescriber{kind: roleRef.Kind, roleName: roleRef.Name, bindingName: bindingName, namespace: namespace}
+	for i := range rules {
+		if !visitor(source, &rules[i], nil) {
+			return false
+		}
+	}
+	return true
+}
+
+type bindingDescriber struct {
+	kind        string
+	roleName    string
+	bindingName string
+	namespace   string
+}
+
+func (d *bindingDescriber) String() string {
+	if d.namespace != "" {
+		return fmt.Sprintf("binding %q of %s %q to user in namespace %q", d.bindingName, d.kind, d.roleName, d.namespace)
+	}
+	return fmt.Sprintf("binding %q of %s %q to user", d.bindingName, d.kind, d.roleName)
+}
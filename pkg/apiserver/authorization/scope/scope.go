@@ -0,0 +1,68 @@
+package scope
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authorization/authorizer"
+)
+
+const (
+	// Full grants all the permissions of the user
+	Full = "user:full"
+	// ReadOnly grants the permissions of the user to read resources
+	ReadOnly = "user:read"
+	// ChangePassword only allows the user to change the password, it is granted to the tokens
+	// issued to the users whose password is expired or uninitialized, and can not be requested.
+	ChangePassword = "user:change-password"
+	// EnrollMFA only allows the user to enroll the two-factor authentication, it is granted to the tokens
+	// issued to the users required to use it by their global roles, and can not be requested.
+	EnrollMFA = "user:enroll-mfa"
+)
+
+var readVerbs = sets.NewString("get", "list", "watch")
+
+// IsValid returns whether the scope is known
+func IsValid(scope string) bool {
+	return scope == Full || scope == ReadOnly
+}
+
+// NewAuthorizer returns an authorizer which restricts the requests of the users authenticated with scoped tokens,
+// the request is denied if none of the scopes allows it, otherwise the decision is left to the next authorizer.
+func NewAuthorizer() authorizer.Authorizer {
+	return authorizer.AuthorizerFunc(func(a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetUser() == nil {
+			return authorizer.DecisionNoOpinion, "", nil
+		}
+		scopes := a.GetUser().GetExtra()[iamv1alpha2.ExtraScopes]
+		if len(scopes) == 0 {
+			return authorizer.DecisionNoOpinion, "", nil
+		}
+		for _, scope := range scopes {
+			if allows(scope, a) {
+				return authorizer.DecisionNoOpinion, "", nil
+			}
+		}
+		return authorizer.DecisionDeny, fmt.Sprintf("the request is not allowed by the token scopes %v", scopes), nil
+	})
+}
+
+func allows(scope string, a authorizer.Attributes) bool {
+	switch scope {
+	case Full:
+		return true
+	case ReadOnly:
+		return readVerbs.Has(a.GetVerb())
+	case ChangePassword:
+		return a.IsResourceRequest() && a.GetVerb() == "update" &&
+			a.GetResource() == iamv1alpha2.ResourcesPluralUser && a.GetSubresource() == "password" &&
+			a.GetName() == a.GetUser().GetName()
+	case EnrollMFA:
+		return a.IsResourceRequest() && a.GetResource() == iamv1alpha2.ResourcesPluralUser &&
+			a.GetSubresource() == "mfa" && a.GetName() == a.GetUser().GetName()
+	default:
+		return false
+	}
+}
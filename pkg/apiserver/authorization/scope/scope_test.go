@@ -0,0 +1,51 @@
+package scope
+
+import (
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authorization/authorizer"
+)
+
+func TestAuthorizer(t *testing.T) {
+	tests := []struct {
+		description string
+		scopes      []string
+		verb        string
+		subresource string
+		name        string
+		expected    authorizer.Decision
+	}{
+		{"unscoped token", nil, "delete", "", "", authorizer.DecisionNoOpinion},
+		{"full", []string{Full}, "delete", "", "", authorizer.DecisionNoOpinion},
+		{"read only get", []string{ReadOnly}, "get", "", "", authorizer.DecisionNoOpinion},
+		{"read only delete", []string{ReadOnly}, "delete", "", "", authorizer.DecisionDeny},
+		{"unknown scope", []string{"user:admin"}, "get", "", "", authorizer.DecisionDeny},
+		{"change own password", []string{ChangePassword}, "update", "password", "admin", authorizer.DecisionNoOpinion},
+		{"change password of others", []string{ChangePassword}, "update", "password", "other", authorizer.DecisionDeny},
+		{"get user with change password scope", []string{ChangePassword}, "get", "", "admin", authorizer.DecisionDeny},
+		{"enroll own mfa", []string{EnrollMFA}, "create", "mfa", "admin", authorizer.DecisionNoOpinion},
+		{"enroll mfa of others", []string{EnrollMFA}, "create", "mfa", "other", authorizer.DecisionDeny},
+		{"change password with enroll mfa scope", []string{EnrollMFA}, "update", "password", "admin", authorizer.DecisionDeny},
+		{"change password with both scopes", []string{ChangePassword, EnrollMFA}, "update", "password", "admin", authorizer.DecisionNoOpinion},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			info := &user.DefaultInfo{Name: "admin"}
+			if test.scopes != nil {
+				info.Extra = map[string][]string{iamv1alpha2.ExtraScopes: test.scopes}
+			}
+			decision, _, err := NewAuthorizer().Authorize(authorizer.AttributesRecord{User: info, Verb: test.verb,
+				Resource: iamv1alpha2.ResourcesPluralUser, Subresource: test.subresource, Name: test.name, ResourceRequest: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision != test.expected {
+				t.Errorf("Authorize() = %v, want %v", decision, test.expected)
+			}
+		})
+	}
+}
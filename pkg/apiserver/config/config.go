@@ -2,6 +2,8 @@ package config
 
 import (
 	"aiscope/pkg/apiserver/authentication"
+	"aiscope/pkg/apiserver/authorization"
+	"aiscope/pkg/models/terminal"
 	"aiscope/pkg/simple/client/cache"
 	"aiscope/pkg/simple/client/k8s"
 	"aiscope/pkg/simple/client/ldap"
@@ -23,6 +25,8 @@ type Config struct {
 	LdapOptions           *ldap.Options           `json:"-,omitempty" yaml:"ldap,omitempty" mapstructure:"ldap"`
 	RedisOptions          *cache.Options          `json:"redis,omitempty" yaml:"redis,omitempty" mapstructure:"redis"`
 	AuthenticationOptions *authentication.Options `json:"authentication,omitempty" yaml:"authentication,omitempty" mapstructure:"authentication"`
+	AuthorizationOptions  *authorization.Options  `json:"authorization,omitempty" yaml:"authorization,omitempty" mapstructure:"authorization"`
+	TerminalOptions       *terminal.Options       `json:"terminal,omitempty" yaml:"terminal,omitempty" mapstructure:"terminal"`
 }
 
 func New() *Config {
@@ -31,6 +35,8 @@ func New() *Config {
 		LdapOptions:			ldap.NewOptions(),
 		RedisOptions: 			cache.NewRedisOptions(),
 		AuthenticationOptions:  authentication.NewOptions(),
+		AuthorizationOptions:   authorization.NewOptions(),
+		TerminalOptions:        terminal.NewOptions(),
 	}
 }
 
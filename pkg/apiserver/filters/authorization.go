@@ -0,0 +1,115 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/klog"
+
+	"aiscope/pkg/apiserver/authorization/authorizer"
+	"aiscope/pkg/apiserver/request"
+)
+
+// WithAuthorization passes all authorized requests on to handler, and returns forbidden error otherwise.
+func WithAuthorization(handler http.Handler, authorizers authorizer.Authorizer) http.Handler {
+	if authorizers == nil {
+		klog.Warningf("Authorization is disabled")
+		return handler
+	}
+
+	defaultSerializer := serializer.NewCodecFactory(runtime.NewScheme()).WithoutConversion()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		attributes, err := getAuthorizerAttributes(ctx)
+		if err != nil {
+			responsewriters.InternalError(w, req, err)
+			return
+		}
+
+		authorized, reason, err := authorizers.Authorize(attributes)
+		if authorized == authorizer.DecisionAllow {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		if err != nil {
+			responsewriters.InternalError(w, req, err)
+			return
+		}
+
+		klog.V(4).Infof("Forbidden: %#v, Reason: %q", req.RequestURI, reason)
+		gv := schema.GroupVersion{Group: attributes.GetAPIGroup(), Version: attributes.GetAPIVersion()}
+		gr := schema.GroupResource{Group: attributes.GetAPIGroup(), Resource: attributes.GetResource()}
+		responsewriters.ErrorNegotiated(apierrors.NewForbidden(gr, attributes.GetName(), errors.New(forbiddenMessage(attributes, reason))), defaultSerializer, gv, w, req)
+	})
+}
+
+func getAuthorizerAttributes(ctx context.Context) (authorizer.Attributes, error) {
+	attribs := authorizer.AttributesRecord{}
+
+	user, ok := request.UserFrom(ctx)
+	if ok {
+		attribs.User = user
+	}
+
+	requestInfo, found := request.RequestInfoFrom(ctx)
+	if !found {
+		return nil, errors.New("no RequestInfo found in the context")
+	}
+
+	// Start with common attributes that apply to resource and non-resource requests
+	attribs.ResourceScope = requestInfo.ResourceScope
+	attribs.ResourceRequest = requestInfo.IsResourceRequest
+	attribs.Path = requestInfo.Path
+	attribs.Verb = requestInfo.Verb
+	attribs.KubernetesRequest = requestInfo.IsKubernetesRequest
+	attribs.APIGroup = requestInfo.APIGroup
+	attribs.APIVersion = requestInfo.APIVersion
+	attribs.Resource = requestInfo.Resource
+	attribs.Subresource = requestInfo.Subresource
+	attribs.Namespace = requestInfo.Namespace
+	attribs.Name = requestInfo.Name
+	attribs.Workspace = requestInfo.Workspace
+
+	return &attribs, nil
+}
+
+func forbiddenMessage(attributes authorizer.Attributes, reason string) string {
+	username := ""
+	if user := attributes.GetUser(); user != nil {
+		username = user.GetName()
+	}
+
+	var message string
+	if !attributes.IsResourceRequest() {
+		message = fmt.Sprintf("User %q cannot %s path %q", username, attributes.GetVerb(), attributes.GetPath())
+	} else {
+		resource := attributes.GetResource()
+		if subresource := attributes.GetSubresource(); len(subresource) > 0 {
+			resource = resource + "/" + subresource
+		}
+
+		switch {
+		case len(attributes.GetNamespace()) > 0:
+			message = fmt.Sprintf("User %q cannot %s resource %q in namespace %q", username, attributes.GetVerb(), resource, attributes.GetNamespace())
+		case len(attributes.GetWorkspace()) > 0:
+			message = fmt.Sprintf("User %q cannot %s resource %q in workspace %q", username, attributes.GetVerb(), resource, attributes.GetWorkspace())
+		default:
+			message = fmt.Sprintf("User %q cannot %s resource %q at the global scope", username, attributes.GetVerb(), resource)
+		}
+	}
+
+	if len(reason) > 0 {
+		message = message + ": " + reason
+	}
+	return message
+}
@@ -5,9 +5,11 @@ import (
 	"aiscope/pkg/utils/iputil"
 	"context"
 	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	k8srequest "k8s.io/apiserver/pkg/endpoints/request"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -24,6 +26,13 @@ type RequestInfoResolver interface {
 
 var specialVerbs = sets.NewString("proxy", "watch")
 
+// specialVerbsNoSubresources contains root verbs which do not allow subresources
+var specialVerbsNoSubresources = sets.NewString("proxy")
+
+// namespaceSubresources contains subresources of namespace
+// this list allows the parser to distinguish between a namespace subresource, and a namespaced resource
+var namespaceSubresources = sets.NewString("status", "finalize")
+
 // RequestInfo holds information parsed from the http.Request,
 // extended from k8s.io/apiserver/pkg/endpoints/request/requestinfo.go
 type RequestInfo struct {
@@ -97,6 +106,12 @@ func (r *RequestInfoFactory) NewRequestInfo(req *http.Request) (*RequestInfo, er
 	requestInfo.APIPrefix = currentParts[0]
 	currentParts = currentParts[1:]
 
+	// URL forms: /api/v1/*, /{apiPrefix}/{group}/{version}/*
+	if requestInfo.APIPrefix != "api" {
+		requestInfo.APIGroup = currentParts[0]
+		currentParts = currentParts[1:]
+	}
+
 	requestInfo.IsResourceRequest = true
 	requestInfo.APIVersion = currentParts[0]
 	currentParts = currentParts[1:]
@@ -125,9 +140,88 @@ func (r *RequestInfoFactory) NewRequestInfo(req *http.Request) (*RequestInfo, er
 		}
 	}
 
+	// discovery requests like /apis/{group}/{version}
+	if len(currentParts) == 0 {
+		requestInfo.ResourceScope = r.resolveResourceScope(requestInfo)
+		return &requestInfo, nil
+	}
+
+	// URL forms: /workspaces/{workspace}/*, where parts are adjusted to be relative to the workspace
+	if currentParts[0] == "workspaces" {
+		if len(currentParts) > 1 {
+			requestInfo.Workspace = currentParts[1]
+		}
+		if len(currentParts) > 2 {
+			currentParts = currentParts[2:]
+		}
+	}
+
+	// URL forms: /namespaces/{namespace}/{kind}/*, where parts are adjusted to be relative to kind
+	if currentParts[0] == "namespaces" {
+		if len(currentParts) > 1 {
+			requestInfo.Namespace = currentParts[1]
+
+			// if there is another step after the namespace name and it is not a known namespace subresource
+			// move currentParts to include it as a resource in its own right
+			if len(currentParts) > 2 && !namespaceSubresources.Has(currentParts[2]) {
+				currentParts = currentParts[2:]
+			}
+		}
+	} else {
+		requestInfo.Namespace = metav1.NamespaceNone
+	}
+
+	// parsing successful, so we now know the proper value for .Parts
+	requestInfo.Parts = currentParts
+
+	// parts look like: resource/resourceName/subresource/other/stuff/we/don't/interpret
+	switch {
+	case len(requestInfo.Parts) >= 3 && !specialVerbsNoSubresources.Has(requestInfo.Verb):
+		requestInfo.Subresource = requestInfo.Parts[2]
+		fallthrough
+	case len(requestInfo.Parts) >= 2:
+		requestInfo.Name = requestInfo.Parts[1]
+		fallthrough
+	case len(requestInfo.Parts) >= 1:
+		requestInfo.Resource = requestInfo.Parts[0]
+	}
+
+	// if there's no name on the request and we thought it was a get before, then the actual verb is a list or a watch
+	if len(requestInfo.Name) == 0 && requestInfo.Verb == "get" {
+		if watch, _ := strconv.ParseBool(req.URL.Query().Get("watch")); watch {
+			requestInfo.Verb = "watch"
+		} else {
+			requestInfo.Verb = "list"
+		}
+	}
+
+	// if there's no name on the request and we thought it was a delete before, then the actual verb is deletecollection
+	if len(requestInfo.Name) == 0 && requestInfo.Verb == "delete" {
+		requestInfo.Verb = "deletecollection"
+	}
+
+	requestInfo.ResourceScope = r.resolveResourceScope(requestInfo)
+
 	return &requestInfo, nil
 }
 
+// resolveResourceScope returns the narrowest scope the requested resource lives in
+func (r *RequestInfoFactory) resolveResourceScope(request RequestInfo) string {
+	if request.Namespace != "" {
+		return NamespaceScope
+	}
+
+	if request.Workspace != "" {
+		return WorkspaceScope
+	}
+
+	if request.Cluster != "" {
+		return ClusterScope
+	}
+
+	return GlobalScope
+}
+
 type requestInfoKeyType int
 
 // requestInfoKey is the RequestInfo key for the context. It's of private type here. Because
@@ -0,0 +1,146 @@
+package request
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func newTestRequestInfoResolver() RequestInfoResolver {
+	return &RequestInfoFactory{
+		APIPrefixes: sets.NewString("api", "apis", "aiapis"),
+	}
+}
+
+func TestRequestInfoFactory_NewRequestInfo(t *testing.T) {
+	tests := []struct {
+		name                      string
+		url                       string
+		method                    string
+		expectedVerb              string
+		expectedResource          string
+		expectedSubresource       string
+		expectedName              string
+		expectedAPIGroup          string
+		expectedWorkspace         string
+		expectedNamespace         string
+		expectedResourceScope     string
+		expectedIsResourceRequest bool
+		expectedKubernetesRequest bool
+	}{
+		{
+			name:                      "login",
+			url:                       "/oauth/token",
+			method:                    http.MethodPost,
+			expectedVerb:              "POST",
+			expectedIsResourceRequest: false,
+		},
+		{
+			name:                      "list kubernetes namespaces",
+			url:                       "/api/v1/namespaces",
+			method:                    http.MethodGet,
+			expectedVerb:              "list",
+			expectedResource:          "namespaces",
+			expectedResourceScope:     GlobalScope,
+			expectedIsResourceRequest: true,
+			expectedKubernetesRequest: true,
+		},
+		{
+			name:                      "create workspace",
+			url:                       "/aiapis/tenant.aiscope/v1alpha2/workspaces",
+			method:                    http.MethodPost,
+			expectedVerb:              "create",
+			expectedResource:          "workspaces",
+			expectedAPIGroup:          "tenant.aiscope",
+			expectedResourceScope:     GlobalScope,
+			expectedIsResourceRequest: true,
+		},
+		{
+			name:                      "get workspace",
+			url:                       "/aiapis/tenant.aiscope/v1alpha2/workspaces/ws1",
+			method:                    http.MethodGet,
+			expectedVerb:              "get",
+			expectedResource:          "workspaces",
+			expectedName:              "ws1",
+			expectedAPIGroup:          "tenant.aiscope",
+			expectedWorkspace:         "ws1",
+			expectedResourceScope:     WorkspaceScope,
+			expectedIsResourceRequest: true,
+		},
+		{
+			name:                      "list namespaces of workspace",
+			url:                       "/aiapis/tenant.aiscope/v1alpha2/workspaces/ws1/namespaces",
+			method:                    http.MethodGet,
+			expectedVerb:              "list",
+			expectedResource:          "namespaces",
+			expectedAPIGroup:          "tenant.aiscope",
+			expectedWorkspace:         "ws1",
+			expectedResourceScope:     WorkspaceScope,
+			expectedIsResourceRequest: true,
+		},
+		{
+			name:                      "list namespaces of workspace member",
+			url:                       "/aiapis/tenant.aiscope/v1alpha2/workspaces/ws1/workspacemembers/tom/namespaces",
+			method:                    http.MethodGet,
+			expectedVerb:              "get",
+			expectedResource:          "workspacemembers",
+			expectedSubresource:       "namespaces",
+			expectedName:              "tom",
+			expectedAPIGroup:          "tenant.aiscope",
+			expectedWorkspace:         "ws1",
+			expectedResourceScope:     WorkspaceScope,
+			expectedIsResourceRequest: true,
+		},
+		{
+			name:                      "delete trackingserver",
+			url:                       "/aiapis/experiment.aiscope/v1alpha2/namespaces/ns1/trackingservers/mlflow",
+			method:                    http.MethodDelete,
+			expectedVerb:              "delete",
+			expectedResource:          "trackingservers",
+			expectedName:              "mlflow",
+			expectedAPIGroup:          "experiment.aiscope",
+			expectedNamespace:         "ns1",
+			expectedResourceScope:     NamespaceScope,
+			expectedIsResourceRequest: true,
+		},
+		{
+			name:                      "watch kubernetes deployments",
+			url:                       "/apis/apps/v1/namespaces/ns1/deployments?watch=true",
+			method:                    http.MethodGet,
+			expectedVerb:              "watch",
+			expectedResource:          "deployments",
+			expectedAPIGroup:          "apps",
+			expectedNamespace:         "ns1",
+			expectedResourceScope:     NamespaceScope,
+			expectedIsResourceRequest: true,
+			expectedKubernetesRequest: true,
+		},
+	}
+
+	requestInfoResolver := newTestRequestInfoResolver()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, test.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			requestInfo, err := requestInfoResolver.NewRequestInfo(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []interface{}{requestInfo.Verb, requestInfo.Resource, requestInfo.Subresource, requestInfo.Name,
+				requestInfo.APIGroup, requestInfo.Workspace, requestInfo.Namespace, requestInfo.ResourceScope,
+				requestInfo.IsResourceRequest, requestInfo.IsKubernetesRequest}
+			expected := []interface{}{test.expectedVerb, test.expectedResource, test.expectedSubresource, test.expectedName,
+				test.expectedAPIGroup, test.expectedWorkspace, test.expectedNamespace, test.expectedResourceScope,
+				test.expectedIsResourceRequest, test.expectedKubernetesRequest}
+			if diff := cmp.Diff(got, expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", expected, diff)
+			}
+		})
+	}
+}
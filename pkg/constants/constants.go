@@ -8,13 +8,18 @@ const (
 
 	CreatorAnnotationKey              = "aiscope.io/creator"
 	AIScopeCreator                    = "aiscope"
+	SystemCreator                     = "system"
 	AdminUserName                 	  = "admin"
 	UserTag           = "User"
 	GroupTag          = "Group"
 
 	WorkspaceTag     = "Workspace"
+	WorkspaceMemberTag  = "Workspace Member"
+	AccessManagementTag = "Access Management"
 	NamespaceTag     = "Namespace"
 	AuthenticationTag = "Authentication"
+	TerminalTag       = "Terminal"
 
 	ExperimentTrackingServerTag       = "Tracking Server"
+	ExperimentCodeServerTag           = "Code Server"
 )
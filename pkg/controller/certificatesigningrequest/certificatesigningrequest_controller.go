@@ -36,11 +36,11 @@ const (
 )
 
 type Controller struct {
-	k8sclient   kubernetes.Interface
-	csrInformer certificatesinformers.CertificateSigningRequestInformer
-	csrLister   certificateslisters.CertificateSigningRequestLister
-	csrSynced   cache.InformerSynced
-	cmSynced    cache.InformerSynced
+	k8sclient    kubernetes.Interface
+	csrInformer  certificatesinformers.CertificateSigningRequestInformer
+	csrLister    certificateslisters.CertificateSigningRequestLister
+	csrSynced    cache.InformerSynced
+	secretSynced cache.InformerSynced
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
@@ -54,7 +54,7 @@ type Controller struct {
 }
 
 func NewController(k8sClient kubernetes.Interface, csrInformer certificatesinformers.CertificateSigningRequestInformer,
-	configMapInformer corev1informers.ConfigMapInformer, config *rest.Config) *Controller {
+	secretInformer corev1informers.SecretInformer, config *rest.Config) *Controller {
 	// Create event broadcaster
 	// Add sample-controller types to the default Kubernetes Scheme so Events can be
 	// logged for sample-controller types.
@@ -69,8 +69,8 @@ func NewController(k8sClient kubernetes.Interface, csrInformer certificatesinfor
 		csrInformer:        csrInformer,
 		csrLister:          csrInformer.Lister(),
 		csrSynced:          csrInformer.Informer().HasSynced,
-		cmSynced:           configMapInformer.Informer().HasSynced,
-		kubeconfigOperator: kubeconfig.NewOperator(k8sClient, configMapInformer.Lister(), config),
+		secretSynced:       secretInformer.Informer().HasSynced,
+		kubeconfigOperator: kubeconfig.NewOperator(k8sClient, secretInformer.Lister(), config, ""),
 		workqueue:          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "CertificateSigningRequest"),
 		recorder:           recorder,
 	}
@@ -94,7 +94,7 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 
 	// Wait for the caches to be csrSynced before starting workers
 	klog.Info("Waiting for csrInformer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.csrSynced, c.cmSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.csrSynced, c.secretSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
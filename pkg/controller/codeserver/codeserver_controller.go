@@ -17,46 +17,793 @@ limitations under the License.
 package codeserver
 
 import (
+	"aiscope/pkg/utils/k8sutil"
+	"aiscope/pkg/utils/sliceutil"
 	"context"
-
-	"k8s.io/apimachinery/pkg/runtime"
+	"fmt"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"net/url"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	experimentv1alpha2 "aiscope/pkg/apis/experiment/v1alpha2"
+	"github.com/traefik/traefik/v2/pkg/config/dynamic"
+	traefikclient "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/clientset/versioned"
+	traefikv1alpha1 "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	networkv1 "k8s.io/api/networking/v1"
+	resourcev1 "k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	successSynced = "Synced"
+	failedSynced  = "FailedSync"
+	// is synced successfully
+	messageResourceSynced = "CodeServer synced successfully"
+	controllerName        = "codeserver-controller"
+	finalizer             = "finalizers.aiscope.io/codeserver"
+	syncFailMessage       = "Failed to sync: %s"
+
+	codeServerPort     = 8443
+	codeServerPortName = "server"
+	configVolumeName   = "config"
+	configMountPath    = "/config"
+	workspacePath      = "/config/workspace"
+	passwordSecretKey  = "password"
+	passwordLength     = 16
+	gitCloneImage      = "alpine/git:v2.32.0"
+	gitCloneScript     = `if [ -z "$(ls -A ` + workspacePath + ` 2>/dev/null)" ]; then git clone ${GIT_BRANCH:+--branch "$GIT_BRANCH"} "$GIT_REPOSITORY" ` + workspacePath + `; fi`
+	linuxServerUserID  = "1000"
+	linuxServerGroupID = "1000"
 )
 
 // CodeServerReconciler reconciles a CodeServer object
 type CodeServerReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	TraefikClient           traefikclient.Interface
+	Logger                  logr.Logger
+	Recorder                record.EventRecorder
+	IngressController       string
+	MaxConcurrentReconciles int
 }
 
 //+kubebuilder:rbac:groups=experiment.aiscope,resources=codeservers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=experiment.aiscope,resources=codeservers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=experiment.aiscope,resources=codeservers/finalizers,verbs=update
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the CodeServer object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.10.0/pkg/reconcile
+// Reconcile compares the state specified by the CodeServer object against the
+// actual cluster state, and creates or updates the PersistentVolumeClaim,
+// password Secret, Deployment, Service and ingress of the code server.
 func (r *CodeServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := r.Logger.WithValues("codeServer", req.NamespacedName)
+	rootCtx := context.Background()
+
+	codeServer := &experimentv1alpha2.CodeServer{}
+	if err := r.Get(rootCtx, req.NamespacedName, codeServer); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if codeServer.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !sliceutil.HasString(codeServer.ObjectMeta.Finalizers, finalizer) {
+			codeServer.ObjectMeta.Finalizers = append(codeServer.ObjectMeta.Finalizers, finalizer)
+			if err := r.Update(rootCtx, codeServer); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if sliceutil.HasString(codeServer.ObjectMeta.Finalizers, finalizer) {
+			codeServer.ObjectMeta.Finalizers = sliceutil.RemoveString(codeServer.ObjectMeta.Finalizers, func(item string) bool {
+				return item == finalizer
+			})
+			logger.V(4).Info("update codeServer")
+			if err := r.Update(rootCtx, codeServer); err != nil {
+				logger.Error(err, "update codeServer failed")
+				return ctrl.Result{}, err
+			}
+		}
 
-	// TODO(user): your logic here
+		return ctrl.Result{}, nil
+	}
 
+	if err := r.reconcilePersistentVolume(rootCtx, logger, codeServer); err != nil {
+		r.Recorder.Event(codeServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reconcileSecret(rootCtx, logger, codeServer); err != nil {
+		r.Recorder.Event(codeServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reconcileDeployment(rootCtx, logger, codeServer); err != nil {
+		r.Recorder.Event(codeServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reconcileService(rootCtx, logger, codeServer); err != nil {
+		r.Recorder.Event(codeServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reconcileIngress(rootCtx, logger, codeServer); err != nil {
+		r.Recorder.Event(codeServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+		return reconcile.Result{}, err
+	}
+
+	r.Recorder.Event(codeServer, corev1.EventTypeNormal, successSynced, messageResourceSynced)
 	return ctrl.Result{}, nil
 }
 
+// passwordSecretRef returns the secret key which holds the password of the code server,
+// nil means the code server is not protected by password
+func passwordSecretRef(instance *experimentv1alpha2.CodeServer) *corev1.SecretKeySelector {
+	if instance.Spec.AuthType == experimentv1alpha2.CodeServerAuthNone {
+		return nil
+	}
+	if instance.Spec.PasswordSecretRef != nil {
+		return instance.Spec.PasswordSecretRef
+	}
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: instance.Name},
+		Key:                  passwordSecretKey,
+	}
+}
+
+func (r *CodeServerReconciler) deleteSecret(ctx context.Context, instance *experimentv1alpha2.CodeServer) error {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, secret); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !k8sutil.IsControlledBy(secret.OwnerReferences, experimentv1alpha2.ResourceKindCodeServer, instance.Name) {
+		return nil
+	}
+
+	if err := r.Delete(ctx, secret); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// reconcileSecret generates a random password for the code server when password
+// authentication is enabled and no password secret is referenced
+func (r *CodeServerReconciler) reconcileSecret(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.CodeServer) error {
+	if instance.Spec.AuthType == experimentv1alpha2.CodeServerAuthNone || instance.Spec.PasswordSecretRef != nil {
+		if err := r.deleteSecret(ctx, instance); err != nil {
+			logger.Error(err, "delete secret failed")
+			return err
+		}
+		return nil
+	}
+
+	currentSecret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentSecret); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "get codeserver secret failed")
+			return err
+		}
+
+		expectSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      instance.Name,
+				Namespace: instance.Namespace,
+				Labels:    labelsForCodeServer(instance.Name),
+			},
+			Data: map[string][]byte{
+				passwordSecretKey: []byte(rand.String(passwordLength)),
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+		if err := controllerutil.SetControllerReference(instance, expectSecret, scheme.Scheme); err != nil {
+			logger.Error(err, "set controller reference failed")
+			return err
+		}
+
+		logger.V(4).Info("create codeserver secret", "codeserver", instance.Name)
+		if err := r.Create(ctx, expectSecret); err != nil {
+			logger.Error(err, "create codeserver secret failed")
+			return err
+		}
+		return nil
+	}
+
+	// the generated password is kept as long as it exists
+	if len(currentSecret.Data[passwordSecretKey]) == 0 {
+		if currentSecret.Data == nil {
+			currentSecret.Data = make(map[string][]byte)
+		}
+		currentSecret.Data[passwordSecretKey] = []byte(rand.String(passwordLength))
+		logger.V(4).Info("update codeserver secret", "codeserver", instance.Name)
+		if err := r.Update(ctx, currentSecret); err != nil {
+			logger.Error(err, "update codeserver secret failed")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *CodeServerReconciler) reconcileDeployment(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.CodeServer) error {
+	expectDeployment, err := newDeploymentForCodeServer(instance)
+	if err != nil {
+		logger.Error(err, "new deployment failed")
+		return err
+	}
+
+	if err := controllerutil.SetControllerReference(instance, expectDeployment, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+
+	currentDeployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentDeployment); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("create codeserver deployment", "codeserver", instance.Name)
+			if err := r.Create(ctx, expectDeployment); err != nil {
+				logger.Error(err, "create codeserver deployment failed")
+				return err
+			}
+			return nil
+		}
+
+		logger.Error(err, "get codeserver deployment failed")
+		return err
+	} else {
+		if !reflect.DeepEqual(expectDeployment.Spec, currentDeployment.Spec) {
+			currentDeployment.Spec = expectDeployment.Spec
+			logger.V(4).Info("update codeserver deployment", "codeserver", instance.Name)
+			if err := r.Update(ctx, currentDeployment); err != nil {
+				logger.Error(err, "update codeserver deployment failed")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newDeploymentForCodeServer(instance *experimentv1alpha2.CodeServer) (*appsv1.Deployment, error) {
+	replicas := int32(1)
+	labels := labelsForCodeServer(instance.Name)
+
+	env := []corev1.EnvVar{
+		{
+			Name:  "PUID",
+			Value: linuxServerUserID,
+		},
+		{
+			Name:  "PGID",
+			Value: linuxServerGroupID,
+		},
+		{
+			Name:  "DEFAULT_WORKSPACE",
+			Value: workspacePath,
+		},
+	}
+
+	if instance.Spec.URL != "" {
+		parsedUrl, err := url.Parse(instance.Spec.URL)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, corev1.EnvVar{
+			Name:  "PROXY_DOMAIN",
+			Value: parsedUrl.Host,
+		})
+	}
+
+	if secretRef := passwordSecretRef(instance); secretRef != nil {
+		env = append(env, []corev1.EnvVar{
+			{
+				Name: "PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: secretRef,
+				},
+			},
+			{
+				Name: "SUDO_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: secretRef,
+				},
+			},
+		}...)
+	}
+
+	configVolume := corev1.Volume{
+		Name: configVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+	if instance.Spec.VolumeSize != "" && instance.Spec.StorageClassName != "" {
+		configVolume.VolumeSource = corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: instance.Name,
+			},
+		}
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      configVolumeName,
+			MountPath: configMountPath,
+		},
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			// the workspace volume is ReadWriteOnce
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Image:           instance.Spec.Image,
+							Name:            "codeserver",
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Ports: []corev1.ContainerPort{{
+								ContainerPort: codeServerPort,
+								Name:          codeServerPortName,
+							}},
+							Env:          env,
+							Resources:    instance.Spec.Resources,
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Volumes: []corev1.Volume{configVolume},
+				},
+			},
+		},
+	}
+
+	if instance.Spec.GitRepository != "" {
+		deployment.Spec.Template.Spec.InitContainers = []corev1.Container{
+			{
+				Image:           gitCloneImage,
+				Name:            "git-clone",
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"sh", "-c", gitCloneScript},
+				Env: []corev1.EnvVar{
+					{
+						Name:  "GIT_REPOSITORY",
+						Value: instance.Spec.GitRepository,
+					},
+					{
+						Name:  "GIT_BRANCH",
+						Value: instance.Spec.GitBranch,
+					},
+				},
+				VolumeMounts: volumeMounts,
+			},
+		}
+	}
+
+	return deployment, nil
+}
+
+func (r *CodeServerReconciler) reconcileService(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.CodeServer) error {
+	expectService := newServiceForCodeServer(instance)
+	if err := controllerutil.SetControllerReference(instance, expectService, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+
+	currentService := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentService); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("create codeserver service", "codeserver", instance.Name)
+			if err := r.Create(ctx, expectService); err != nil {
+				logger.Error(err, "create codeserver service failed")
+				return err
+			}
+			return nil
+		}
+
+		logger.Error(err, "get codeserver service failed")
+		return err
+	} else {
+		// ClusterIP is allocated by the apiserver and can not be changed
+		expectService.Spec.ClusterIP = currentService.Spec.ClusterIP
+		expectService.Spec.ClusterIPs = currentService.Spec.ClusterIPs
+		if !reflect.DeepEqual(expectService.Spec.Ports, currentService.Spec.Ports) ||
+			!reflect.DeepEqual(expectService.Spec.Selector, currentService.Spec.Selector) {
+			currentService.Spec = expectService.Spec
+			logger.V(4).Info("update codeserver service", "codeserver", instance.Name)
+			if err := r.Update(ctx, currentService); err != nil {
+				logger.Error(err, "update codeserver service failed")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newServiceForCodeServer(instance *experimentv1alpha2.CodeServer) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+			Labels:    labelsForCodeServer(instance.Name),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       codeServerPortName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       codeServerPort,
+					TargetPort: intstr.FromInt(codeServerPort),
+				},
+			},
+			Selector: labelsForCodeServer(instance.Name),
+		},
+	}
+
+	return service
+}
+
+func labelsForCodeServer(name string) map[string]string {
+	return map[string]string{"app": "codeserver", experimentv1alpha2.CodeServerLabel: name}
+}
+
+func (r *CodeServerReconciler) reconcileIngress(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.CodeServer) error {
+	if instance.Spec.URL == "" {
+		return nil
+	}
+
+	parsedUrl, err := url.Parse(instance.Spec.URL)
+	if err != nil {
+		logger.Error(err, "parse url failed")
+		return err
+	}
+
+	if r.IngressController == "traefik" {
+		if err := r.reconcileTraefikRoute(ctx, logger, instance, parsedUrl); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if r.IngressController == "nginx" {
+		if err := r.reconcileNginxIngress(ctx, logger, instance, parsedUrl); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func (r *CodeServerReconciler) reconcileTraefikRoute(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.CodeServer, parsedUrl *url.URL) error {
+	expectIngressRoute := ingressRouteForCodeServer(instance, parsedUrl)
+	if err := controllerutil.SetControllerReference(instance, expectIngressRoute, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+	currentIngressRoute, err := r.TraefikClient.TraefikV1alpha1().IngressRoutes(instance.Namespace).Get(ctx, instance.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			_, err = r.TraefikClient.TraefikV1alpha1().IngressRoutes(instance.Namespace).Create(ctx, expectIngressRoute, metav1.CreateOptions{})
+			if err != nil {
+				logger.Error(err, "create IngressRoute failed")
+				return err
+			}
+		} else {
+			logger.Error(err, "get IngressRoute failed")
+			return err
+		}
+	} else {
+		if !reflect.DeepEqual(expectIngressRoute.Spec, currentIngressRoute.Spec) {
+			currentIngressRoute.Spec = expectIngressRoute.Spec
+			_, err = r.TraefikClient.TraefikV1alpha1().IngressRoutes(instance.Namespace).Update(ctx, currentIngressRoute, metav1.UpdateOptions{})
+			if err != nil {
+				logger.Error(err, "update IngressRoute failed")
+				return err
+			}
+		}
+	}
+
+	if !hasPathPrefix(parsedUrl) {
+		return nil
+	}
+
+	expectMiddleware := middlewareForCodeServer(instance, parsedUrl)
+	if err := controllerutil.SetControllerReference(instance, expectMiddleware, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+	currentMiddleware, err := r.TraefikClient.TraefikV1alpha1().Middlewares(instance.Namespace).Get(ctx, instance.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			_, err = r.TraefikClient.TraefikV1alpha1().Middlewares(instance.Namespace).Create(ctx, expectMiddleware, metav1.CreateOptions{})
+			if err != nil {
+				logger.Error(err, "create Middlewares failed")
+				return err
+			}
+		} else {
+			logger.Error(err, "get Middlewares failed")
+			return err
+		}
+	} else {
+		if !reflect.DeepEqual(expectMiddleware.Spec, currentMiddleware.Spec) {
+			currentMiddleware.Spec = expectMiddleware.Spec
+			_, err = r.TraefikClient.TraefikV1alpha1().Middlewares(instance.Namespace).Update(ctx, currentMiddleware, metav1.UpdateOptions{})
+			if err != nil {
+				logger.Error(err, "update Middlewares failed")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasPathPrefix(parsedUrl *url.URL) bool {
+	return parsedUrl.Path != "" && parsedUrl.Path != "/"
+}
+
+func ingressRouteForCodeServer(instance *experimentv1alpha2.CodeServer, parsedUrl *url.URL) *traefikv1alpha1.IngressRoute {
+	entryPoints := []string{"web"}
+	if parsedUrl.Scheme == "https" {
+		entryPoints = []string{"websecure", "web"}
+	}
+
+	route := traefikv1alpha1.Route{
+		Match: fmt.Sprintf("Host(`%s`)", parsedUrl.Host),
+		Kind:  "Rule",
+		Services: []traefikv1alpha1.Service{
+			{
+				LoadBalancerSpec: traefikv1alpha1.LoadBalancerSpec{
+					Name: instance.Name,
+					Port: intstr.FromString(codeServerPortName),
+				},
+			},
+		},
+	}
+	if hasPathPrefix(parsedUrl) {
+		route.Match = fmt.Sprintf("Host(`%s`) && PathPrefix(`%s`)", parsedUrl.Host, parsedUrl.Path)
+		route.Middlewares = []traefikv1alpha1.MiddlewareRef{
+			{
+				Name: instance.Name,
+			},
+		}
+	}
+
+	return &traefikv1alpha1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+		},
+		Spec: traefikv1alpha1.IngressRouteSpec{
+			EntryPoints: entryPoints,
+			Routes:      []traefikv1alpha1.Route{route},
+		},
+	}
+}
+
+func middlewareForCodeServer(instance *experimentv1alpha2.CodeServer, parsedUrl *url.URL) *traefikv1alpha1.Middleware {
+	return &traefikv1alpha1.Middleware{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+		},
+		Spec: traefikv1alpha1.MiddlewareSpec{
+			StripPrefix: &dynamic.StripPrefix{
+				Prefixes: []string{parsedUrl.Path},
+			},
+		},
+	}
+}
+
+func (r *CodeServerReconciler) reconcileNginxIngress(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.CodeServer, parsedUrl *url.URL) error {
+	expectIngress := newIngressForCodeServer(instance, parsedUrl)
+	if err := controllerutil.SetControllerReference(instance, expectIngress, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+
+	currentIngress := &networkv1.Ingress{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentIngress); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("create codeserver ingress", "codeserver", instance.Name)
+			if err := r.Create(ctx, expectIngress); err != nil {
+				logger.Error(err, "create codeserver ingress failed")
+				return err
+			}
+			return nil
+		}
+
+		logger.Error(err, "get codeserver ingress failed")
+		return err
+	} else {
+		if !reflect.DeepEqual(expectIngress.Spec, currentIngress.Spec) || !reflect.DeepEqual(expectIngress.Annotations, currentIngress.Annotations) {
+			currentIngress.Spec = expectIngress.Spec
+			currentIngress.Annotations = expectIngress.Annotations
+			logger.V(4).Info("update codeserver ingress", "codeserver", instance.Name)
+			if err := r.Update(ctx, currentIngress); err != nil {
+				logger.Error(err, "update codeserver ingress failed")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newIngressForCodeServer(instance *experimentv1alpha2.CodeServer, parsedUrl *url.URL) *networkv1.Ingress {
+	pathType := networkv1.PathTypeImplementationSpecific
+	path := "/"
+	annotations := map[string]string{}
+	if hasPathPrefix(parsedUrl) {
+		annotations["nginx.ingress.kubernetes.io/rewrite-target"] = "/$2"
+		path = fmt.Sprintf("%s(/|$)(.*)", parsedUrl.Path)
+	}
+
+	return &networkv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        instance.Name,
+			Namespace:   instance.Namespace,
+			Annotations: annotations,
+		},
+		Spec: networkv1.IngressSpec{
+			Rules: []networkv1.IngressRule{
+				{
+					Host: parsedUrl.Host,
+					IngressRuleValue: networkv1.IngressRuleValue{
+						HTTP: &networkv1.HTTPIngressRuleValue{
+							Paths: []networkv1.HTTPIngressPath{
+								{
+									Path:     path,
+									PathType: &pathType,
+									Backend: networkv1.IngressBackend{
+										Service: &networkv1.IngressServiceBackend{
+											Name: instance.Name,
+											Port: networkv1.ServiceBackendPort{
+												Name: codeServerPortName,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *CodeServerReconciler) deletePersistentVolumeClaim(ctx context.Context, instance *experimentv1alpha2.CodeServer) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, pvc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !k8sutil.IsControlledBy(pvc.OwnerReferences, experimentv1alpha2.ResourceKindCodeServer, instance.Name) {
+		return nil
+	}
+
+	if err := r.Delete(ctx, pvc); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *CodeServerReconciler) reconcilePersistentVolume(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.CodeServer) error {
+	if instance.Spec.VolumeSize == "" || instance.Spec.StorageClassName == "" {
+		if err := r.deletePersistentVolumeClaim(ctx, instance); err != nil {
+			logger.Error(err, "delete PersistentVolumeClaim failed")
+			return err
+		}
+		return nil
+	}
+
+	expectPVC, err := newPersistentVolumeClaim(instance)
+	if err != nil {
+		logger.Error(err, "new persistent volume claim failed")
+		return err
+	}
+
+	if err := controllerutil.SetControllerReference(instance, expectPVC, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+	currentPVC := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentPVC); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("create codeserver pvc", "codeserver", instance.Name)
+			if err := r.Create(ctx, expectPVC); err != nil {
+				logger.Error(err, "create codeserver pvc failed")
+				return err
+			}
+			return nil
+		}
+		logger.Error(err, "get codeserver pvc failed")
+		return err
+	} else {
+		if currentPVC.Spec.StorageClassName == nil || *expectPVC.Spec.StorageClassName != *currentPVC.Spec.StorageClassName ||
+			!reflect.DeepEqual(expectPVC.Spec.Resources.Requests, currentPVC.Spec.Resources.Requests) {
+			err := errors.NewBadRequest("update codeserver pvc is not allowed")
+			logger.Error(err, "update codeserver pvc is not supported")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newPersistentVolumeClaim(instance *experimentv1alpha2.CodeServer) (*corev1.PersistentVolumeClaim, error) {
+	storageClassName := instance.Spec.StorageClassName
+	pvcQuantity, err := resourcev1.ParseQuantity(instance.Spec.VolumeSize)
+	if err != nil {
+		return nil, err
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+			Labels:    labelsForCodeServer(instance.Name),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: &storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: pvcQuantity,
+				},
+			},
+		},
+	}
+	return pvc, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *CodeServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+
+	r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	if r.MaxConcurrentReconciles <= 0 {
+		r.MaxConcurrentReconciles = 1
+	}
 	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		}).
 		For(&experimentv1alpha2.CodeServer{}).
 		Complete(r)
 }
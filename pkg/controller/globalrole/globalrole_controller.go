@@ -19,20 +19,29 @@ package globalrole
 import (
 	aiscope "aiscope/pkg/client/clientset/versioned"
 	"context"
+	"encoding/json"
 	"fmt"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	rbacv1informers "k8s.io/client-go/informers/rbac/v1"
+	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"time"
 
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
 	iamv1alpha2informers "aiscope/pkg/client/informers/externalversions/iam/v1alpha2"
 	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
 )
@@ -51,6 +60,8 @@ type Controller struct {
 	globalRoleInformer           iamv1alpha2informers.GlobalRoleInformer
 	globalRoleLister             iamv1alpha2listers.GlobalRoleLister
 	globalRoleSynced             cache.InformerSynced
+	clusterRoleLister            rbacv1listers.ClusterRoleLister
+	clusterRoleSynced            cache.InformerSynced
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
@@ -62,7 +73,8 @@ type Controller struct {
 	recorder record.EventRecorder
 }
 
-func NewController(k8sClient kubernetes.Interface, ksClient aiscope.Interface, globalRoleInformer iamv1alpha2informers.GlobalRoleInformer) *Controller {
+func NewController(k8sClient kubernetes.Interface, ksClient aiscope.Interface, globalRoleInformer iamv1alpha2informers.GlobalRoleInformer,
+	clusterRoleInformer rbacv1informers.ClusterRoleInformer) *Controller {
 	// Create event broadcaster
 	// Add sample-controller types to the default Kubernetes Scheme so Events can be
 	// logged for sample-controller types.
@@ -78,6 +90,8 @@ func NewController(k8sClient kubernetes.Interface, ksClient aiscope.Interface, g
 		globalRoleInformer:           globalRoleInformer,
 		globalRoleLister:             globalRoleInformer.Lister(),
 		globalRoleSynced:             globalRoleInformer.Informer().HasSynced,
+		clusterRoleLister:            clusterRoleInformer.Lister(),
+		clusterRoleSynced:            clusterRoleInformer.Informer().HasSynced,
 		workqueue:                    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "GlobalRole"),
 		recorder:                     recorder,
 	}
@@ -89,6 +103,13 @@ func NewController(k8sClient kubernetes.Interface, ksClient aiscope.Interface, g
 		},
 		DeleteFunc: ctl.enqueueGlobalRole,
 	})
+	clusterRoleInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: ctl.enqueueOwnerGlobalRole,
+		UpdateFunc: func(old, new interface{}) {
+			ctl.enqueueOwnerGlobalRole(new)
+		},
+		DeleteFunc: ctl.enqueueOwnerGlobalRole,
+	})
 	return ctl
 }
 
@@ -102,7 +123,7 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	// Wait for the caches to be synced before starting workers
 	klog.Info("Waiting for informer caches to sync")
 
-	if ok := cache.WaitForCacheSync(stopCh, c.globalRoleSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.globalRoleSynced, c.clusterRoleSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
@@ -126,6 +147,40 @@ func (c *Controller) enqueueGlobalRole(obj interface{}) {
 		return
 	}
 	c.workqueue.Add(key)
+
+	// role templates changed, the roles aggregating them need to be computed again
+	if globalRole, ok := obj.(*iamv1alpha2.GlobalRole); ok && globalRole.Labels[iamv1alpha2.RoleTemplateLabel] == "true" {
+		globalRoles, err := c.globalRoleLister.List(labels.Everything())
+		if err != nil {
+			utilruntime.HandleError(err)
+			return
+		}
+		for _, item := range globalRoles {
+			for _, role := range aggregationRoles(item) {
+				if role == globalRole.Name {
+					c.workqueue.Add(item.Name)
+					break
+				}
+			}
+		}
+	}
+}
+
+// enqueueOwnerGlobalRole enqueues the GlobalRole a ClusterRole is materialised from,
+// so that the ClusterRole is restored once modified or deleted.
+func (c *Controller) enqueueOwnerGlobalRole(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	clusterRole, ok := obj.(*rbacv1.ClusterRole)
+	if !ok {
+		return
+	}
+	owner := metav1.GetControllerOf(clusterRole)
+	if owner == nil || owner.Kind != iamv1alpha2.ResourceKindGlobalRole {
+		return
+	}
+	c.workqueue.Add(owner.Name)
 }
 
 func (c *Controller) runWorker() {
@@ -203,6 +258,16 @@ func (c *Controller) reconcile(key string) error {
 		return err
 	}
 
+	if globalRole, err = c.aggregateRoleTemplates(globalRole); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	if err = c.syncClusterRole(globalRole); err != nil {
+		klog.Error(err)
+		return err
+	}
+
 	c.recorder.Event(globalRole, corev1.EventTypeNormal, successSynced, messageResourceSynced)
 	return nil
 }
@@ -210,3 +275,139 @@ func (c *Controller) reconcile(key string) error {
 func (c *Controller) Start(ctx context.Context) error {
 	return c.Run(4, ctx.Done())
 }
+
+// aggregateRoleTemplates computes the rules of the GlobalRole from the rules declared on the GlobalRole itself
+// and the rules of the role templates listed in the aggregation annotation. The rules added by the aggregation
+// are recorded in the aggregated rules annotation, so that they are revoked once removed from the templates.
+func (c *Controller) aggregateRoleTemplates(globalRole *iamv1alpha2.GlobalRole) (*iamv1alpha2.GlobalRole, error) {
+	templates := aggregationRoles(globalRole)
+	aggregated := aggregatedRules(globalRole)
+	if len(templates) == 0 && len(aggregated) == 0 {
+		return globalRole, nil
+	}
+
+	// the rules aggregated last time are not declared on the GlobalRole
+	var rules []rbacv1.PolicyRule
+	for _, rule := range globalRole.Rules {
+		if !ruleExists(aggregated, rule) {
+			rules = append(rules, rule)
+		}
+	}
+	aggregated = nil
+	for _, name := range templates {
+		template, err := c.globalRoleLister.Get(name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				klog.V(4).Infof("role template %s of globalrole %s not found", name, globalRole.Name)
+				continue
+			}
+			return nil, err
+		}
+		for _, rule := range template.Rules {
+			if !ruleExists(rules, rule) {
+				rules = append(rules, rule)
+				aggregated = append(aggregated, rule)
+			}
+		}
+	}
+
+	annotation := ""
+	if len(aggregated) > 0 {
+		data, err := json.Marshal(aggregated)
+		if err != nil {
+			return nil, err
+		}
+		annotation = string(data)
+	}
+
+	if reflect.DeepEqual(rules, globalRole.Rules) && annotation == globalRole.Annotations[iamv1alpha2.AggregatedRulesAnnotation] {
+		return globalRole, nil
+	}
+
+	globalRole = globalRole.DeepCopy()
+	globalRole.Rules = rules
+	if annotation == "" {
+		delete(globalRole.Annotations, iamv1alpha2.AggregatedRulesAnnotation)
+	} else {
+		if globalRole.Annotations == nil {
+			globalRole.Annotations = make(map[string]string)
+		}
+		globalRole.Annotations[iamv1alpha2.AggregatedRulesAnnotation] = annotation
+	}
+	return c.ksClient.IamV1alpha2().GlobalRoles().Update(context.Background(), globalRole, metav1.UpdateOptions{})
+}
+
+// syncClusterRole materialises the GlobalRole as a ClusterRole of the same name owned by the GlobalRole,
+// ClusterRoles of that name not created by this controller are left untouched.
+func (c *Controller) syncClusterRole(globalRole *iamv1alpha2.GlobalRole) error {
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: globalRole.Name,
+		},
+		Rules: globalRole.Rules,
+	}
+	if err := controllerutil.SetControllerReference(globalRole, clusterRole, scheme.Scheme); err != nil {
+		return err
+	}
+
+	existing, err := c.clusterRoleLister.Get(globalRole.Name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = c.k8sClient.RbacV1().ClusterRoles().Create(context.Background(), clusterRole, metav1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
+	}
+
+	if !metav1.IsControlledBy(existing, globalRole) {
+		klog.Warningf("clusterrole %s is not controlled by globalrole %s, skip", existing.Name, globalRole.Name)
+		return nil
+	}
+
+	if reflect.DeepEqual(existing.Rules, clusterRole.Rules) {
+		return nil
+	}
+
+	existing = existing.DeepCopy()
+	existing.Rules = clusterRole.Rules
+	_, err = c.k8sClient.RbacV1().ClusterRoles().Update(context.Background(), existing, metav1.UpdateOptions{})
+	return err
+}
+
+func aggregationRoles(globalRole *iamv1alpha2.GlobalRole) []string {
+	annotation := globalRole.Annotations[iamv1alpha2.AggregationRolesAnnotation]
+	if annotation == "" {
+		return nil
+	}
+	var roles []string
+	if err := json.Unmarshal([]byte(annotation), &roles); err != nil {
+		klog.Warningf("invalid aggregation roles annotation of globalrole %s: %v", globalRole.Name, err)
+		return nil
+	}
+	return roles
+}
+
+func aggregatedRules(globalRole *iamv1alpha2.GlobalRole) []rbacv1.PolicyRule {
+	annotation := globalRole.Annotations[iamv1alpha2.AggregatedRulesAnnotation]
+	if annotation == "" {
+		return nil
+	}
+	var rules []rbacv1.PolicyRule
+	if err := json.Unmarshal([]byte(annotation), &rules); err != nil {
+		klog.Warningf("invalid aggregated rules annotation of globalrole %s: %v", globalRole.Name, err)
+		return nil
+	}
+	return rules
+}
+
+func ruleExists(rules []rbacv1.PolicyRule, rule rbacv1.PolicyRule) bool {
+	for _, item := range rules {
+		if reflect.DeepEqual(item, rule) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package globalrole
+
+import (
+	"context"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	fakeaiscope "aiscope/pkg/client/clientset/versioned/fake"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+)
+
+func TestAggregateRoleTemplatesRevokesRemovedRules(t *testing.T) {
+	declared := rbacv1.PolicyRule{APIGroups: []string{"tenant.aiscope"}, Resources: []string{"workspaces"}, Verbs: []string{"list"}}
+	viewUsers := rbacv1.PolicyRule{APIGroups: []string{"iam.aiscope"}, Resources: []string{"users"}, Verbs: []string{"get", "list"}}
+	manageUsers := rbacv1.PolicyRule{APIGroups: []string{"iam.aiscope"}, Resources: []string{"users"}, Verbs: []string{"*"}}
+
+	template := &iamv1alpha2.GlobalRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "role-template-users", Labels: map[string]string{iamv1alpha2.RoleTemplateLabel: "true"}},
+		Rules:      []rbacv1.PolicyRule{viewUsers, manageUsers},
+	}
+	globalRole := &iamv1alpha2.GlobalRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "users-manager",
+			Annotations: map[string]string{iamv1alpha2.AggregationRolesAnnotation: `["role-template-users"]`},
+		},
+		Rules: []rbacv1.PolicyRule{declared},
+	}
+	client := fakeaiscope.NewSimpleClientset()
+	for _, role := range []*iamv1alpha2.GlobalRole{template, globalRole} {
+		if _, err := client.IamV1alpha2().GlobalRoles().Create(context.Background(), role, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(template); err != nil {
+		t.Fatal(err)
+	}
+	c := &Controller{ksClient: client, globalRoleLister: iamv1alpha2listers.NewGlobalRoleLister(indexer)}
+
+	aggregated, err := c.aggregateRoleTemplates(globalRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aggregated.Rules) != 3 {
+		t.Fatalf("the rules of the template should be aggregated: %+v", aggregated.Rules)
+	}
+
+	// the template no longer grants to manage the users
+	template = template.DeepCopy()
+	template.Rules = []rbacv1.PolicyRule{viewUsers}
+	if err = indexer.Update(template); err != nil {
+		t.Fatal(err)
+	}
+	aggregated, err = c.aggregateRoleTemplates(aggregated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aggregated.Rules) != 2 || !ruleExists(aggregated.Rules, declared) || !ruleExists(aggregated.Rules, viewUsers) {
+		t.Errorf("the removed rule should be revoked and the declared rule kept: %+v", aggregated.Rules)
+	}
+
+	// the role no longer aggregates any template
+	aggregated = aggregated.DeepCopy()
+	delete(aggregated.Annotations, iamv1alpha2.AggregationRolesAnnotation)
+	aggregated, err = c.aggregateRoleTemplates(aggregated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aggregated.Rules) != 1 || !ruleExists(aggregated.Rules, declared) {
+		t.Errorf("only the declared rule should be kept: %+v", aggregated.Rules)
+	}
+	if _, ok := aggregated.Annotations[iamv1alpha2.AggregatedRulesAnnotation]; ok {
+		t.Errorf("the aggregated rules annotation should be removed")
+	}
+}
@@ -29,12 +29,15 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	rbacv1informers "k8s.io/client-go/informers/rbac/v1"
+	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"time"
 
@@ -54,6 +57,8 @@ type Controller struct {
 	ksClient                            aiscope.Interface
 	globalRoleBindingLister             iamv1alpha2listers.GlobalRoleBindingLister
 	globalRoleBindingSynced             cache.InformerSynced
+	clusterRoleBindingLister            rbacv1listers.ClusterRoleBindingLister
+	clusterRoleBindingSynced            cache.InformerSynced
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
@@ -66,7 +71,8 @@ type Controller struct {
 }
 
 func NewController(k8sClient kubernetes.Interface, ksClient aiscope.Interface,
-	globalRoleBindingInformer iamv1alpha2informers.GlobalRoleBindingInformer) *Controller {
+	globalRoleBindingInformer iamv1alpha2informers.GlobalRoleBindingInformer,
+	clusterRoleBindingInformer rbacv1informers.ClusterRoleBindingInformer) *Controller {
 	// Create event broadcaster
 	// Add sample-controller types to the default Kubernetes Scheme so Events can be
 	// logged for sample-controller types.
@@ -81,6 +87,8 @@ func NewController(k8sClient kubernetes.Interface, ksClient aiscope.Interface,
 		ksClient:                            ksClient,
 		globalRoleBindingLister:             globalRoleBindingInformer.Lister(),
 		globalRoleBindingSynced:             globalRoleBindingInformer.Informer().HasSynced,
+		clusterRoleBindingLister:            clusterRoleBindingInformer.Lister(),
+		clusterRoleBindingSynced:            clusterRoleBindingInformer.Informer().HasSynced,
 		workqueue:                           workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "GlobalRoleBinding"),
 		recorder:                            recorder,
 	}
@@ -92,6 +100,13 @@ func NewController(k8sClient kubernetes.Interface, ksClient aiscope.Interface,
 		},
 		DeleteFunc: ctl.enqueueGlobalRoleBinding,
 	})
+	clusterRoleBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: ctl.enqueueOwnerGlobalRoleBinding,
+		UpdateFunc: func(old, new interface{}) {
+			ctl.enqueueOwnerGlobalRoleBinding(new)
+		},
+		DeleteFunc: ctl.enqueueOwnerGlobalRoleBinding,
+	})
 	return ctl
 }
 
@@ -106,7 +121,7 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	klog.Info("Waiting for informer caches to sync")
 
 	synced := make([]cache.InformerSynced, 0)
-	synced = append(synced, c.globalRoleBindingSynced)
+	synced = append(synced, c.globalRoleBindingSynced, c.clusterRoleBindingSynced)
 
 	if ok := cache.WaitForCacheSync(stopCh, synced...); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
@@ -134,6 +149,23 @@ func (c *Controller) enqueueGlobalRoleBinding(obj interface{}) {
 	c.workqueue.Add(key)
 }
 
+// enqueueOwnerGlobalRoleBinding enqueues the GlobalRoleBinding a ClusterRoleBinding is created from,
+// so that the ClusterRoleBinding is restored once modified or deleted.
+func (c *Controller) enqueueOwnerGlobalRoleBinding(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	clusterRoleBinding, ok := obj.(*rbacv1.ClusterRoleBinding)
+	if !ok {
+		return
+	}
+	owner := metav1.GetControllerOf(clusterRoleBinding)
+	if owner == nil || owner.Kind != iamv1alpha2.ResourceKindGlobalRoleBinding {
+		return
+	}
+	c.workqueue.Add(owner.Name)
+}
+
 func (c *Controller) runWorker() {
 	for c.processNextWorkItem() {
 	}
@@ -209,11 +241,9 @@ func (c *Controller) reconcile(key string) error {
 		return err
 	}
 
-	if globalRoleBinding.RoleRef.Name == iamv1alpha2.PlatformAdmin {
-		if err := c.assignClusterAdminRole(globalRoleBinding); err != nil {
-			klog.Error(err)
-			return err
-		}
+	if err := c.syncClusterRoleBinding(globalRoleBinding); err != nil {
+		klog.Error(err)
+		return err
 	}
 
 	c.recorder.Event(globalRoleBinding, corev1.EventTypeNormal, successSynced, messageResourceSynced)
@@ -224,23 +254,24 @@ func (c *Controller) Start(ctx context.Context) error {
 	return c.Run(4, ctx.Done())
 }
 
-func (c *Controller) assignClusterAdminRole(globalRoleBinding *iamv1alpha2.GlobalRoleBinding) error {
+// syncClusterRoleBinding binds the subjects of the GlobalRoleBinding to the ClusterRole materialised
+// from the referred GlobalRole, ClusterRoleBindings of that name not created by this controller are left untouched.
+func (c *Controller) syncClusterRoleBinding(globalRoleBinding *iamv1alpha2.GlobalRoleBinding) error {
 
-	username := findExpectUsername(globalRoleBinding)
-	if username == "" {
+	subjects := ensureSubjectAPIVersionIsValid(globalRoleBinding.Subjects)
+	if len(subjects) == 0 {
 		return nil
 	}
 
 	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
-		TypeMeta: metav1.TypeMeta{},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: fmt.Sprintf("%s-%s", username, iamv1alpha2.ClusterAdmin),
+			Name: globalRoleBinding.Name,
 		},
-		Subjects: ensureSubjectAPIVersionIsValid(globalRoleBinding.Subjects),
+		Subjects: subjects,
 		RoleRef: rbacv1.RoleRef{
-			APIGroup: "rbac.authorization.k8s.io",
+			APIGroup: rbacv1.GroupName,
 			Kind:     iamv1alpha2.ResourceKindClusterRole,
-			Name:     iamv1alpha2.ClusterAdmin,
+			Name:     globalRoleBinding.RoleRef.Name,
 		},
 	}
 
@@ -249,36 +280,55 @@ func (c *Controller) assignClusterAdminRole(globalRoleBinding *iamv1alpha2.Globa
 		return err
 	}
 
-	_, err = c.k8sClient.RbacV1().ClusterRoleBindings().Create(context.Background(), clusterRoleBinding, metav1.CreateOptions{})
+	existing, err := c.clusterRoleBindingLister.Get(clusterRoleBinding.Name)
 	if err != nil {
-		if errors.IsAlreadyExists(err) {
-			return nil
+		if !errors.IsNotFound(err) {
+			return err
 		}
-		return err
+		_, err = c.k8sClient.RbacV1().ClusterRoleBindings().Create(context.Background(), clusterRoleBinding, metav1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
 	}
 
-	return nil
-}
+	if !metav1.IsControlledBy(existing, globalRoleBinding) {
+		klog.Warningf("clusterrolebinding %s is not controlled by globalrolebinding %s, skip", existing.Name, globalRoleBinding.Name)
+		return nil
+	}
 
-func findExpectUsername(globalRoleBinding *iamv1alpha2.GlobalRoleBinding) string {
-	for _, subject := range globalRoleBinding.Subjects {
-		if subject.Kind == iamv1alpha2.ResourceKindUser {
-			return subject.Name
+	// role ref is immutable, recreate the cluster role binding if it changed
+	if !reflect.DeepEqual(existing.RoleRef, clusterRoleBinding.RoleRef) {
+		err = c.k8sClient.RbacV1().ClusterRoleBindings().Delete(context.Background(), existing.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
 		}
+		_, err = c.k8sClient.RbacV1().ClusterRoleBindings().Create(context.Background(), clusterRoleBinding, metav1.CreateOptions{})
+		return err
 	}
-	return ""
+
+	if reflect.DeepEqual(existing.Subjects, clusterRoleBinding.Subjects) {
+		return nil
+	}
+
+	existing = existing.DeepCopy()
+	existing.Subjects = clusterRoleBinding.Subjects
+	_, err = c.k8sClient.RbacV1().ClusterRoleBindings().Update(context.Background(), existing, metav1.UpdateOptions{})
+	return err
 }
 
 func ensureSubjectAPIVersionIsValid(subjects []rbacv1.Subject) []rbacv1.Subject {
 	validSubjects := make([]rbacv1.Subject, 0)
 	for _, subject := range subjects {
-		if subject.Kind == iamv1alpha2.ResourceKindUser {
-			validSubject := rbacv1.Subject{
-				Kind:     iamv1alpha2.ResourceKindUser,
-				APIGroup: "rbac.authorization.k8s.io",
+		switch subject.Kind {
+		case iamv1alpha2.ResourceKindUser, rbacv1.GroupKind:
+			validSubjects = append(validSubjects, rbacv1.Subject{
+				Kind:     subject.Kind,
+				APIGroup: rbacv1.GroupName,
 				Name:     subject.Name,
-			}
-			validSubjects = append(validSubjects, validSubject)
+			})
+		case rbacv1.ServiceAccountKind:
+			validSubjects = append(validSubjects, subject)
 		}
 	}
 	return validSubjects
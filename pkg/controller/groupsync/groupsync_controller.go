@@ -0,0 +1,63 @@
+package groupsync
+
+import (
+	"aiscope/pkg/apiserver/authentication/identityprovider"
+	"aiscope/pkg/apiserver/authentication/oauth"
+	aiscope "aiscope/pkg/client/clientset/versioned"
+	"aiscope/pkg/models/iam/group"
+	"context"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"time"
+)
+
+const (
+	controllerName = "groupsync-controller"
+)
+
+// Controller periodically mirrors the groups of the identity providers into Group and GroupBinding objects,
+// only the providers implementing identityprovider.GroupSyncProvider are synced.
+type Controller struct {
+	syncer            group.Syncer
+	identityProviders []oauth.IdentityProviderOptions
+	syncPeriod        time.Duration
+}
+
+// NewController creates GroupSync Controller instance
+func NewController(aiClient aiscope.Interface, identityProviders []oauth.IdentityProviderOptions, syncPeriod time.Duration) *Controller {
+	return &Controller{
+		syncer:            group.NewSyncer(aiClient),
+		identityProviders: identityProviders,
+		syncPeriod:        syncPeriod,
+	}
+}
+
+func (c *Controller) Start(ctx context.Context) error {
+	klog.Infof("Starting %s", controllerName)
+	defer klog.Infof("Shutting down %s", controllerName)
+	wait.UntilWithContext(ctx, c.sync, c.syncPeriod)
+	return nil
+}
+
+func (c *Controller) sync(_ context.Context) {
+	for _, providerOptions := range c.identityProviders {
+		provider, err := identityprovider.GetGenericProvider(providerOptions.Name)
+		if err != nil {
+			continue
+		}
+		groupSyncProvider, ok := provider.(identityprovider.GroupSyncProvider)
+		if !ok {
+			continue
+		}
+		groups, err := groupSyncProvider.ListGroups()
+		if err != nil {
+			klog.Errorf("failed to list groups of identity provider %s: %v", providerOptions.Name, err)
+			continue
+		}
+		if err = c.syncer.SyncGroups(&providerOptions, groups); err != nil {
+			klog.Errorf("failed to sync groups of identity provider %s: %v", providerOptions.Name, err)
+			continue
+		}
+		klog.V(4).Infof("synced %d groups of identity provider %s", len(groups), providerOptions.Name)
+	}
+}
@@ -17,46 +17,653 @@ limitations under the License.
 package jupyternotebook
 
 import (
+	"aiscope/pkg/utils/k8sutil"
+	"aiscope/pkg/utils/sliceutil"
 	"context"
-
-	"k8s.io/apimachinery/pkg/runtime"
+	"fmt"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"net/url"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	experimentv1alpha2 "aiscope/pkg/apis/experiment/v1alpha2"
+	traefikclient "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/clientset/versioned"
+	traefikv1alpha1 "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	networkv1 "k8s.io/api/networking/v1"
+	resourcev1 "k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	successSynced = "Synced"
+	failedSynced  = "FailedSync"
+	// is synced successfully
+	messageResourceSynced = "JupyterNotebook synced successfully"
+	controllerName        = "jupyternotebook-controller"
+	finalizer             = "finalizers.aiscope.io/jupyternotebook"
+	syncFailMessage       = "Failed to sync: %s"
+
+	notebookPort       = 8888
+	notebookPortName   = "http"
+	notebookVolumeName = "notebook-data"
+	notebookMountPath  = "/home/jovyan"
+	// jovyan user group of the jupyter docker stacks images
+	notebookFSGroup = int64(100)
 )
 
 // JupyterNotebookReconciler reconciles a JupyterNotebook object
 type JupyterNotebookReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	TraefikClient           traefikclient.Interface
+	Logger                  logr.Logger
+	Recorder                record.EventRecorder
+	IngressController       string
+	MaxConcurrentReconciles int
 }
 
 //+kubebuilder:rbac:groups=experiment.aiscope,resources=jupyternotebooks,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=experiment.aiscope,resources=jupyternotebooks/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=experiment.aiscope,resources=jupyternotebooks/finalizers,verbs=update
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the JupyterNotebook object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.10.0/pkg/reconcile
+// Reconcile compares the state specified by the JupyterNotebook object against
+// the actual cluster state, and creates or updates the PersistentVolumeClaim,
+// StatefulSet, Service and ingress of the notebook server.
 func (r *JupyterNotebookReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := r.Logger.WithValues("jupyterNotebook", req.NamespacedName)
+	rootCtx := context.Background()
+
+	notebook := &experimentv1alpha2.JupyterNotebook{}
+	if err := r.Get(rootCtx, req.NamespacedName, notebook); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if notebook.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !sliceutil.HasString(notebook.ObjectMeta.Finalizers, finalizer) {
+			notebook.ObjectMeta.Finalizers = append(notebook.ObjectMeta.Finalizers, finalizer)
+			if err := r.Update(rootCtx, notebook); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if sliceutil.HasString(notebook.ObjectMeta.Finalizers, finalizer) {
+			notebook.ObjectMeta.Finalizers = sliceutil.RemoveString(notebook.ObjectMeta.Finalizers, func(item string) bool {
+				return item == finalizer
+			})
+			logger.V(4).Info("update jupyterNotebook")
+			if err := r.Update(rootCtx, notebook); err != nil {
+				logger.Error(err, "update jupyterNotebook failed")
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.reconcilePersistentVolume(rootCtx, logger, notebook); err != nil {
+		r.Recorder.Event(notebook, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reconcileStatefulSet(rootCtx, logger, notebook); err != nil {
+		r.Recorder.Event(notebook, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reconcileService(rootCtx, logger, notebook); err != nil {
+		r.Recorder.Event(notebook, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+		return reconcile.Result{}, err
+	}
 
-	// TODO(user): your logic here
+	if err := r.reconcileIngress(rootCtx, logger, notebook); err != nil {
+		r.Recorder.Event(notebook, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+		return reconcile.Result{}, err
+	}
 
+	r.Recorder.Event(notebook, corev1.EventTypeNormal, successSynced, messageResourceSynced)
 	return ctrl.Result{}, nil
 }
 
+func (r *JupyterNotebookReconciler) reconcileStatefulSet(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.JupyterNotebook) error {
+	expectStatefulSet, err := newStatefulSetForJupyterNotebook(instance)
+	if err != nil {
+		logger.Error(err, "new statefulset failed")
+		return err
+	}
+
+	if err := controllerutil.SetControllerReference(instance, expectStatefulSet, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+
+	currentStatefulSet := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentStatefulSet); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("create jupyternotebook statefulset", "jupyternotebook", instance.Name)
+			if err := r.Create(ctx, expectStatefulSet); err != nil {
+				logger.Error(err, "create jupyternotebook statefulset failed")
+				return err
+			}
+			return nil
+		}
+
+		logger.Error(err, "get jupyternotebook statefulset failed")
+		return err
+	} else {
+		if updateStatefulSetSpec(currentStatefulSet, expectStatefulSet) {
+			logger.V(4).Info("update jupyternotebook statefulset", "jupyternotebook", instance.Name)
+			if err := r.Update(ctx, currentStatefulSet); err != nil {
+				logger.Error(err, "update jupyternotebook statefulset failed")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// updateStatefulSetSpec applies the fields set by the controller to the current StatefulSet,
+// the fields defaulted by the apiserver are kept. Returns false if none of the fields is changed.
+func updateStatefulSetSpec(current *appsv1.StatefulSet, expect *appsv1.StatefulSet) bool {
+	spec := current.Spec.DeepCopy()
+	spec.Replicas = expect.Spec.Replicas
+	spec.Template.Labels = expect.Spec.Template.Labels
+	if spec.Template.Spec.SecurityContext == nil {
+		spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	spec.Template.Spec.SecurityContext.FSGroup = expect.Spec.Template.Spec.SecurityContext.FSGroup
+	spec.Template.Spec.Volumes = expect.Spec.Template.Spec.Volumes
+
+	if len(spec.Template.Spec.Containers) != len(expect.Spec.Template.Spec.Containers) {
+		spec.Template.Spec.Containers = expect.Spec.Template.Spec.Containers
+	} else {
+		for i := range spec.Template.Spec.Containers {
+			container, expectContainer := &spec.Template.Spec.Containers[i], expect.Spec.Template.Spec.Containers[i]
+			container.Name = expectContainer.Name
+			container.Image = expectContainer.Image
+			container.ImagePullPolicy = expectContainer.ImagePullPolicy
+			container.Ports = expectContainer.Ports
+			container.Env = expectContainer.Env
+			container.Resources = expectContainer.Resources
+			container.VolumeMounts = expectContainer.VolumeMounts
+		}
+	}
+
+	if equality.Semantic.DeepEqual(spec, &current.Spec) {
+		return false
+	}
+	current.Spec = *spec
+	return true
+}
+
+func newStatefulSetForJupyterNotebook(instance *experimentv1alpha2.JupyterNotebook) (*appsv1.StatefulSet, error) {
+	replicas := int32(1)
+	fsGroup := notebookFSGroup
+	labels := labelsForJupyterNotebook(instance.Name)
+
+	env := make([]corev1.EnvVar, 0, len(instance.Spec.Env)+2)
+	if instance.Spec.URL != "" {
+		parsedUrl, err := url.Parse(instance.Spec.URL)
+		if err != nil {
+			return nil, err
+		}
+		// the notebook serves under the path of the url, so the ingress path is passed through as is,
+		// kubeflow images read the base url from NB_PREFIX, the jupyter docker stacks images from NOTEBOOK_ARGS
+		if parsedUrl.Path != "" && parsedUrl.Path != "/" {
+			env = append(env, corev1.EnvVar{
+				Name:  "NB_PREFIX",
+				Value: parsedUrl.Path,
+			})
+			if !hasEnv(instance.Spec.Env, "NOTEBOOK_ARGS") {
+				env = append(env, corev1.EnvVar{
+					Name:  "NOTEBOOK_ARGS",
+					Value: "--ServerApp.base_url=" + parsedUrl.Path,
+				})
+			}
+		}
+	}
+	env = append(env, instance.Spec.Env...)
+
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: instance.Name,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						FSGroup: &fsGroup,
+					},
+					Containers: []corev1.Container{
+						{
+							Image:           instance.Spec.Image,
+							Name:            "notebook",
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Ports: []corev1.ContainerPort{{
+								ContainerPort: notebookPort,
+								Name:          notebookPortName,
+								Protocol:      corev1.ProtocolTCP,
+							}},
+							Env:       env,
+							Resources: instance.Spec.Resources,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if instance.Spec.VolumeSize != "" && instance.Spec.StorageClassName != "" {
+		statefulSet.Spec.Template.Spec.Volumes = []corev1.Volume{
+			{
+				Name: notebookVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: instance.Name,
+					},
+				},
+			},
+		}
+
+		statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
+			{
+				Name:      notebookVolumeName,
+				MountPath: notebookMountPath,
+			},
+		}
+	}
+
+	return statefulSet, nil
+}
+
+func hasEnv(env []corev1.EnvVar, name string) bool {
+	for _, item := range env {
+		if item.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *JupyterNotebookReconciler) reconcileService(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.JupyterNotebook) error {
+	expectService := newServiceForJupyterNotebook(instance)
+	if err := controllerutil.SetControllerReference(instance, expectService, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+
+	currentService := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentService); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("create jupyternotebook service", "jupyternotebook", instance.Name)
+			if err := r.Create(ctx, expectService); err != nil {
+				logger.Error(err, "create jupyternotebook service failed")
+				return err
+			}
+			return nil
+		}
+
+		logger.Error(err, "get jupyternotebook service failed")
+		return err
+	} else {
+		// ClusterIP is allocated by the apiserver and can not be changed
+		expectService.Spec.ClusterIP = currentService.Spec.ClusterIP
+		expectService.Spec.ClusterIPs = currentService.Spec.ClusterIPs
+		if !reflect.DeepEqual(expectService.Spec.Ports, currentService.Spec.Ports) ||
+			!reflect.DeepEqual(expectService.Spec.Selector, currentService.Spec.Selector) {
+			currentService.Spec = expectService.Spec
+			logger.V(4).Info("update jupyternotebook service", "jupyternotebook", instance.Name)
+			if err := r.Update(ctx, currentService); err != nil {
+				logger.Error(err, "update jupyternotebook service failed")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newServiceForJupyterNotebook(instance *experimentv1alpha2.JupyterNotebook) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+			Labels:    labelsForJupyterNotebook(instance.Name),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       notebookPortName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       notebookPort,
+					TargetPort: intstr.FromInt(notebookPort),
+				},
+			},
+			Selector: labelsForJupyterNotebook(instance.Name),
+		},
+	}
+
+	return service
+}
+
+func labelsForJupyterNotebook(name string) map[string]string {
+	return map[string]string{"app": "jupyternotebook", experimentv1alpha2.JupyterNotebookLabel: name}
+}
+
+func (r *JupyterNotebookReconciler) reconcileIngress(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.JupyterNotebook) error {
+	if instance.Spec.URL == "" {
+		return nil
+	}
+
+	parsedUrl, err := url.Parse(instance.Spec.URL)
+	if err != nil {
+		logger.Error(err, "parse url failed")
+		return err
+	}
+
+	if r.IngressController == "traefik" {
+		if err := r.reconcileTraefikRoute(ctx, logger, instance, parsedUrl); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if r.IngressController == "nginx" {
+		if err := r.reconcileNginxIngress(ctx, logger, instance, parsedUrl); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func (r *JupyterNotebookReconciler) reconcileTraefikRoute(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.JupyterNotebook, parsedUrl *url.URL) error {
+	expectIngressRoute := ingressRouteForJupyterNotebook(instance, parsedUrl)
+	if err := controllerutil.SetControllerReference(instance, expectIngressRoute, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+
+	currentIngressRoute, err := r.TraefikClient.TraefikV1alpha1().IngressRoutes(instance.Namespace).Get(ctx, instance.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			_, err = r.TraefikClient.TraefikV1alpha1().IngressRoutes(instance.Namespace).Create(ctx, expectIngressRoute, metav1.CreateOptions{})
+			if err != nil {
+				logger.Error(err, "create IngressRoute failed")
+				return err
+			}
+			return nil
+		}
+		logger.Error(err, "get IngressRoute failed")
+		return err
+	}
+
+	if !reflect.DeepEqual(expectIngressRoute.Spec, currentIngressRoute.Spec) {
+		currentIngressRoute.Spec = expectIngressRoute.Spec
+		_, err = r.TraefikClient.TraefikV1alpha1().IngressRoutes(instance.Namespace).Update(ctx, currentIngressRoute, metav1.UpdateOptions{})
+		if err != nil {
+			logger.Error(err, "update IngressRoute failed")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ingressRouteForJupyterNotebook(instance *experimentv1alpha2.JupyterNotebook, parsedUrl *url.URL) *traefikv1alpha1.IngressRoute {
+	match := fmt.Sprintf("Host(`%s`)", parsedUrl.Host)
+	if parsedUrl.Path != "" && parsedUrl.Path != "/" {
+		match = fmt.Sprintf("Host(`%s`) && PathPrefix(`%s`)", parsedUrl.Host, parsedUrl.Path)
+	}
+
+	ingressRoute := &traefikv1alpha1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+		},
+		Spec: traefikv1alpha1.IngressRouteSpec{
+			EntryPoints: []string{"web"},
+			Routes: []traefikv1alpha1.Route{
+				{
+					Match: match,
+					Kind:  "Rule",
+					Services: []traefikv1alpha1.Service{
+						{
+							LoadBalancerSpec: traefikv1alpha1.LoadBalancerSpec{
+								Name: instance.Name,
+								Port: intstr.FromString(notebookPortName),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// https is served with the certificate of TLSSecretName, or the default certificate of traefik
+	if parsedUrl.Scheme == "https" {
+		ingressRoute.Spec.EntryPoints = []string{"websecure", "web"}
+		ingressRoute.Spec.TLS = &traefikv1alpha1.TLS{
+			SecretName: instance.Spec.TLSSecretName,
+		}
+	}
+
+	return ingressRoute
+}
+
+func (r *JupyterNotebookReconciler) reconcileNginxIngress(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.JupyterNotebook, parsedUrl *url.URL) error {
+	expectIngress := newIngressForJupyterNotebook(instance, parsedUrl)
+	if err := controllerutil.SetControllerReference(instance, expectIngress, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+
+	currentIngress := &networkv1.Ingress{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentIngress); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("create jupyternotebook ingress", "jupyternotebook", instance.Name)
+			if err := r.Create(ctx, expectIngress); err != nil {
+				logger.Error(err, "create jupyternotebook ingress failed")
+				return err
+			}
+			return nil
+		}
+
+		logger.Error(err, "get jupyternotebook ingress failed")
+		return err
+	} else {
+		if !reflect.DeepEqual(expectIngress.Spec, currentIngress.Spec) {
+			currentIngress.Spec = expectIngress.Spec
+			logger.V(4).Info("update jupyternotebook ingress", "jupyternotebook", instance.Name)
+			if err := r.Update(ctx, currentIngress); err != nil {
+				logger.Error(err, "update jupyternotebook ingress failed")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newIngressForJupyterNotebook(instance *experimentv1alpha2.JupyterNotebook, parsedUrl *url.URL) *networkv1.Ingress {
+	pathType := networkv1.PathTypePrefix
+	path := parsedUrl.Path
+	if path == "" {
+		path = "/"
+	}
+
+	ingress := &networkv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+		},
+		Spec: networkv1.IngressSpec{
+			Rules: []networkv1.IngressRule{
+				{
+					Host: parsedUrl.Host,
+					IngressRuleValue: networkv1.IngressRuleValue{
+						HTTP: &networkv1.HTTPIngressRuleValue{
+							Paths: []networkv1.HTTPIngressPath{
+								{
+									Path:     path,
+									PathType: &pathType,
+									Backend: networkv1.IngressBackend{
+										Service: &networkv1.IngressServiceBackend{
+											Name: instance.Name,
+											Port: networkv1.ServiceBackendPort{
+												Name: notebookPortName,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// https is served with the certificate of TLSSecretName, or the default certificate of the ingress controller
+	if parsedUrl.Scheme == "https" {
+		ingress.Spec.TLS = []networkv1.IngressTLS{
+			{
+				Hosts:      []string{parsedUrl.Host},
+				SecretName: instance.Spec.TLSSecretName,
+			},
+		}
+	}
+
+	return ingress
+}
+
+func (r *JupyterNotebookReconciler) deletePersistentVolumeClaim(ctx context.Context, instance *experimentv1alpha2.JupyterNotebook) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, pvc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !k8sutil.IsControlledBy(pvc.OwnerReferences, experimentv1alpha2.ResourceKindJupyterNotebook, instance.Name) {
+		return nil
+	}
+
+	if err := r.Delete(ctx, pvc); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *JupyterNotebookReconciler) reconcilePersistentVolume(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.JupyterNotebook) error {
+	if instance.Spec.VolumeSize == "" || instance.Spec.StorageClassName == "" {
+		if err := r.deletePersistentVolumeClaim(ctx, instance); err != nil {
+			logger.Error(err, "delete PersistentVolumeClaim failed")
+			return err
+		}
+		return nil
+	}
+
+	expectPVC, err := newPersistentVolumeClaim(instance)
+	if err != nil {
+		logger.Error(err, "new persistent volume claim failed")
+		return err
+	}
+
+	if err := controllerutil.SetControllerReference(instance, expectPVC, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+	currentPVC := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentPVC); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("create jupyternotebook pvc", "jupyternotebook", instance.Name)
+			if err := r.Create(ctx, expectPVC); err != nil {
+				logger.Error(err, "create jupyternotebook pvc failed")
+				return err
+			}
+			return nil
+		}
+		logger.Error(err, "get jupyternotebook pvc failed")
+		return err
+	} else {
+		if currentPVC.Spec.StorageClassName == nil || *expectPVC.Spec.StorageClassName != *currentPVC.Spec.StorageClassName ||
+			!reflect.DeepEqual(expectPVC.Spec.Resources.Requests, currentPVC.Spec.Resources.Requests) {
+			err := errors.NewBadRequest("update jupyternotebook pvc is not allowed")
+			logger.Error(err, "update jupyternotebook pvc is not supported")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newPersistentVolumeClaim(instance *experimentv1alpha2.JupyterNotebook) (*corev1.PersistentVolumeClaim, error) {
+	storageClassName := instance.Spec.StorageClassName
+	pvcQuantity, err := resourcev1.ParseQuantity(instance.Spec.VolumeSize)
+	if err != nil {
+		return nil, err
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: instance.Namespace,
+			Labels:    labelsForJupyterNotebook(instance.Name),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: &storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: pvcQuantity,
+				},
+			},
+		},
+	}
+	return pvc, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *JupyterNotebookReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+
+	r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	if r.MaxConcurrentReconciles <= 0 {
+		r.MaxConcurrentReconciles = 1
+	}
 	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		}).
 		For(&experimentv1alpha2.JupyterNotebook{}).
 		Complete(r)
 }
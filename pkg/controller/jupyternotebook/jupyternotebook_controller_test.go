@@ -0,0 +1,77 @@
+package jupyternotebook
+
+import (
+	"net/url"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	experimentv1alpha2 "aiscope/pkg/apis/experiment/v1alpha2"
+)
+
+func TestUpdateStatefulSetSpec(t *testing.T) {
+	instance := &experimentv1alpha2.JupyterNotebook{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "dev"},
+		Spec: experimentv1alpha2.JupyterNotebookSpec{
+			Image: "jupyter/base-notebook",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			URL: "https://notebooks.example.com/dev/notebook",
+		},
+	}
+	expect, err := newStatefulSetForJupyterNotebook(instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the fields defaulted by the apiserver are not set by the controller
+	current := expect.DeepCopy()
+	current.Spec.PodManagementPolicy = appsv1.OrderedReadyPodManagement
+	current.Spec.RevisionHistoryLimit = new(int32)
+	current.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	current.Spec.Template.Spec.DNSPolicy = corev1.DNSClusterFirst
+	current.Spec.Template.Spec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+	current.Spec.Template.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory] = resource.MustParse("1024Mi")
+	if updateStatefulSetSpec(current, expect) {
+		t.Errorf("the defaulted statefulset should not be updated")
+	}
+
+	instance.Spec.Image = "jupyter/scipy-notebook"
+	if expect, err = newStatefulSetForJupyterNotebook(instance); err != nil {
+		t.Fatal(err)
+	}
+	if !updateStatefulSetSpec(current, expect) {
+		t.Fatalf("the statefulset should be updated with the image")
+	}
+	container := current.Spec.Template.Spec.Containers[0]
+	if container.Image != instance.Spec.Image || container.TerminationMessagePath != corev1.TerminationMessagePathDefault {
+		t.Errorf("the image should be updated and the defaulted fields kept: %+v", container)
+	}
+}
+
+func TestHTTPSIngress(t *testing.T) {
+	instance := &experimentv1alpha2.JupyterNotebook{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "dev"},
+		Spec: experimentv1alpha2.JupyterNotebookSpec{
+			URL:           "https://notebooks.example.com/dev/notebook",
+			TLSSecretName: "notebooks-tls",
+		},
+	}
+	parsedUrl, err := url.Parse(instance.Spec.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ingress := newIngressForJupyterNotebook(instance, parsedUrl)
+	if len(ingress.Spec.TLS) != 1 || ingress.Spec.TLS[0].SecretName != "notebooks-tls" {
+		t.Errorf("the ingress should terminate tls with the secret: %+v", ingress.Spec.TLS)
+	}
+	ingressRoute := ingressRouteForJupyterNotebook(instance, parsedUrl)
+	if ingressRoute.Spec.TLS == nil || ingressRoute.Spec.TLS.SecretName != "notebooks-tls" {
+		t.Errorf("the ingress route should terminate tls with the secret: %+v", ingressRoute.Spec.TLS)
+	}
+}
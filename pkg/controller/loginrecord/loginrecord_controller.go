@@ -59,6 +59,10 @@ type loginRecordController struct {
 	userSynced                  cache.InformerSynced
 	loginHistoryRetentionPeriod time.Duration
 	loginHistoryMaximumEntries  int
+	// the user is blocked if the failed login attempts reach authenticateRateLimiterMaxTries
+	// in authenticateRateLimiterDuration, the user controller unblocks the user after authenticateRateLimiterDuration
+	authenticateRateLimiterMaxTries int
+	authenticateRateLimiterDuration time.Duration
 	// recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
 	recorder record.EventRecorder
@@ -69,7 +73,9 @@ func NewLoginRecordController(k8sClient kubernetes.Interface,
 	loginRecordInformer iamv1alpha2informers.LoginRecordInformer,
 	userInformer iamv1alpha2informers.UserInformer,
 	loginHistoryRetentionPeriod time.Duration,
-	loginHistoryMaximumEntries int) *loginRecordController {
+	loginHistoryMaximumEntries int,
+	authenticateRateLimiterMaxTries int,
+	authenticateRateLimiterDuration time.Duration) *loginRecordController {
 
 	klog.V(4).Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
@@ -82,13 +88,15 @@ func NewLoginRecordController(k8sClient kubernetes.Interface,
 			Synced:    []cache.InformerSynced{loginRecordInformer.Informer().HasSynced, userInformer.Informer().HasSynced},
 			Name:      controllerName,
 		},
-		k8sClient:                   k8sClient,
-		aiClient:                    aiClient,
-		loginRecordLister:           loginRecordInformer.Lister(),
-		userLister:                  userInformer.Lister(),
-		loginHistoryRetentionPeriod: loginHistoryRetentionPeriod,
-		loginHistoryMaximumEntries:  loginHistoryMaximumEntries,
-		recorder:                    recorder,
+		k8sClient:                       k8sClient,
+		aiClient:                        aiClient,
+		loginRecordLister:               loginRecordInformer.Lister(),
+		userLister:                      userInformer.Lister(),
+		loginHistoryRetentionPeriod:     loginHistoryRetentionPeriod,
+		loginHistoryMaximumEntries:      loginHistoryMaximumEntries,
+		authenticateRateLimiterMaxTries: authenticateRateLimiterMaxTries,
+		authenticateRateLimiterDuration: authenticateRateLimiterDuration,
+		recorder:                        recorder,
 	}
 	ctl.Handler = ctl.reconcile
 	klog.Info("Setting up event handlers")
@@ -132,6 +140,12 @@ func (c *loginRecordController) reconcile(key string) error {
 		return err
 	}
 
+	if !loginRecord.Spec.Success {
+		if user, err = c.blockUserIfAuthLimitExceeded(user); err != nil {
+			return err
+		}
+	}
+
 	if err = c.updateUserLastLoginTime(user, loginRecord); err != nil {
 		return err
 	}
@@ -166,6 +180,49 @@ func (c *loginRecordController) updateUserLastLoginTime(user *iamv1alpha2.User,
 	return nil
 }
 
+// blockUserIfAuthLimitExceeded counts the failed login attempts of the active user in the sliding window,
+// the attempts before the last state transition are ignored, so that an unblocked user is not blocked again immediately.
+func (c *loginRecordController) blockUserIfAuthLimitExceeded(user *iamv1alpha2.User) (*iamv1alpha2.User, error) {
+	if c.authenticateRateLimiterMaxTries <= 0 || !user.DeletionTimestamp.IsZero() ||
+		user.Status.State == nil || *user.Status.State != iamv1alpha2.UserActive {
+		return user, nil
+	}
+
+	loginRecords, err := c.loginRecordLister.List(labels.SelectorFromSet(labels.Set{iamv1alpha2.UserReferenceLabel: user.Name}))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	since := now.Add(-c.authenticateRateLimiterDuration)
+	if user.Status.LastTransitionTime != nil && user.Status.LastTransitionTime.After(since) {
+		since = user.Status.LastTransitionTime.Time
+	}
+	failedLoginAttempts := 0
+	for _, loginRecord := range loginRecords {
+		if !loginRecord.Spec.Success && loginRecord.CreationTimestamp.After(since) {
+			failedLoginAttempts++
+		}
+	}
+
+	if failedLoginAttempts < c.authenticateRateLimiterMaxTries {
+		return user, nil
+	}
+
+	user = user.DeepCopy()
+	limitExceeded := iamv1alpha2.UserAuthLimitExceeded
+	user.Status.State = &limitExceeded
+	user.Status.Reason = fmt.Sprintf("Failed login attempts exceed %d in last %s", failedLoginAttempts, c.authenticateRateLimiterDuration)
+	user.Status.LastTransitionTime = &metav1.Time{Time: now}
+	updated, err := c.aiClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	klog.V(4).Infof("user %s is blocked: %s", user.Name, user.Status.Reason)
+	return updated, nil
+}
+
 // shrinkEntriesFor will delete old entries out of limit
 func (c *loginRecordController) shrinkEntriesFor(user *iamv1alpha2.User) error {
 	loginRecords, err := c.loginRecordLister.List(labels.SelectorFromSet(labels.Set{iamv1alpha2.UserReferenceLabel: user.Name}))
@@ -196,4 +253,3 @@ func (c *loginRecordController) userForLoginRecord(loginRecord *iamv1alpha2.Logi
 	}
 	return c.userLister.Get(username)
 }
-
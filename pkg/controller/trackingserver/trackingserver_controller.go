@@ -25,6 +25,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -57,8 +58,30 @@ const (
 	interval        = time.Second
 	timeout         = 15 * time.Second
 	syncFailMessage = "Failed to sync: %s"
+	// statusResyncPeriod is the interval to check the status until the tracking server is running
+	statusResyncPeriod = 30 * time.Second
+	crashLoopBackOff   = "CrashLoopBackOff"
+
+	credentialsSecretNameFormat = "%s-credentials"
+	tlsSecretNameFormat         = "%s-tls"
+	awsAccessKeySecretKey       = "aws-access-key"
+	awsSecretKeySecretKey       = "aws-secret-key"
+	backendPasswordSecretKey    = "backend-password"
+	backendPasswordEnv          = "BACKEND_PASSWORD"
+	backendPasswordIgnored      = "BackendPasswordIgnored"
 )
 
+// backendPasswordWrapper inserts the url encoded BACKEND_PASSWORD into BACKEND_URI before starting the
+// mlflow server like the CMD of the image, kubelet would expand the password into the uri without encoding it
+const backendPasswordWrapper = `import os, urllib.parse
+password = urllib.parse.quote(os.environ["BACKEND_PASSWORD"], safe="")
+uri = os.environ["BACKEND_URI"].replace("@", ":" + password + "@", 1)
+args = ["mlflow", "server", "--backend-store-uri", uri, "--host", "0.0.0.0", "--port", "5000"]
+if os.environ.get("ARTIFACT_ROOT"):
+    args += ["--default-artifact-root", os.environ["ARTIFACT_ROOT"]]
+os.execvp("mlflow", args)
+`
+
 // TrackingServerReconciler reconciles a TrackingServer object
 type TrackingServerReconciler struct {
 	client.Client
@@ -113,35 +136,237 @@ func (r *TrackingServerReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, nil
 	}
 
+	if migrated, err := r.migrateCredentials(rootCtx, logger, trackingServer); err != nil {
+		return reconcile.Result{}, r.syncFailed(rootCtx, logger, trackingServer, err)
+	} else if migrated {
+		// the update of spec triggers another reconcile
+		return ctrl.Result{}, nil
+	}
+
 	if err := r.reconcilePersistentVolume(rootCtx, logger, trackingServer); err != nil {
-		r.Recorder.Event(trackingServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
-		return reconcile.Result{}, err
+		return reconcile.Result{}, r.syncFailed(rootCtx, logger, trackingServer, err)
 	}
 
-	if err := r.reconcileSecret(rootCtx, logger, trackingServer); err != nil {
-		r.Recorder.Event(trackingServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
-		return reconcile.Result{}, err
+	if trackingServer.Spec.BackendPasswordRef != nil && !backendURIHasUser(trackingServer) {
+		r.Recorder.Event(trackingServer, corev1.EventTypeWarning, backendPasswordIgnored,
+			"backend_password_ref is ignored, backend_uri has no user to authenticate with the password")
 	}
 
 	if err := r.reconcileDeployment(rootCtx, logger, trackingServer); err != nil {
-		r.Recorder.Event(trackingServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
-		return reconcile.Result{}, err
+		return reconcile.Result{}, r.syncFailed(rootCtx, logger, trackingServer, err)
 	}
 
 	if err := r.reconcileService(rootCtx, logger, trackingServer); err != nil {
-		r.Recorder.Event(trackingServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
-		return reconcile.Result{}, err
+		return reconcile.Result{}, r.syncFailed(rootCtx, logger, trackingServer, err)
 	}
 
 	if err := r.reconcileIngress(rootCtx, logger, trackingServer); err != nil {
+		return reconcile.Result{}, r.syncFailed(rootCtx, logger, trackingServer, err)
+	}
+
+	// the legacy tls secret is deleted after the ingress has been switched to TLSSecretName
+	if err := r.deleteSecret(rootCtx, trackingServer); err != nil {
+		logger.Error(err, "delete legacy secret failed")
+		return reconcile.Result{}, r.syncFailed(rootCtx, logger, trackingServer, err)
+	}
+
+	if err := r.updateStatus(rootCtx, logger, trackingServer); err != nil {
 		r.Recorder.Event(trackingServer, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
 		return reconcile.Result{}, err
 	}
 
 	r.Recorder.Event(trackingServer, corev1.EventTypeNormal, successSynced, messageResourceSynced)
+
+	// pods restarts are not reflected in the deployment status, check again later
+	if trackingServer.Status.Phase != experimentv1alpha2.TrackingServerRunning {
+		return ctrl.Result{RequeueAfter: statusResyncPeriod}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
+// syncFailed records the sync error as event and marks the tracking server failed
+func (r *TrackingServerReconciler) syncFailed(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.TrackingServer, err error) error {
+	r.Recorder.Event(instance, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+
+	status := instance.Status.DeepCopy()
+	status.Phase = experimentv1alpha2.TrackingServerFailed
+	status.ObservedGeneration = instance.Generation
+	if !reflect.DeepEqual(status, &instance.Status) {
+		instance.Status = *status
+		if updateErr := r.Status().Update(ctx, instance); updateErr != nil {
+			logger.Error(updateErr, "update trackingserver status failed")
+		}
+	}
+
+	return err
+}
+
+// updateStatus observes the resources of the tracking server and updates the phase and conditions
+func (r *TrackingServerReconciler) updateStatus(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.TrackingServer) error {
+	status := instance.Status.DeepCopy()
+	status.ObservedGeneration = instance.Generation
+	status.URL = instance.Spec.URL
+
+	failed := false
+
+	if instance.Spec.VolumeSize != "" && instance.Spec.StorageClassName != "" {
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, pvc); err != nil {
+			logger.Error(err, "get trackingserver pvc failed")
+			return err
+		}
+		condition := metav1.Condition{
+			Type:               experimentv1alpha2.TrackingServerPersistentVolumeClaimBound,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             string(pvc.Status.Phase),
+			Message:            fmt.Sprintf("PersistentVolumeClaim %s is %s", pvc.Name, pvc.Status.Phase),
+		}
+		if pvc.Status.Phase == corev1.ClaimBound {
+			condition.Status = metav1.ConditionTrue
+		}
+		if pvc.Status.Phase == corev1.ClaimLost {
+			failed = true
+		}
+		if condition.Reason == "" {
+			condition.Reason = string(corev1.ClaimPending)
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+	} else {
+		meta.RemoveStatusCondition(&status.Conditions, experimentv1alpha2.TrackingServerPersistentVolumeClaimBound)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, deployment); err != nil {
+		logger.Error(err, "get trackingserver deployment failed")
+		return err
+	}
+	status.ReadyReplicas = deployment.Status.ReadyReplicas
+	deploymentCondition := metav1.Condition{
+		Type:               experimentv1alpha2.TrackingServerDeploymentAvailable,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             "MinimumReplicasUnavailable",
+		Message:            fmt.Sprintf("%d of %d replicas are available", deployment.Status.AvailableReplicas, instance.Spec.Size),
+	}
+	for _, c := range deployment.Status.Conditions {
+		switch {
+		case c.Type == appsv1.DeploymentAvailable && c.Status == corev1.ConditionTrue:
+			deploymentCondition.Status = metav1.ConditionTrue
+			deploymentCondition.Reason = c.Reason
+		case c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse,
+			c.Type == appsv1.DeploymentReplicaFailure && c.Status == corev1.ConditionTrue:
+			failed = true
+			deploymentCondition.Reason = c.Reason
+			deploymentCondition.Message = c.Message
+		}
+	}
+	crashLooping, err := r.isCrashLooping(ctx, instance)
+	if err != nil {
+		logger.Error(err, "list trackingserver pods failed")
+		return err
+	}
+	if crashLooping {
+		failed = true
+		deploymentCondition.Status = metav1.ConditionFalse
+		deploymentCondition.Reason = crashLoopBackOff
+		deploymentCondition.Message = "mlflow container is crash looping"
+	}
+	if deploymentCondition.Reason == "" {
+		deploymentCondition.Reason = "Unknown"
+	}
+	meta.SetStatusCondition(&status.Conditions, deploymentCondition)
+
+	ingressCondition, err := r.ingressCondition(ctx, instance)
+	if err != nil {
+		logger.Error(err, "get trackingserver ingress failed")
+		return err
+	}
+	meta.SetStatusCondition(&status.Conditions, ingressCondition)
+
+	switch {
+	case failed:
+		status.Phase = experimentv1alpha2.TrackingServerFailed
+	case meta.IsStatusConditionTrue(status.Conditions, experimentv1alpha2.TrackingServerDeploymentAvailable) &&
+		status.ReadyReplicas >= instance.Spec.Size:
+		status.Phase = experimentv1alpha2.TrackingServerRunning
+	default:
+		status.Phase = experimentv1alpha2.TrackingServerPending
+	}
+
+	if reflect.DeepEqual(status, &instance.Status) {
+		return nil
+	}
+
+	instance.Status = *status
+	logger.V(4).Info("update trackingserver status", "trackingserver", instance.Name, "phase", status.Phase)
+	if err := r.Status().Update(ctx, instance); err != nil {
+		logger.Error(err, "update trackingserver status failed")
+		return err
+	}
+
+	return nil
+}
+
+func (r *TrackingServerReconciler) isCrashLooping(ctx context.Context, instance *experimentv1alpha2.TrackingServer) (bool, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(instance.Namespace), client.MatchingLabels(labelsForTrackingServer(instance.Name))); err != nil {
+		return false, err
+	}
+
+	for _, pod := range pods.Items {
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason == crashLoopBackOff {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func (r *TrackingServerReconciler) ingressCondition(ctx context.Context, instance *experimentv1alpha2.TrackingServer) (metav1.Condition, error) {
+	condition := metav1.Condition{
+		Type:               experimentv1alpha2.TrackingServerIngressReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             "IngressNotFound",
+		Message:            fmt.Sprintf("no ingress is reconciled by ingress controller %q", r.IngressController),
+	}
+
+	switch r.IngressController {
+	case "traefik":
+		// IngressRoute has no status, it is served by traefik once created
+		if _, err := r.TraefikClient.TraefikV1alpha1().IngressRoutes(instance.Namespace).Get(ctx, instance.Name, metav1.GetOptions{}); err != nil {
+			if errors.IsNotFound(err) {
+				return condition, nil
+			}
+			return condition, err
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "IngressRouteCreated"
+		condition.Message = fmt.Sprintf("IngressRoute %s is created", instance.Name)
+	case "nginx":
+		ingress := &networkv1.Ingress{}
+		if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, ingress); err != nil {
+			if errors.IsNotFound(err) {
+				return condition, nil
+			}
+			return condition, err
+		}
+		if len(ingress.Status.LoadBalancer.Ingress) == 0 {
+			condition.Reason = "LoadBalancerPending"
+			condition.Message = fmt.Sprintf("Ingress %s has no load balancer address", instance.Name)
+			return condition, nil
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "LoadBalancerReady"
+		condition.Message = fmt.Sprintf("Ingress %s is served by the load balancer", instance.Name)
+	}
+
+	return condition, nil
+}
+
 func (r *TrackingServerReconciler) reconcileDeployment(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.TrackingServer) error {
 	expectDployment := newDeploymentForTrackingServer(instance)
 	if err := controllerutil.SetControllerReference(instance, expectDployment, scheme.Scheme); err != nil {
@@ -204,32 +429,7 @@ func newDeploymentForTrackingServer(instance *experimentv1alpha2.TrackingServer)
 								ContainerPort: 5000,
 								Name:        "server",
 							}},
-							Env: []corev1.EnvVar{
-								{
-									Name:   "MLFLOW_TRACKING_URI",
-									Value:  instance.Spec.URL,
-								},
-								{
-									Name:   "MLFLOW_S3_ENDPOINT_URL",
-									Value:  instance.Spec.S3_ENDPOINT_URL,
-								},
-								{
-									Name:   "AWS_ACCESS_KEY_ID",
-									Value:  instance.Spec.AWS_ACCESS_KEY,
-								},
-								{
-									Name:   "AWS_SECRET_ACCESS_KEY",
-									Value:  instance.Spec.AWS_SECRET_KEY,
-								},
-								{
-									Name:   "ARTIFACT_ROOT",
-									Value:  instance.Spec.ARTIFACT_ROOT,
-								},
-								{
-									Name:   "BACKEND_URI",
-									Value:  instance.Spec.BACKEND_URI,
-								},
-							},
+							Env: envForTrackingServer(instance),
 						},
 					},
 				},
@@ -237,6 +437,10 @@ func newDeploymentForTrackingServer(instance *experimentv1alpha2.TrackingServer)
 		},
 	}
 
+	if instance.Spec.BackendPasswordRef != nil && backendURIHasUser(instance) {
+		deployment.Spec.Template.Spec.Containers[0].Command = []string{"python3", "-c", backendPasswordWrapper}
+	}
+
 	if instance.Spec.VolumeSize != "" && instance.Spec.StorageClassName != "" {
 		deployment.Spec.Template.Spec.Volumes = []corev1.Volume{
 			{
@@ -260,6 +464,200 @@ func newDeploymentForTrackingServer(instance *experimentv1alpha2.TrackingServer)
 	return deployment
 }
 
+func envForTrackingServer(instance *experimentv1alpha2.TrackingServer) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{
+			Name:   "MLFLOW_TRACKING_URI",
+			Value:  instance.Spec.URL,
+		},
+		{
+			Name:   "MLFLOW_S3_ENDPOINT_URL",
+			Value:  instance.Spec.S3_ENDPOINT_URL,
+		},
+	}
+
+	if instance.Spec.AWSAccessKeyRef != nil {
+		env = append(env, corev1.EnvVar{
+			Name:      "AWS_ACCESS_KEY_ID",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: instance.Spec.AWSAccessKeyRef},
+		})
+	}
+
+	if instance.Spec.AWSSecretKeyRef != nil {
+		env = append(env, corev1.EnvVar{
+			Name:      "AWS_SECRET_ACCESS_KEY",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: instance.Spec.AWSSecretKeyRef},
+		})
+	}
+
+	env = append(env, corev1.EnvVar{
+		Name:   "ARTIFACT_ROOT",
+		Value:  instance.Spec.ARTIFACT_ROOT,
+	})
+
+	if instance.Spec.BackendPasswordRef != nil {
+		env = append(env, corev1.EnvVar{
+			Name:      backendPasswordEnv,
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: instance.Spec.BackendPasswordRef},
+		})
+	}
+
+	env = append(env, corev1.EnvVar{
+		Name:   "BACKEND_URI",
+		Value:  backendURIForTrackingServer(instance),
+	})
+
+	return env
+}
+
+// backendURIForTrackingServer returns BACKEND_URI without the password, the password of
+// BACKEND_PASSWORD is inserted into the uri by backendPasswordWrapper in the container
+func backendURIForTrackingServer(instance *experimentv1alpha2.TrackingServer) string {
+	if instance.Spec.BackendPasswordRef == nil {
+		return instance.Spec.BACKEND_URI
+	}
+
+	parsedUrl, err := url.Parse(instance.Spec.BACKEND_URI)
+	if err != nil || parsedUrl.User == nil {
+		return instance.Spec.BACKEND_URI
+	}
+
+	parsedUrl.User = url.User(parsedUrl.User.Username())
+	return parsedUrl.String()
+}
+
+// backendURIHasUser returns whether BACKEND_URI has a user the password of BackendPasswordRef belongs to
+func backendURIHasUser(instance *experimentv1alpha2.TrackingServer) bool {
+	parsedUrl, err := url.Parse(instance.Spec.BACKEND_URI)
+	return err == nil && parsedUrl.User != nil
+}
+
+// migrateCredentials moves the plain text credentials and certificate of the spec into
+// secrets and replaces them with secret references, returns true if the spec is updated
+func (r *TrackingServerReconciler) migrateCredentials(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.TrackingServer) (bool, error) {
+	spec := instance.Spec.DeepCopy()
+	credentialsName := fmt.Sprintf(credentialsSecretNameFormat, instance.Name)
+	credentials := make(map[string][]byte)
+
+	if spec.AWS_ACCESS_KEY != "" {
+		if spec.AWSAccessKeyRef == nil {
+			credentials[awsAccessKeySecretKey] = []byte(spec.AWS_ACCESS_KEY)
+			spec.AWSAccessKeyRef = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: credentialsName},
+				Key:                  awsAccessKeySecretKey,
+			}
+		}
+		spec.AWS_ACCESS_KEY = ""
+	}
+
+	if spec.AWS_SECRET_KEY != "" {
+		if spec.AWSSecretKeyRef == nil {
+			credentials[awsSecretKeySecretKey] = []byte(spec.AWS_SECRET_KEY)
+			spec.AWSSecretKeyRef = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: credentialsName},
+				Key:                  awsSecretKeySecretKey,
+			}
+		}
+		spec.AWS_SECRET_KEY = ""
+	}
+
+	if parsedUrl, err := url.Parse(spec.BACKEND_URI); err == nil && parsedUrl.User != nil {
+		if password, ok := parsedUrl.User.Password(); ok {
+			if spec.BackendPasswordRef == nil {
+				credentials[backendPasswordSecretKey] = []byte(password)
+				spec.BackendPasswordRef = &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: credentialsName},
+					Key:                  backendPasswordSecretKey,
+				}
+			}
+			parsedUrl.User = url.User(parsedUrl.User.Username())
+			spec.BACKEND_URI = parsedUrl.String()
+		}
+	}
+
+	if len(credentials) > 0 {
+		if err := r.createOrMergeSecret(ctx, logger, instance, credentialsName, corev1.SecretTypeOpaque, credentials); err != nil {
+			return false, err
+		}
+	}
+
+	if spec.Cert != "" || spec.Key != "" {
+		if spec.TLSSecretName == "" && spec.Cert != "" && spec.Key != "" {
+			tlsName := fmt.Sprintf(tlsSecretNameFormat, instance.Name)
+			data := map[string][]byte{
+				corev1.TLSCertKey:       []byte(spec.Cert),
+				corev1.TLSPrivateKeyKey: []byte(spec.Key),
+			}
+			if err := r.createOrMergeSecret(ctx, logger, instance, tlsName, corev1.SecretTypeTLS, data); err != nil {
+				return false, err
+			}
+			spec.TLSSecretName = tlsName
+		}
+		spec.Cert = ""
+		spec.Key = ""
+	}
+
+	if reflect.DeepEqual(spec, &instance.Spec) {
+		return false, nil
+	}
+
+	instance.Spec = *spec
+	// the last applied configuration of kubectl keeps the plain text credentials
+	delete(instance.Annotations, corev1.LastAppliedConfigAnnotation)
+	logger.V(4).Info("migrate trackingserver credentials to secrets", "trackingserver", instance.Name)
+	if err := r.Update(ctx, instance); err != nil {
+		logger.Error(err, "update trackingserver failed")
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *TrackingServerReconciler) createOrMergeSecret(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.TrackingServer, name string, secretType corev1.SecretType, data map[string][]byte) error {
+	currentSecret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, currentSecret); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "get trackingserver secret failed")
+			return err
+		}
+
+		expectSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: instance.Namespace,
+				Labels:    labelsForTrackingServer(instance.Name),
+			},
+			Data: data,
+			Type: secretType,
+		}
+		if err := controllerutil.SetControllerReference(instance, expectSecret, scheme.Scheme); err != nil {
+			logger.Error(err, "set controller reference failed")
+			return err
+		}
+
+		logger.V(4).Info("create trackingserver secret", "trackingserver", instance.Name, "secret", name)
+		if err := r.Create(ctx, expectSecret); err != nil {
+			logger.Error(err, "create trackingserver secret failed")
+			return err
+		}
+		return nil
+	}
+
+	if currentSecret.Data == nil {
+		currentSecret.Data = make(map[string][]byte)
+	}
+	for k, v := range data {
+		currentSecret.Data[k] = v
+	}
+	logger.V(4).Info("update trackingserver secret", "trackingserver", instance.Name, "secret", name)
+	if err := r.Update(ctx, currentSecret); err != nil {
+		logger.Error(err, "update trackingserver secret failed")
+		return err
+	}
+
+	return nil
+}
+
 func (r *TrackingServerReconciler) reconcileService(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.TrackingServer) error {
 	expectService := newServiceForTrackingServer(instance)
 	if err := controllerutil.SetControllerReference(instance, expectService, scheme.Scheme); err != nil {
@@ -321,6 +719,7 @@ func labelsForTrackingServer(name string) map[string]string {
 	return map[string]string{"app": "trackingserver", "ts_name": name}
 }
 
+// deleteSecret deletes the tls secret created from the deprecated Cert and Key
 func (r *TrackingServerReconciler) deleteSecret(ctx context.Context, instance *experimentv1alpha2.TrackingServer) error {
 	secret := &corev1.Secret{}
 	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, secret); err != nil {
@@ -338,68 +737,18 @@ func (r *TrackingServerReconciler) deleteSecret(ctx context.Context, instance *e
 	return nil
 }
 
-func (r *TrackingServerReconciler) reconcileSecret(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.TrackingServer) error {
-	if instance.Spec.Cert == "" || instance.Spec.Key == "" {
-		if err := r.deleteSecret(ctx, instance); err != nil {
-			logger.Error(err, "delete secret failed")
-			return err
-		}
-		return nil
-	}
-
-	expectSecret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:       instance.Name,
-			Namespace:  instance.Namespace,
-		},
-		Data: map[string][]byte{
-			"tls.crt":  []byte(instance.Spec.Cert),
-			"tls.key":  []byte(instance.Spec.Key),
-		},
-		Type: corev1.SecretTypeTLS,
-	}
-
-	if err := controllerutil.SetControllerReference(instance, expectSecret, scheme.Scheme); err != nil {
-		logger.Error(err, "set controller reference failed")
-		return err
-	}
-
-	currentSecret := &corev1.Secret{}
-	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentSecret); err != nil {
-		if errors.IsNotFound(err) {
-			logger.V(4).Info("create trackingserver secret", "trackingserver", instance.Name)
-			if err := r.Create(ctx, expectSecret); err != nil {
-				logger.Error(err, "create trackingserver secret failed")
-				return err
-			}
-			return nil
-		}
-
-		logger.Error(err, "get trackingserver secret failed")
-		return err
-	} else {
-		if !reflect.DeepEqual(expectSecret.Data, currentSecret.Data) {
-			currentSecret.Data = expectSecret.Data
-			logger.V(4).Info("update trackingserver secret", "trackingserver", instance.Name)
-			if err := r.Update(ctx, currentSecret); err != nil {
-				logger.Error(err, "update trackingserver secret failed")
+func (r *TrackingServerReconciler) reconcileIngress(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.TrackingServer) error {
+	var secret *corev1.Secret
+	if instance.Spec.TLSSecretName != "" {
+		secret = &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: instance.Spec.TLSSecretName, Namespace: instance.Namespace}, secret); err != nil {
+			if !errors.IsNotFound(err) {
 				return err
 			}
+			secret = nil
 		}
 	}
 
-	return nil
-}
-
-func (r *TrackingServerReconciler) reconcileIngress(ctx context.Context, logger logr.Logger, instance *experimentv1alpha2.TrackingServer) error {
-	secret := &corev1.Secret{}
-	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, secret); err != nil {
-		if !errors.IsNotFound(err) {
-			return err
-		}
-		secret = nil
-	}
-
 	if r.IngressController == "traefik" {
 		if err := r.reconcileTraefikRoute(ctx, logger, instance, secret); err != nil {
 			return err
@@ -490,13 +839,6 @@ func (r *TrackingServerReconciler) reconcileNginxIngress(ctx context.Context, lo
 		return err
 	}
 
-	currentSecret := &corev1.Secret{}
-	if err := r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, currentSecret); err != nil {
-		if !errors.IsNotFound(err) {
-			return err
-		}
-	}
-
 	expectIngress := newIngressForTrackingServer(instance, secret, parsedUrl)
 
 	if err := controllerutil.SetControllerReference(instance, expectIngress, scheme.Scheme); err != nil {
@@ -631,7 +973,7 @@ func newIngressForTrackingServer(instance *experimentv1alpha2.TrackingServer, se
 				Hosts: []string{
 					parsedUrl.Host,
 				},
-				SecretName: instance.Name,
+				SecretName: secret.Name,
 			},
 		}
 	}
@@ -653,7 +995,7 @@ func ingressRouteForTrackingServer(instance *experimentv1alpha2.TrackingServer,
 	if secret != nil {
 		ingressRoute.Spec.EntryPoints = []string{"websecure","web"}
 		ingressRoute.Spec.TLS = &traefikv1alpha1.TLS{
-			SecretName: instance.Name,
+			SecretName: secret.Name,
 		}
 	}
 
@@ -826,5 +1168,7 @@ func (r *TrackingServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
 		}).
 		For(&experimentv1alpha2.TrackingServer{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.PersistentVolumeClaim{}).
 		Complete(r)
 }
@@ -0,0 +1,47 @@
+package trackingserver
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	experimentv1alpha2 "aiscope/pkg/apis/experiment/v1alpha2"
+)
+
+func TestBackendPassword(t *testing.T) {
+	passwordRef := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "mlflow-credentials"},
+		Key:                  backendPasswordSecretKey,
+	}
+
+	tests := []struct {
+		description string
+		backendURI  string
+		passwordRef *corev1.SecretKeySelector
+		expectedURI string
+		wrapped     bool
+	}{
+		{"no password", "mysql+pymysql://root@db:3306/mlflow", nil, "mysql+pymysql://root@db:3306/mlflow", false},
+		{"password of user", "mysql+pymysql://root@db:3306/mlflow", passwordRef, "mysql+pymysql://root@db:3306/mlflow", true},
+		{"password in uri", "mysql+pymysql://root:secret@db:3306/mlflow", passwordRef, "mysql+pymysql://root@db:3306/mlflow", true},
+		{"no user", "sqlite:////mlflow/mlflow.db", passwordRef, "sqlite:////mlflow/mlflow.db", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			instance := &experimentv1alpha2.TrackingServer{Spec: experimentv1alpha2.TrackingServerSpec{
+				BACKEND_URI:        test.backendURI,
+				BackendPasswordRef: test.passwordRef,
+			}}
+			container := newDeploymentForTrackingServer(instance).Spec.Template.Spec.Containers[0]
+			for _, env := range container.Env {
+				if env.Name == "BACKEND_URI" && env.Value != test.expectedURI {
+					t.Errorf("BACKEND_URI = %s, want %s", env.Value, test.expectedURI)
+				}
+			}
+			if wrapped := len(container.Command) > 0; wrapped != test.wrapped {
+				t.Errorf("wrapped = %v, want %v", wrapped, test.wrapped)
+			}
+		})
+	}
+}
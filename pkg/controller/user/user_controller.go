@@ -31,6 +31,7 @@ import (
 	"time"
 
 	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authentication"
 	ldapclient "aiscope/pkg/simple/client/ldap"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilwait "k8s.io/apimachinery/pkg/util/wait"
@@ -57,6 +58,7 @@ type Reconciler struct {
 	Scheme                  *runtime.Scheme
 	LdapClient              ldapclient.Interface
 	KubeconfigClient        kubeconfig.Interface
+	AuthenticationOptions   *authentication.Options
 	Logger                  logr.Logger
 	Recorder                record.EventRecorder
 	MaxConcurrentReconciles int
@@ -131,14 +133,15 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
-	if err = r.syncUserStatus(ctx, user); err != nil {
+	requeueAfter, err := r.syncUserStatus(ctx, user)
+	if err != nil {
 		klog.Error(err)
 		r.Recorder.Event(user, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
 		return ctrl.Result{}, err
 	}
 
 	if r.KubeconfigClient != nil {
-		// ensure user KubeconfigClient configmap is created
+		// ensure user KubeconfigClient secret is created
 		if err = r.KubeconfigClient.CreateKubeConfig(user); err != nil {
 			klog.Error(err)
 			r.Recorder.Event(user, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
@@ -148,7 +151,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 	r.Recorder.Event(user, corev1.EventTypeNormal, successSynced, messageResourceSynced)
 
-	return ctrl.Result{}, nil
+	// blocked user will be unblocked after AuthenticateRateLimiterDuration
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (r *Reconciler) waitForSyncToLDAP(user *iamv1alpha2.User) error {
@@ -216,8 +220,8 @@ func (r *Reconciler) encryptPassword(ctx context.Context, user *iamv1alpha2.User
 }
 
 
-// syncUserStatus Update the user status
-func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User) error {
+// syncUserStatus Update the user status, returns the duration after which the blocked user should be unblocked
+func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User) (time.Duration, error) {
 	if user.Spec.EncryptedPassword == "" {
 		if user.Labels[iamv1alpha2.IdentifyProviderLabel] != "" {
 			// mapped user from other identity provider always active until disabled
@@ -229,7 +233,7 @@ func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User)
 				}
 				err := r.Update(ctx, user, &client.UpdateOptions{})
 				if err != nil {
-					return err
+					return 0, err
 				}
 			}
 		} else {
@@ -242,17 +246,17 @@ func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User)
 				}
 				err := r.Update(ctx, user, &client.UpdateOptions{})
 				if err != nil {
-					return err
+					return 0, err
 				}
 			}
 		}
-		return nil
+		return 0, nil
 	}
 
 	// becomes active after password encrypted
 	if isEncrypted(user.Spec.EncryptedPassword) {
-		if user.Status.State == nil || *user.Status.State == iamv1alpha2.UserDisabled {
-			fmt.Printf("user status state\n")
+		if user.Status.State == nil ||
+			(*user.Status.State == iamv1alpha2.UserDisabled && user.Status.Reason != iamv1alpha2.UserDisabledByAdministrator) {
 			active := iamv1alpha2.UserActive
 			user.Status = iamv1alpha2.UserStatus{
 				State:              &active,
@@ -260,7 +264,7 @@ func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User)
 			}
 			err := r.Update(ctx, user, &client.UpdateOptions{})
 			if err != nil {
-				return err
+				return 0, err
 			}
 		}
 	}
@@ -268,22 +272,24 @@ func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User)
 	// blocked user, check if need to unblock user
 	if user.Status.State != nil && *user.Status.State == iamv1alpha2.UserAuthLimitExceeded {
 		if user.Status.LastTransitionTime != nil {
-			// && user.Status.LastTransitionTime.Add(r.AuthenticationOptions.AuthenticateRateLimiterDuration).Before(time.Now()) {
-			// unblock user
-			active := iamv1alpha2.UserActive
-			user.Status = iamv1alpha2.UserStatus{
-				State:              &active,
-				LastTransitionTime: &metav1.Time{Time: time.Now()},
-			}
-			err := r.Update(ctx, user, &client.UpdateOptions{})
-			if err != nil {
-				return err
+			unblockAfter := time.Until(user.Status.LastTransitionTime.Add(r.AuthenticationOptions.AuthenticateRateLimiterDuration))
+			if unblockAfter > 0 {
+				return unblockAfter, nil
 			}
-			return nil
+		}
+		// unblock user
+		active := iamv1alpha2.UserActive
+		user.Status = iamv1alpha2.UserStatus{
+			State:              &active,
+			LastTransitionTime: &metav1.Time{Time: time.Now()},
+		}
+		err := r.Update(ctx, user, &client.UpdateOptions{})
+		if err != nil {
+			return 0, err
 		}
 	}
 
-	return nil
+	return 0, nil
 }
 
 func encrypt(password string) (string, error) {
@@ -316,6 +322,9 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if r.MaxConcurrentReconciles <= 0 {
 		r.MaxConcurrentReconciles = 1
 	}
+	if r.AuthenticationOptions == nil {
+		r.AuthenticationOptions = authentication.NewOptions()
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(controllerName).
 		WithOptions(controller.Options{
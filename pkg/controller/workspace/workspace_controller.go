@@ -23,8 +23,10 @@ import (
 	"aiscope/pkg/utils/k8sutil"
 	"aiscope/pkg/utils/sliceutil"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/go-logr/logr"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
@@ -54,6 +56,7 @@ type Reconciler struct {
 //+kubebuilder:rbac:groups=tenant.aiscope.io,resources=workspaces,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=tenant.aiscope.io,resources=workspaces/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=tenant.aiscope.io,resources=workspaces/finalizers,verbs=update
+//+kubebuilder:rbac:groups=iam.aiscope,resources=workspaceroles;workspacerolebindings,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -99,6 +102,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, nil
 	}
 
+	if err := r.initWorkspaceRoles(rootCtx, logger, workspace); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.initManagerRoleBinding(rootCtx, logger, workspace); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	var namespaces corev1.NamespaceList
 	if err := r.List(rootCtx, &namespaces, client.MatchingLabels{tenantv1alpha2.WorkspaceLabel: req.Name}); err != nil {
 		logger.Error(err, "list namespaces failed")
@@ -133,6 +144,93 @@ func (r *Reconciler) bindWorkspace(ctx context.Context, logger logr.Logger, name
 	return nil
 }
 
+// initWorkspaceRoles stamps out the built-in workspace roles, roles that already exist are left
+// untouched, their rules are kept up to date with the role templates by the workspacerole controller.
+func (r *Reconciler) initWorkspaceRoles(ctx context.Context, logger logr.Logger, workspace *tenantv1alpha2.Workspace) error {
+	for _, roleBase := range builtinWorkspaceRoles {
+		workspaceRole, err := newWorkspaceRole(workspace.Name, roleBase)
+		if err != nil {
+			logger.Error(err, "generate workspace role failed")
+			return err
+		}
+		if err := controllerutil.SetControllerReference(workspace, workspaceRole, scheme.Scheme); err != nil {
+			logger.Error(err, "set controller reference failed")
+			return err
+		}
+		if err := r.Create(ctx, workspaceRole); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+			logger.Error(err, "create workspace role failed", "workspacerole", workspaceRole.Name)
+			return err
+		}
+		logger.V(4).Info("workspace role created", "workspacerole", workspaceRole.Name)
+	}
+	return nil
+}
+
+// initManagerRoleBinding binds the workspace manager to the admin role of the workspace
+func (r *Reconciler) initManagerRoleBinding(ctx context.Context, logger logr.Logger, workspace *tenantv1alpha2.Workspace) error {
+	manager := workspace.Spec.Manager
+	if manager == "" {
+		return nil
+	}
+
+	workspaceAdmin := fmt.Sprintf("%s-%s", workspace.Name, iamv1alpha2.WorkspaceAdmin)
+	roleBinding := &iamv1alpha2.WorkspaceRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s-%s", manager, workspaceAdmin),
+			Labels: map[string]string{
+				iamv1alpha2.UserReferenceLabel: manager,
+				tenantv1alpha2.WorkspaceLabel:  workspace.Name,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: iamv1alpha2.SchemeGroupVersion.String(),
+			Kind:     iamv1alpha2.ResourceKindWorkspaceRole,
+			Name:     workspaceAdmin,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Name:     manager,
+				Kind:     iamv1alpha2.ResourceKindUser,
+				APIGroup: iamv1alpha2.SchemeGroupVersion.String(),
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(workspace, roleBinding, scheme.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+	if err := r.Create(ctx, roleBinding); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		logger.Error(err, "create workspace manager role binding failed", "manager", manager)
+		return err
+	}
+	logger.V(4).Info("workspace manager bound to admin role", "manager", manager)
+	return nil
+}
+
+func newWorkspaceRole(workspace string, roleBase workspaceRoleBase) (*iamv1alpha2.WorkspaceRole, error) {
+	aggregationRoles, err := json.Marshal(roleBase.aggregationRoles)
+	if err != nil {
+		return nil, err
+	}
+	return &iamv1alpha2.WorkspaceRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("%s-%s", workspace, roleBase.name),
+			Labels: map[string]string{tenantv1alpha2.WorkspaceLabel: workspace},
+			Annotations: map[string]string{
+				iamv1alpha2.AggregationRolesAnnotation: string(aggregationRoles),
+				constants.CreatorAnnotationKey:         constants.SystemCreator,
+			},
+		},
+		Rules: roleBase.rules,
+	}, nil
+}
+
 func (r *Reconciler) initDevopsNamespace(ctx context.Context, logger logr.Logger, workspace string) error {
 
 	creatorNamespace := newCreatorNamespace(constants.AIScopeCreator, workspace)
@@ -177,5 +275,7 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
 		}).
 		For(&tenantv1alpha2.Workspace{}).
+		Owns(&iamv1alpha2.WorkspaceRole{}).
+		Owns(&iamv1alpha2.WorkspaceRoleBinding{}).
 		Complete(r)
 }
@@ -0,0 +1,106 @@
+package workspace
+
+import (
+	experimentv1alpha2 "aiscope/pkg/apis/experiment/v1alpha2"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// The default rules are scoped to the aiscope resources and the core resources listed below,
+// the secrets holding the credentials of the workloads are never granted by the built-in roles.
+var (
+	aiscopeAPIGroups = []string{
+		experimentv1alpha2.SchemeGroupVersion.Group,
+		iamv1alpha2.SchemeGroupVersion.Group,
+		tenantv1alpha2.SchemeGroupVersion.Group,
+	}
+	coreResources = []string{
+		"namespaces", "pods", "pods/log", "services", "configmaps", "persistentvolumeclaims", "events",
+	}
+)
+
+// workspaceRoleBase is the template a built-in WorkspaceRole is stamped out from for every workspace.
+// The rules are only the defaults, once the role templates listed in aggregationRoles are present
+// the rules are aggregated from them by the workspacerole controller.
+type workspaceRoleBase struct {
+	name             string
+	aggregationRoles []string
+	rules            []rbacv1.PolicyRule
+}
+
+var builtinWorkspaceRoles = []workspaceRoleBase{
+	{
+		name: iamv1alpha2.WorkspaceAdmin,
+		aggregationRoles: []string{
+			"role-template-manage-workspace-settings", "role-template-view-workspace-settings",
+			"role-template-manage-projects", "role-template-view-projects", "role-template-create-projects",
+			"role-template-manage-members", "role-template-view-members",
+			"role-template-manage-roles", "role-template-view-roles",
+			"role-template-manage-groups", "role-template-view-groups",
+		},
+		rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: aiscopeAPIGroups,
+				Resources: []string{"*"},
+				Verbs:     []string{"*"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: append([]string{"pods/exec"}, coreResources...),
+				Verbs:     []string{"*"},
+			},
+		},
+	},
+	{
+		name: iamv1alpha2.WorkspaceRegular,
+		aggregationRoles: []string{
+			"role-template-view-workspace-settings",
+		},
+		rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"*"},
+				Resources: []string{"workspaces", "workspacemembers"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+		},
+	},
+	{
+		name: iamv1alpha2.WorkspaceViewer,
+		aggregationRoles: []string{
+			"role-template-view-projects", "role-template-view-members",
+			"role-template-view-roles", "role-template-view-groups",
+			"role-template-view-workspace-settings",
+		},
+		rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: aiscopeAPIGroups,
+				Resources: []string{"*"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: coreResources,
+				Verbs:     []string{"get", "list", "watch"},
+			},
+		},
+	},
+	{
+		name: iamv1alpha2.WorkspaceSelfProvisioner,
+		aggregationRoles: []string{
+			"role-template-create-projects", "role-template-view-workspace-settings",
+		},
+		rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"*"},
+				Resources: []string{"workspaces", "workspacemembers"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			{
+				APIGroups: []string{"*"},
+				Resources: []string{"namespaces"},
+				Verbs:     []string{"create", "watch"},
+			},
+		},
+	},
+}
@@ -21,14 +21,23 @@ import (
 	"aiscope/pkg/constants"
 	controllerutils "aiscope/pkg/controller/utils/controller"
 	"aiscope/pkg/utils/k8sutil"
+	"aiscope/pkg/utils/sliceutil"
 	"context"
+	"encoding/json"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -71,9 +80,31 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
 		}).
 		For(&iamv1alpha2.WorkspaceRole{}).
+		Watches(&source.Kind{Type: &iamv1alpha2.WorkspaceRole{}}, handler.EnqueueRequestsFromMapFunc(r.mapRoleTemplateToRoles)).
 		Complete(r)
 }
 
+// mapRoleTemplateToRoles enqueues the workspace roles aggregating the changed role template
+func (r *Reconciler) mapRoleTemplateToRoles(obj client.Object) []reconcile.Request {
+	if !isRoleTemplate(obj) {
+		return nil
+	}
+
+	workspaceRoles := &iamv1alpha2.WorkspaceRoleList{}
+	if err := r.List(context.Background(), workspaceRoles); err != nil {
+		r.Logger.Error(err, "list workspace roles failed")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, workspaceRole := range workspaceRoles.Items {
+		if sliceutil.HasString(aggregationRoles(&workspaceRole), obj.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: workspaceRole.Name}})
+		}
+	}
+	return requests
+}
+
 // +kubebuilder:rbac:groups=iam.aiscope,resources=workspaceroles,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=tenant.aiscope,resources=workspaces,verbs=get;list;watch;
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -89,10 +120,89 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	if err := r.aggregateRoleTemplates(rootCtx, logger, workspaceRole); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	r.Recorder.Event(workspaceRole, corev1.EventTypeNormal, controllerutils.SuccessSynced, controllerutils.MessageResourceSynced)
 	return ctrl.Result{}, nil
 }
 
+// aggregateRoleTemplates replaces the rules of the role with the rules of the role templates
+// listed in the aggregation annotation, the rules are left untouched if none of the templates exist.
+// Only the system role templates are aggregated, the workspace roles named after a missing template are ignored.
+func (r *Reconciler) aggregateRoleTemplates(ctx context.Context, logger logr.Logger, workspaceRole *iamv1alpha2.WorkspaceRole) error {
+	templateNames := aggregationRoles(workspaceRole)
+	if len(templateNames) == 0 {
+		return nil
+	}
+
+	rules := make([]rbacv1.PolicyRule, 0)
+	found := false
+	for _, templateName := range templateNames {
+		template := &iamv1alpha2.WorkspaceRole{}
+		if err := r.Get(ctx, types.NamespacedName{Name: templateName}, template); err != nil {
+			if errors.IsNotFound(err) {
+				logger.V(4).Info("role template not found", "template", templateName)
+				continue
+			}
+			logger.Error(err, "get role template failed", "template", templateName)
+			return err
+		}
+		if !isRoleTemplate(template) {
+			logger.Info("ignore workspace role which is not a system role template", "template", templateName)
+			continue
+		}
+		found = true
+		for _, rule := range template.Rules {
+			if !ruleExists(rules, rule) {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	if !found || reflect.DeepEqual(rules, workspaceRole.Rules) {
+		return nil
+	}
+
+	workspaceRole.Rules = rules
+	if err := r.Update(ctx, workspaceRole); err != nil {
+		logger.Error(err, "update aggregated workspace role failed")
+		return err
+	}
+	return nil
+}
+
+func aggregationRoles(workspaceRole *iamv1alpha2.WorkspaceRole) []string {
+	annotation := workspaceRole.Annotations[iamv1alpha2.AggregationRolesAnnotation]
+	if annotation == "" {
+		return nil
+	}
+	var roles []string
+	if err := json.Unmarshal([]byte(annotation), &roles); err != nil {
+		klog.Warningf("invalid aggregation roles annotation of workspace role %s: %v", workspaceRole.Name, err)
+		return nil
+	}
+	return roles
+}
+
+// isRoleTemplate returns whether the workspace role is a role template created by the system,
+// the roles of the workspaces carry the workspace label and can not be role templates.
+func isRoleTemplate(obj client.Object) bool {
+	return obj.GetLabels()[iamv1alpha2.RoleTemplateLabel] == "true" &&
+		obj.GetAnnotations()[constants.CreatorAnnotationKey] == constants.SystemCreator &&
+		obj.GetLabels()[constants.WorkspaceLabelKey] == ""
+}
+
+func ruleExists(rules []rbacv1.PolicyRule, rule rbacv1.PolicyRule) bool {
+	for _, item := range rules {
+		if reflect.DeepEqual(item, rule) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Reconciler) bindWorkspace(ctx context.Context, logger logr.Logger, workspaceRole *iamv1alpha2.WorkspaceRole) error {
 	workspaceName := workspaceRole.Labels[constants.WorkspaceLabelKey]
 	if workspaceName == "" {
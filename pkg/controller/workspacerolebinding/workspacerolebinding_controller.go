@@ -24,11 +24,17 @@ import (
 	"context"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -72,11 +78,73 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
 		}).
 		For(&iamv1alpha2.WorkspaceRoleBinding{}).
+		Owns(&rbacv1.RoleBinding{}).
+		Watches(&source.Kind{Type: &iamv1alpha2.WorkspaceRole{}}, handler.EnqueueRequestsFromMapFunc(r.mapWorkspaceRoleToBindings)).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(r.mapNamespaceToBindings)).
 		Complete(r)
 }
 
+// mapWorkspaceRoleToBindings enqueues the bindings referring to the workspace role, so the rules
+// of the propagated roles follow the workspace role.
+func (r *Reconciler) mapWorkspaceRoleToBindings(obj client.Object) []reconcile.Request {
+	workspace := obj.GetLabels()[tenantv1alpha2.WorkspaceLabel]
+	if workspace == "" {
+		return nil
+	}
+
+	workspaceRoleBindings := &iamv1alpha2.WorkspaceRoleBindingList{}
+	if err := r.List(context.Background(), workspaceRoleBindings, client.MatchingLabels{tenantv1alpha2.WorkspaceLabel: workspace}); err != nil {
+		r.Logger.Error(err, "list workspace role bindings failed")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, workspaceRoleBinding := range workspaceRoleBindings.Items {
+		if workspaceRoleBinding.RoleRef.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: workspaceRoleBinding.Name}})
+		}
+	}
+	return requests
+}
+
+// mapNamespaceToBindings enqueues the bindings of the workspace the namespace belongs to,
+// and the bindings already propagated into the namespace in case it left the workspace.
+func (r *Reconciler) mapNamespaceToBindings(obj client.Object) []reconcile.Request {
+	ctx := context.Background()
+	names := make(map[string]bool)
+
+	if workspace := obj.GetLabels()[tenantv1alpha2.WorkspaceLabel]; workspace != "" {
+		workspaceRoleBindings := &iamv1alpha2.WorkspaceRoleBindingList{}
+		if err := r.List(ctx, workspaceRoleBindings, client.MatchingLabels{tenantv1alpha2.WorkspaceLabel: workspace}); err != nil {
+			r.Logger.Error(err, "list workspace role bindings failed")
+			return nil
+		}
+		for _, workspaceRoleBinding := range workspaceRoleBindings.Items {
+			names[workspaceRoleBinding.Name] = true
+		}
+	}
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := r.List(ctx, roleBindings, client.InNamespace(obj.GetName()), client.HasLabels{iamv1alpha2.WorkspaceRoleBindingLabel}); err != nil {
+		r.Logger.Error(err, "list role bindings failed")
+		return nil
+	}
+	for _, roleBinding := range roleBindings.Items {
+		names[roleBinding.Labels[iamv1alpha2.WorkspaceRoleBindingLabel]] = true
+	}
+
+	var requests []reconcile.Request
+	for name := range names {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+	return requests
+}
+
 // +kubebuilder:rbac:groups=iamaiscope,resources=workspacerolebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=tenantaiscope,resources=workspaces,verbs=get;list;watch;
+// +kubebuilder:rbac:groups=iam.aiscope,resources=workspaceroles,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.Logger.WithValues("workspacerolebinding", req.NamespacedName)
 	rootCtx := context.Background()
@@ -85,14 +153,248 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if !workspaceRoleBinding.DeletionTimestamp.IsZero() {
+		// propagated roles and role bindings are garbage collected through owner references
+		return ctrl.Result{}, nil
+	}
+
 	if err := r.bindWorkspace(rootCtx, logger, workspaceRoleBinding); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if err := r.syncNamespaceRoleBindings(rootCtx, logger, workspaceRoleBinding); err != nil {
+		r.Recorder.Event(workspaceRoleBinding, corev1.EventTypeWarning, controllerutils.FailedSynced, err.Error())
+		return ctrl.Result{}, err
+	}
+
 	r.Recorder.Event(workspaceRoleBinding, corev1.EventTypeNormal, controllerutils.SuccessSynced, controllerutils.MessageResourceSynced)
 	return ctrl.Result{}, nil
 }
 
+// syncNamespaceRoleBindings maintains a Role and a RoleBinding in every namespace of the workspace,
+// the Role mirrors the rules of the WorkspaceRole and the RoleBinding the subjects of the WorkspaceRoleBinding.
+// Both are owned by their workspace counterparts, those left in namespaces out of the workspace are removed.
+func (r *Reconciler) syncNamespaceRoleBindings(ctx context.Context, logger logr.Logger, workspaceRoleBinding *iamv1alpha2.WorkspaceRoleBinding) error {
+	workspaceName := workspaceRoleBinding.Labels[tenantv1alpha2.WorkspaceLabel]
+
+	namespaces := make(map[string]bool)
+	if workspaceName != "" {
+		namespaceList := &corev1.NamespaceList{}
+		if err := r.List(ctx, namespaceList, client.MatchingLabels{tenantv1alpha2.WorkspaceLabel: workspaceName}); err != nil {
+			logger.Error(err, "list namespaces failed")
+			return err
+		}
+		for _, namespace := range namespaceList.Items {
+			if namespace.DeletionTimestamp.IsZero() {
+				namespaces[namespace.Name] = true
+			}
+		}
+	}
+
+	workspaceRole := &iamv1alpha2.WorkspaceRole{}
+	if err := r.Get(ctx, types.NamespacedName{Name: workspaceRoleBinding.RoleRef.Name}, workspaceRole); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "get workspace role failed")
+			return err
+		}
+		logger.V(4).Info("workspace role not found", "workspacerole", workspaceRoleBinding.RoleRef.Name)
+		// nothing to propagate, only clean up
+		namespaces = map[string]bool{}
+	}
+
+	for namespace := range namespaces {
+		if err := r.syncRole(ctx, logger, namespace, workspaceRole); err != nil {
+			return err
+		}
+		if err := r.syncRoleBinding(ctx, logger, namespace, workspaceRoleBinding); err != nil {
+			return err
+		}
+	}
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := r.List(ctx, roleBindings, client.MatchingLabels{iamv1alpha2.WorkspaceRoleBindingLabel: workspaceRoleBinding.Name}); err != nil {
+		logger.Error(err, "list role bindings failed")
+		return err
+	}
+	for i := range roleBindings.Items {
+		roleBinding := &roleBindings.Items[i]
+		if namespaces[roleBinding.Namespace] {
+			continue
+		}
+		logger.V(4).Info("delete role binding out of the workspace", "namespace", roleBinding.Namespace, "rolebinding", roleBinding.Name)
+		if err := r.Delete(ctx, roleBinding); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "delete role binding failed")
+			return err
+		}
+		role := &rbacv1.Role{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: roleBinding.Namespace, Name: roleBinding.RoleRef.Name}, role); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			logger.Error(err, "get role failed")
+			return err
+		}
+		if _, ok := role.Labels[iamv1alpha2.WorkspaceRoleLabel]; !ok {
+			continue
+		}
+		// the role is shared by the bindings of the workspace role in the namespace
+		referenced, err := r.roleReferenced(ctx, role, roleBinding)
+		if err != nil {
+			logger.Error(err, "list role bindings failed", "namespace", role.Namespace)
+			return err
+		}
+		if !referenced {
+			if err := r.Delete(ctx, role); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "delete role failed")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// roleReferenced returns whether any role binding other than the deleted one still refers to the role
+func (r *Reconciler) roleReferenced(ctx context.Context, role *rbacv1.Role, deleted *rbacv1.RoleBinding) (bool, error) {
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := r.List(ctx, roleBindings, client.InNamespace(role.Namespace)); err != nil {
+		return false, err
+	}
+	for _, roleBinding := range roleBindings.Items {
+		if roleBinding.Name == deleted.Name || !roleBinding.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if roleBinding.RoleRef.Kind == iamv1alpha2.ResourceKindRole && roleBinding.RoleRef.Name == role.Name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *Reconciler) syncRole(ctx context.Context, logger logr.Logger, namespace string, workspaceRole *iamv1alpha2.WorkspaceRole) error {
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workspaceRole.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				tenantv1alpha2.WorkspaceLabel:  workspaceRole.Labels[tenantv1alpha2.WorkspaceLabel],
+				iamv1alpha2.WorkspaceRoleLabel: workspaceRole.Name,
+			},
+		},
+		Rules: workspaceRole.Rules,
+	}
+	if err := controllerutil.SetControllerReference(workspaceRole, role, r.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+
+	existing := &rbacv1.Role{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: role.Name}, existing); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "get role failed")
+			return err
+		}
+		logger.V(4).Info("create role", "namespace", namespace, "role", role.Name)
+		if err := r.Create(ctx, role); err != nil {
+			logger.Error(err, "create role failed")
+			return err
+		}
+		return nil
+	}
+
+	if !metav1.IsControlledBy(existing, workspaceRole) {
+		logger.Info("role not controlled by the workspace role, skip", "namespace", namespace, "role", role.Name)
+		return nil
+	}
+
+	if !reflect.DeepEqual(existing.Rules, role.Rules) || !reflect.DeepEqual(existing.Labels, role.Labels) {
+		existing.Rules = role.Rules
+		existing.Labels = role.Labels
+		logger.V(4).Info("update role", "namespace", namespace, "role", role.Name)
+		if err := r.Update(ctx, existing); err != nil {
+			logger.Error(err, "update role failed")
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) syncRoleBinding(ctx context.Context, logger logr.Logger, namespace string, workspaceRoleBinding *iamv1alpha2.WorkspaceRoleBinding) error {
+	subjects := make([]rbacv1.Subject, 0, len(workspaceRoleBinding.Subjects))
+	for _, subject := range workspaceRoleBinding.Subjects {
+		// kubernetes RBAC only accepts its own api group for users and groups
+		if subject.Kind == rbacv1.UserKind || subject.Kind == rbacv1.GroupKind {
+			subject.APIGroup = rbacv1.GroupName
+		}
+		subjects = append(subjects, subject)
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workspaceRoleBinding.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				tenantv1alpha2.WorkspaceLabel:         workspaceRoleBinding.Labels[tenantv1alpha2.WorkspaceLabel],
+				iamv1alpha2.WorkspaceRoleBindingLabel: workspaceRoleBinding.Name,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     iamv1alpha2.ResourceKindRole,
+			Name:     workspaceRoleBinding.RoleRef.Name,
+		},
+		Subjects: subjects,
+	}
+	if err := controllerutil.SetControllerReference(workspaceRoleBinding, roleBinding, r.Scheme); err != nil {
+		logger.Error(err, "set controller reference failed")
+		return err
+	}
+
+	existing := &rbacv1.RoleBinding{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: roleBinding.Name}, existing); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "get role binding failed")
+			return err
+		}
+		logger.V(4).Info("create role binding", "namespace", namespace, "rolebinding", roleBinding.Name)
+		if err := r.Create(ctx, roleBinding); err != nil {
+			logger.Error(err, "create role binding failed")
+			return err
+		}
+		return nil
+	}
+
+	if !metav1.IsControlledBy(existing, workspaceRoleBinding) {
+		logger.Info("role binding not controlled by the workspace role binding, skip", "namespace", namespace, "rolebinding", roleBinding.Name)
+		return nil
+	}
+
+	// role ref is immutable, recreate the role binding if it changed
+	if !reflect.DeepEqual(existing.RoleRef, roleBinding.RoleRef) {
+		logger.V(4).Info("recreate role binding", "namespace", namespace, "rolebinding", roleBinding.Name)
+		if err := r.Delete(ctx, existing); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "delete role binding failed")
+			return err
+		}
+		if err := r.Create(ctx, roleBinding); err != nil {
+			logger.Error(err, "create role binding failed")
+			return err
+		}
+		return nil
+	}
+
+	if !reflect.DeepEqual(existing.Subjects, roleBinding.Subjects) || !reflect.DeepEqual(existing.Labels, roleBinding.Labels) {
+		existing.Subjects = roleBinding.Subjects
+		existing.Labels = roleBinding.Labels
+		logger.V(4).Info("update role binding", "namespace", namespace, "rolebinding", roleBinding.Name)
+		if err := r.Update(ctx, existing); err != nil {
+			logger.Error(err, "update role binding failed")
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *Reconciler) bindWorkspace(ctx context.Context, logger logr.Logger, workspaceRoleBinding *iamv1alpha2.WorkspaceRoleBinding) error {
 	workspaceName := workspaceRoleBinding.Labels[constants.WorkspaceLabelKey]
 	if workspaceName == "" {
@@ -0,0 +1,68 @@
+package workspacerolebinding
+
+import (
+	"context"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
+)
+
+func TestSharedRoleDeletedWithLastRoleBinding(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := iamv1alpha2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	// the namespace dev has been removed from the workspace, the role is shared by the bindings of alice and bob
+	objects := []client.Object{
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{
+			Name: "ws-viewer", Namespace: "dev",
+			Labels: map[string]string{iamv1alpha2.WorkspaceRoleLabel: "ws-viewer"},
+		}},
+	}
+	workspaceRoleBindings := make(map[string]*iamv1alpha2.WorkspaceRoleBinding)
+	for _, username := range []string{"alice", "bob"} {
+		name := username + "-ws-viewer"
+		workspaceRoleBindings[username] = &iamv1alpha2.WorkspaceRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{tenantv1alpha2.WorkspaceLabel: "ws"}},
+			RoleRef:    rbacv1.RoleRef{APIGroup: iamv1alpha2.SchemeGroupVersion.Group, Kind: iamv1alpha2.ResourceKindWorkspaceRole, Name: "ws-viewer"},
+		}
+		objects = append(objects, &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "dev",
+				Labels: map[string]string{iamv1alpha2.WorkspaceRoleBindingLabel: name},
+			},
+			RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: iamv1alpha2.ResourceKindRole, Name: "ws-viewer"},
+		})
+	}
+	r := &Reconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(), Scheme: scheme}
+	role := types.NamespacedName{Namespace: "dev", Name: "ws-viewer"}
+
+	if err := r.syncNamespaceRoleBindings(context.Background(), log.Log, workspaceRoleBindings["alice"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(context.Background(), role, &rbacv1.Role{}); err != nil {
+		t.Fatalf("the role still referred by the binding of bob should be kept: %v", err)
+	}
+
+	if err := r.syncNamespaceRoleBindings(context.Background(), log.Log, workspaceRoleBindings["bob"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(context.Background(), role, &rbacv1.Role{}); !errors.IsNotFound(err) {
+		t.Errorf("the role should be deleted with the last binding: %v", err)
+	}
+}
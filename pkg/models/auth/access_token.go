@@ -0,0 +1,282 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authentication/token"
+	"aiscope/pkg/apiserver/authorization/scope"
+	"aiscope/pkg/simple/client/cache"
+)
+
+const (
+	// the last used time is updated at most once per interval
+	accessTokenLastUsedInterval = time.Minute
+)
+
+var accessTokenResource = iamv1alpha2.Resource("accesstokens")
+
+// AccessToken is a personal access token of the user, only the hash of the token is stored.
+type AccessToken struct {
+	// Name is unique for the user
+	Name string `json:"name"`
+	// Scopes restrict the permissions of the token, default to user:full
+	Scopes []string `json:"scopes,omitempty"`
+	// The token never expires if not specified
+	ExpirationTimestamp *metav1.Time `json:"expirationTimestamp,omitempty"`
+	CreationTimestamp   metav1.Time  `json:"creationTimestamp,omitempty"`
+	LastUsedTimestamp   *metav1.Time `json:"lastUsedTimestamp,omitempty"`
+	// Token is returned only once when the token is created
+	Token string `json:"token,omitempty"`
+}
+
+type accessTokenRecord struct {
+	AccessToken
+	Hash string `json:"hash"`
+}
+
+// AccessTokenOperator manages the personal access tokens, which are not revoked by logout or by RevokeAllUserTokens,
+// the access tokens have to be revoked explicitly when the credentials of the user are reset.
+type AccessTokenOperator interface {
+	ListAccessTokens(username string) ([]AccessToken, error)
+	CreateAccessToken(username string, accessToken *AccessToken) (*AccessToken, error)
+	RevokeAccessToken(username string, name string) error
+	RevokeAllAccessTokens(username string) error
+}
+
+type accessTokenOperator struct {
+	issuer token.Issuer
+	cache  cache.Interface
+}
+
+func NewAccessTokenOperator(cache cache.Interface, issuer token.Issuer) AccessTokenOperator {
+	return &accessTokenOperator{
+		issuer: issuer,
+		cache:  cache,
+	}
+}
+
+func (o *accessTokenOperator) ListAccessTokens(username string) ([]AccessToken, error) {
+	keys, err := o.cache.Keys(accessTokenKey(username, "*"))
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	result := make([]AccessToken, 0, len(keys))
+	for _, key := range keys {
+		record, err := getAccessTokenRecord(o.cache, key)
+		if err != nil {
+			return nil, err
+		}
+		// revoked or expired
+		if record == nil {
+			continue
+		}
+		result = append(result, record.AccessToken)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreationTimestamp.After(result[j].CreationTimestamp.Time)
+	})
+	return result, nil
+}
+
+func (o *accessTokenOperator) CreateAccessToken(username string, accessToken *AccessToken) (*AccessToken, error) {
+	if errs := validation.IsDNS1123Label(accessToken.Name); len(errs) > 0 {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid name %s: %v", accessToken.Name, errs))
+	}
+	if len(accessToken.Scopes) == 0 {
+		accessToken.Scopes = []string{scope.Full}
+	}
+	for _, s := range accessToken.Scopes {
+		if !scope.IsValid(s) {
+			return nil, errors.NewBadRequest(fmt.Sprintf("unknown scope %s", s))
+		}
+	}
+
+	now := time.Now()
+	var expiresIn time.Duration
+	if accessToken.ExpirationTimestamp != nil {
+		expiresIn = accessToken.ExpirationTimestamp.Sub(now)
+		if expiresIn <= 0 {
+			return nil, errors.NewBadRequest("expiration timestamp must be in the future")
+		}
+	}
+
+	key := accessTokenKey(username, accessToken.Name)
+	exist, err := o.cache.Exists(key)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	if exist {
+		return nil, errors.NewAlreadyExists(accessTokenResource, accessToken.Name)
+	}
+
+	tokenStr, err := o.issuer.IssueTo(&token.IssueRequest{
+		User: &user.DefaultInfo{Name: username},
+		Claims: token.Claims{
+			StandardClaims: jwt.StandardClaims{
+				Id: accessToken.Name,
+			},
+			TokenType: token.StaticToken,
+			Scopes:    accessToken.Scopes,
+		},
+		ExpiresIn: expiresIn,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	record := &accessTokenRecord{
+		AccessToken: AccessToken{
+			Name:                accessToken.Name,
+			Scopes:              accessToken.Scopes,
+			ExpirationTimestamp: accessToken.ExpirationTimestamp,
+			CreationTimestamp:   metav1.NewTime(now),
+		},
+		Hash: hashToken(tokenStr),
+	}
+	if err = setAccessTokenRecord(o.cache, key, record); err != nil {
+		return nil, err
+	}
+
+	created := record.AccessToken
+	created.Token = tokenStr
+	return &created, nil
+}
+
+func (o *accessTokenOperator) RevokeAccessToken(username string, name string) error {
+	key := accessTokenKey(username, name)
+	exist, err := o.cache.Exists(key)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if !exist {
+		return errors.NewNotFound(accessTokenResource, name)
+	}
+	if err = o.cache.Del(key); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (o *accessTokenOperator) RevokeAllAccessTokens(username string) error {
+	keys, err := o.cache.Keys(accessTokenKey(username, "*"))
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if err = o.cache.Del(keys...); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// accessTokenValidate verifies that the personal access token has not been revoked, and records the last used time.
+func accessTokenValidate(c cache.Interface, username, name, tokenStr string) error {
+	key := accessTokenKey(username, name)
+	record, err := getAccessTokenRecord(c, key)
+	if err != nil {
+		return err
+	}
+	if record == nil || subtle.ConstantTimeCompare([]byte(record.Hash), []byte(hashToken(tokenStr))) != 1 {
+		err = fmt.Errorf("access token %s of user %s has been revoked", name, username)
+		klog.V(4).Info(err)
+		return err
+	}
+	now := time.Now()
+	if record.LastUsedTimestamp == nil || now.Sub(record.LastUsedTimestamp.Time) > accessTokenLastUsedInterval {
+		record.LastUsedTimestamp = &metav1.Time{Time: now}
+		if err = setAccessTokenRecord(c, key, record); err != nil {
+			klog.Warningf("failed to update the last used time of access token %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// accessTokenRevoke revokes the personal access token only if it is still stored under the name,
+// the token recreated with the same name is kept.
+func accessTokenRevoke(c cache.Interface, username, name, tokenStr string) error {
+	key := accessTokenKey(username, name)
+	record, err := getAccessTokenRecord(c, key)
+	if err != nil {
+		return err
+	}
+	if record == nil || subtle.ConstantTimeCompare([]byte(record.Hash), []byte(hashToken(tokenStr))) != 1 {
+		return nil
+	}
+	if err = c.Del(key); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// getAccessTokenRecord returns nil if the record does not exist
+func getAccessTokenRecord(c cache.Interface, key string) (*accessTokenRecord, error) {
+	exist, err := c.Exists(key)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	if !exist {
+		return nil, nil
+	}
+	data, err := c.Get(key)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	var record accessTokenRecord
+	if err = json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func setAccessTokenRecord(c cache.Interface, key string, record *accessTokenRecord) error {
+	expiresIn := cache.NeverExpire
+	if record.ExpirationTimestamp != nil {
+		expiresIn = time.Until(record.ExpirationTimestamp.Time)
+		if expiresIn <= 0 {
+			return c.Del(key)
+		}
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err = c.Set(key, string(data), expiresIn); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func accessTokenKey(username, name string) string {
+	return fmt.Sprintf("aiscope:user:%s:accesstoken:%s", username, name)
+}
+
+func hashToken(tokenStr string) string {
+	hash := sha256.Sum256([]byte(tokenStr))
+	return hex.EncodeToString(hash[:])
+}
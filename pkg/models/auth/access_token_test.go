@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"aiscope/pkg/apiserver/authentication"
+	"aiscope/pkg/apiserver/authentication/token"
+	"aiscope/pkg/apiserver/authorization/scope"
+	"aiscope/pkg/simple/client/cache"
+)
+
+func TestAccessTokenOperator(t *testing.T) {
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simpleCache := cache.NewSimpleCache()
+	operator := NewAccessTokenOperator(simpleCache, issuer)
+	tokenOperator := NewTokenOperator(simpleCache, issuer, options)
+
+	expiration := metav1.NewTime(time.Now().Add(time.Hour))
+	created, err := operator.CreateAccessToken("admin", &AccessToken{Name: "pipeline", Scopes: []string{scope.ReadOnly}, ExpirationTimestamp: &expiration})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Token == "" {
+		t.Fatalf("the token should be returned when created")
+	}
+
+	verified, err := tokenOperator.Verify(created.Token)
+	if err != nil {
+		t.Fatalf("failed to verify access token: %v", err)
+	}
+	if verified.User.GetName() != "admin" || len(verified.Scopes) != 1 || verified.Scopes[0] != scope.ReadOnly {
+		t.Errorf("unexpected verified response: %+v", verified)
+	}
+
+	if _, err = operator.CreateAccessToken("admin", &AccessToken{Name: "pipeline"}); err == nil {
+		t.Errorf("the name of the access token should be unique")
+	}
+	if _, err = operator.CreateAccessToken("admin", &AccessToken{Name: "unknown", Scopes: []string{"user:admin"}}); err == nil {
+		t.Errorf("unknown scopes should be rejected")
+	}
+
+	tokens, err := operator.ListAccessTokens("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].Token != "" || tokens[0].LastUsedTimestamp == nil {
+		t.Errorf("unexpected access tokens: %+v", tokens)
+	}
+
+	if err = operator.RevokeAccessToken("admin", "pipeline"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tokenOperator.Verify(created.Token); err == nil {
+		t.Errorf("revoked access token should not be verified")
+	}
+}
@@ -3,7 +3,10 @@ package auth
 import (
 	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
 	"aiscope/pkg/apiserver/authentication/identityprovider"
+	"aiscope/pkg/apiserver/authentication/oauth"
 	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/models/iam/group"
+	"aiscope/pkg/utils/sliceutil"
 	"context"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -56,6 +59,28 @@ func mappedUser(idp string, identity identityprovider.Identity) *iamv1alpha2.Use
 	}
 }
 
+// mappedUserInfo returns the info of the user mapped to the identity. If the identity provider knows the groups,
+// the group memberships of the user are refreshed and the mirrored groups are included.
+// Failures of the refresh are logged only, the memberships are corrected by the next sync.
+func mappedUserInfo(syncer group.Syncer, provider *oauth.IdentityProviderOptions, user *iamv1alpha2.User, identity identityprovider.Identity) authuser.Info {
+	info := &authuser.DefaultInfo{Name: user.GetName(), Groups: append([]string{}, user.Spec.Groups...)}
+	groupIdentity, ok := identity.(identityprovider.GroupIdentity)
+	if !ok {
+		return info
+	}
+	groups, err := syncer.SyncUserGroups(provider, user.GetName(), groupIdentity.GetGroups())
+	if err != nil {
+		klog.Errorf("failed to refresh groups of user %s from identity provider %s: %v", user.GetName(), provider.Name, err)
+		return info
+	}
+	for _, group := range groups {
+		if !sliceutil.HasString(info.Groups, group) {
+			info.Groups = append(info.Groups, group)
+		}
+	}
+	return info
+}
+
 // findUser returns the user associated with the username or email
 func (u *userGetter) findUser(username string) (*iamv1alpha2.User, error) {
 	if _, err := mail.ParseAddress(username); err != nil {
@@ -81,7 +106,6 @@ func (u *userGetter) findUser(username string) (*iamv1alpha2.User, error) {
 func (u *userGetter) findMappedUser(idp, uid string) (*iamv1alpha2.User, error) {
 	selector := labels.SelectorFromSet(labels.Set{
 		iamv1alpha2.IdentifyProviderLabel: idp,
-		// iamv1alpha2.OriginUIDLabel:        uid,
 	})
 
 	users, err := u.userLister.List(selector)
@@ -89,9 +113,11 @@ func (u *userGetter) findMappedUser(idp, uid string) (*iamv1alpha2.User, error)
 		klog.Error(err)
 		return nil, err
 	}
-	if len(users) != 1 {
-		return nil, errors.NewNotFound(iamv1alpha2.Resource("user"), uid)
+	for _, user := range users {
+		if user.Labels[iamv1alpha2.OriginUIDLabel] == uid {
+			return user, nil
+		}
 	}
 
-	return users[0], err
+	return nil, errors.NewNotFound(iamv1alpha2.Resource("user"), uid)
 }
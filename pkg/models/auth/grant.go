@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"aiscope/pkg/simple/client/cache"
+	"aiscope/pkg/utils/sliceutil"
+)
+
+// GrantOperator remembers the scopes the users granted to the OAuth clients,
+// the users are prompted only once for the clients with the prompt grant method.
+type GrantOperator interface {
+	// Granted returns whether the user has granted all the scopes to the client
+	Granted(username, clientID string, scopes []string) (bool, error)
+	// Grant records the scopes granted to the client by the user
+	Grant(username, clientID string, scopes []string) error
+}
+
+type grantOperator struct {
+	cache cache.Interface
+}
+
+func NewGrantOperator(cache cache.Interface) GrantOperator {
+	return &grantOperator{
+		cache: cache,
+	}
+}
+
+func (g *grantOperator) Granted(username, clientID string, scopes []string) (bool, error) {
+	granted, found, err := g.grantedScopes(username, clientID)
+	if err != nil || !found {
+		return false, err
+	}
+	for _, scope := range scopes {
+		if !sliceutil.HasString(granted, scope) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (g *grantOperator) Grant(username, clientID string, scopes []string) error {
+	granted, _, err := g.grantedScopes(username, clientID)
+	if err != nil {
+		return err
+	}
+	for _, scope := range scopes {
+		if !sliceutil.HasString(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	if err := g.cache.Set(grantKey(username, clientID), strings.Join(granted, " "), cache.NeverExpire); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// grantedScopes returns the scopes granted to the client, found is false if the user has never authorized the client
+func (g *grantOperator) grantedScopes(username, clientID string) (scopes []string, found bool, err error) {
+	key := grantKey(username, clientID)
+	exist, err := g.cache.Exists(key)
+	if err != nil {
+		klog.Error(err)
+		return nil, false, err
+	}
+	if !exist {
+		return nil, false, nil
+	}
+	value, err := g.cache.Get(key)
+	if err != nil {
+		klog.Error(err)
+		return nil, false, err
+	}
+	return strings.Fields(value), true, nil
+}
+
+func grantKey(username, clientID string) string {
+	return fmt.Sprintf("aiscope:user:%s:grant:%s", username, clientID)
+}
@@ -0,0 +1,363 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authorization/scope"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/constants"
+	"aiscope/pkg/models/iam/am"
+	"aiscope/pkg/models/iam/group"
+	"aiscope/pkg/simple/client/cache"
+	"aiscope/pkg/utils/otputil"
+)
+
+const (
+	SecretTypeMFA       corev1.SecretType = "aiscope.io/mfa"
+	mfaSecretNameFormat                   = "mfa-%s"
+	mfaSecretKey                          = "secret"
+	mfaEnabledKey                         = "enabled"
+	mfaRecoveryCodesKey                   = "recovery-codes"
+	mfaIssuer                             = "AIScope"
+	recoveryCodeCount                     = 10
+	// the time steps of the used codes are cached until the codes expire, a code MUST NOT be used more than once
+	usedCodeKeyFormat = "aiscope:user:%s:mfa:%d"
+	usedCodeMaxAge    = 3 * otputil.Period
+)
+
+var (
+	MFARequiredError      = fmt.Errorf("two-factor authentication required")
+	IncorrectMFACodeError = fmt.Errorf("incorrect two-factor authentication code")
+)
+
+// MFAStatus is the two-factor authentication state of the user
+type MFAStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required is true if any global role of the user requires the two-factor authentication
+	Required bool `json:"required"`
+	// RecoveryCodes is the number of the unused recovery codes
+	RecoveryCodes int `json:"recoveryCodes"`
+}
+
+// MFAEnrollment is the TOTP secret to import into the authenticator app,
+// the secret takes effect once it is activated with a code generated by the app.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	// URL is the otpauth key URI, usually shown as a QR code
+	URL string `json:"url"`
+}
+
+// MFAOperator manages the TOTP two-factor authentication of the aiscope accounts,
+// the secret and the hashed recovery codes are kept in a secret owned by the user.
+type MFAOperator interface {
+	// Status returns the two-factor authentication state of the user
+	Status(username string) (*MFAStatus, error)
+	// Enroll generates a new TOTP secret, the pending secret is replaced
+	Enroll(username string) (*MFAEnrollment, error)
+	// Activate enables the two-factor authentication with the code of the enrolled secret,
+	// the recovery codes are returned only once.
+	Activate(username string, code string) ([]string, error)
+	// Disable removes the secret and the recovery codes
+	Disable(username string) error
+	// Verify verifies the TOTP code or the recovery code, both can only be used once
+	Verify(username string, code string) error
+	// Challenge returns whether the second factor is required before the tokens are issued to the user authenticated
+	// with the password. The users required to use it by their global roles are restricted to enroll it.
+	Challenge(user authuser.Info) (authuser.Info, bool, error)
+}
+
+type mfaOperator struct {
+	k8sClient   kubernetes.Interface
+	cache       cache.Interface
+	userLister  iamv1alpha2listers.UserLister
+	groupLister iamv1alpha2listers.GroupLister
+	am          am.AccessManagementInterface
+}
+
+func NewMFAOperator(k8sClient kubernetes.Interface, cacheClient cache.Interface,
+	userLister iamv1alpha2listers.UserLister, groupLister iamv1alpha2listers.GroupLister,
+	am am.AccessManagementInterface) MFAOperator {
+	return &mfaOperator{
+		k8sClient:   k8sClient,
+		cache:       cacheClient,
+		userLister:  userLister,
+		groupLister: groupLister,
+		am:          am,
+	}
+}
+
+func (o *mfaOperator) Status(username string) (*MFAStatus, error) {
+	required, err := o.required(username)
+	if err != nil {
+		return nil, err
+	}
+	status := &MFAStatus{Required: required}
+	secret, err := o.getSecret(username)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return status, nil
+		}
+		return nil, err
+	}
+	status.Enabled = isMFAEnabled(secret)
+	if status.Enabled {
+		recoveryCodes, err := recoveryCodesOf(secret)
+		if err != nil {
+			return nil, err
+		}
+		status.RecoveryCodes = len(recoveryCodes)
+	}
+	return status, nil
+}
+
+func (o *mfaOperator) Enroll(username string) (*MFAEnrollment, error) {
+	user, err := o.userLister.Get(username)
+	if err != nil {
+		return nil, err
+	}
+	key, err := otputil.GenerateSecret()
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	secret, err := o.getSecret(username)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf(mfaSecretNameFormat, username),
+				Namespace: constants.AIScopeControlNamespace,
+				Labels:    map[string]string{constants.UsernameLabelKey: username},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: iamv1alpha2.SchemeGroupVersion.String(),
+					Kind:       iamv1alpha2.ResourceKindUser,
+					Name:       user.Name,
+					UID:        user.UID,
+				}},
+			},
+			Type: SecretTypeMFA,
+			Data: map[string][]byte{mfaSecretKey: []byte(key)},
+		}
+		if _, err = o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Create(context.Background(), secret, metav1.CreateOptions{}); err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+	} else {
+		// the enabled secret can not be replaced silently, it has to be disabled first
+		if isMFAEnabled(secret) {
+			return nil, errors.NewConflict(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), username,
+				fmt.Errorf("two-factor authentication is already enabled"))
+		}
+		secret = secret.DeepCopy()
+		secret.Data = map[string][]byte{mfaSecretKey: []byte(key)}
+		if _, err = o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+	}
+
+	return &MFAEnrollment{Secret: key, URL: otputil.URL(mfaIssuer, username, key)}, nil
+}
+
+func (o *mfaOperator) Activate(username string, code string) ([]string, error) {
+	secret, err := o.getSecret(username)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewBadRequest("two-factor authentication is not enrolled")
+		}
+		return nil, err
+	}
+	if isMFAEnabled(secret) {
+		return nil, errors.NewConflict(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), username,
+			fmt.Errorf("two-factor authentication is already enabled"))
+	}
+	if err = o.verifyCode(username, string(secret.Data[mfaSecretKey]), code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range recoveryCodes {
+		if recoveryCodes[i], err = generateRecoveryCode(); err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(recoveryCodes[i]))
+	}
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data[mfaEnabledKey] = []byte("true")
+	secret.Data[mfaRecoveryCodesKey] = data
+	if _, err = o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+func (o *mfaOperator) Disable(username string) error {
+	err := o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Delete(context.Background(), fmt.Sprintf(mfaSecretNameFormat, username), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (o *mfaOperator) Verify(username string, code string) error {
+	secret, err := o.getSecret(username)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewBadRequest("two-factor authentication is not enabled")
+		}
+		return err
+	}
+	if !isMFAEnabled(secret) {
+		return errors.NewBadRequest("two-factor authentication is not enabled")
+	}
+	if len(code) == otputil.Digits {
+		return o.verifyCode(username, string(secret.Data[mfaSecretKey]), code)
+	}
+
+	recoveryCodes, err := recoveryCodesOf(secret)
+	if err != nil {
+		return err
+	}
+	hash := hashToken(normalizeRecoveryCode(code))
+	for i, recoveryCode := range recoveryCodes {
+		if recoveryCode != hash {
+			continue
+		}
+		data, err := json.Marshal(append(recoveryCodes[:i:i], recoveryCodes[i+1:]...))
+		if err != nil {
+			return err
+		}
+		// the update conflicts if the recovery code is used concurrently
+		secret = secret.DeepCopy()
+		secret.Data[mfaRecoveryCodesKey] = data
+		if _, err = o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+			klog.Error(err)
+			return err
+		}
+		return nil
+	}
+	return IncorrectMFACodeError
+}
+
+func (o *mfaOperator) Challenge(user authuser.Info) (authuser.Info, bool, error) {
+	if user.GetName() == iamv1alpha2.PreRegistrationUser {
+		return user, false, nil
+	}
+	status, err := o.Status(user.GetName())
+	if err != nil {
+		return nil, false, err
+	}
+	if status.Enabled {
+		return user, true, nil
+	}
+	if !status.Required {
+		return user, false, nil
+	}
+	extra := make(map[string][]string)
+	for key, value := range user.GetExtra() {
+		extra[key] = value
+	}
+	extra[iamv1alpha2.ExtraScopes] = append(append([]string{}, extra[iamv1alpha2.ExtraScopes]...), scope.EnrollMFA)
+	return &authuser.DefaultInfo{
+		Name:   user.GetName(),
+		UID:    user.GetUID(),
+		Groups: user.GetGroups(),
+		Extra:  extra,
+	}, false, nil
+}
+
+// required returns whether any global role of the user requires the two-factor authentication
+func (o *mfaOperator) required(username string) (bool, error) {
+	user, err := o.userLister.Get(username)
+	if err != nil {
+		return false, err
+	}
+	globalRoles, err := o.am.GetGlobalRoleOfUser(username, group.ResolveGroups(o.groupLister, user.Spec.Groups))
+	if err != nil {
+		return false, err
+	}
+	for _, globalRole := range globalRoles {
+		if globalRole.Annotations[iamv1alpha2.MFARequiredAnnotation] == "true" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifyCode validates the TOTP code, the time step of the code is recorded to prevent replay
+func (o *mfaOperator) verifyCode(username string, key string, code string) error {
+	step, ok := otputil.Validate(key, code, time.Now())
+	if !ok {
+		return IncorrectMFACodeError
+	}
+	usedCodeKey := fmt.Sprintf(usedCodeKeyFormat, username, step)
+	if used, err := o.cache.Exists(usedCodeKey); err != nil {
+		return err
+	} else if used {
+		return IncorrectMFACodeError
+	}
+	if err := o.cache.Set(usedCodeKey, code, usedCodeMaxAge); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (o *mfaOperator) getSecret(username string) (*corev1.Secret, error) {
+	return o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Get(context.Background(), fmt.Sprintf(mfaSecretNameFormat, username), metav1.GetOptions{})
+}
+
+func isMFAEnabled(secret *corev1.Secret) bool {
+	return string(secret.Data[mfaEnabledKey]) == "true"
+}
+
+func recoveryCodesOf(secret *corev1.Secret) ([]string, error) {
+	var recoveryCodes []string
+	if data := secret.Data[mfaRecoveryCodesKey]; len(data) > 0 {
+		if err := json.Unmarshal(data, &recoveryCodes); err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+	}
+	return recoveryCodes, nil
+}
+
+// generateRecoveryCode returns a random code formatted as xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := hex.EncodeToString(b)
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	k8scache "k8s.io/client-go/tools/cache"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authorization/scope"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/models/iam/am"
+	"aiscope/pkg/simple/client/cache"
+	"aiscope/pkg/utils/otputil"
+)
+
+// globalRoles binds the global roles to the users, the other operations are not needed
+type globalRoles struct {
+	am.AccessManagementInterface
+	roles map[string]*iamv1alpha2.GlobalRole
+}
+
+func (g *globalRoles) GetGlobalRoleOfUser(username string, _ []string) ([]*iamv1alpha2.GlobalRole, error) {
+	if role, ok := g.roles[username]; ok {
+		return []*iamv1alpha2.GlobalRole{role}, nil
+	}
+	return nil, nil
+}
+
+func TestMFAOperator(t *testing.T) {
+	indexer := k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{})
+	for _, name := range []string{"admin", "guest"} {
+		if err := indexer.Add(&iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: name}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	platformAdmin := &iamv1alpha2.GlobalRole{ObjectMeta: metav1.ObjectMeta{
+		Name:        "platform-admin",
+		Annotations: map[string]string{iamv1alpha2.MFARequiredAnnotation: "true"},
+	}}
+	operator := NewMFAOperator(fakek8s.NewSimpleClientset(), cache.NewSimpleCache(),
+		iamv1alpha2listers.NewUserLister(indexer),
+		iamv1alpha2listers.NewGroupLister(k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{})),
+		&globalRoles{roles: map[string]*iamv1alpha2.GlobalRole{"admin": platformAdmin}})
+
+	admin := &user.DefaultInfo{Name: "admin"}
+	restricted, challenged, err := operator.Challenge(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenged || len(restricted.GetExtra()[iamv1alpha2.ExtraScopes]) != 1 || restricted.GetExtra()[iamv1alpha2.ExtraScopes][0] != scope.EnrollMFA {
+		t.Errorf("the user required to use MFA should be restricted to enroll it, got %+v", restricted)
+	}
+	guest := &user.DefaultInfo{Name: "guest"}
+	if unrestricted, challenged, err := operator.Challenge(guest); err != nil || challenged || unrestricted != guest {
+		t.Errorf("the user not required to use MFA should not be restricted, got %+v, %v", unrestricted, err)
+	}
+
+	enrollment, err := operator.Enroll("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enrollment.URL, "otpauth://totp/") {
+		t.Errorf("unexpected key URI %s", enrollment.URL)
+	}
+	if _, err = operator.Activate("admin", "000000x"); err != IncorrectMFACodeError {
+		t.Errorf("expected %v, got %v", IncorrectMFACodeError, err)
+	}
+	step := otputil.TimeStep(time.Now())
+	code, _ := otputil.GenerateCode(enrollment.Secret, step)
+	recoveryCodes, err := operator.Activate("admin", code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Errorf("expected %d recovery codes, got %d", recoveryCodeCount, len(recoveryCodes))
+	}
+	if _, err = operator.Enroll("admin"); !errors.IsConflict(err) {
+		t.Errorf("the enabled secret should not be replaced, got %v", err)
+	}
+	if _, challenged, err = operator.Challenge(admin); err != nil || !challenged {
+		t.Errorf("the user with MFA enabled should be challenged, got %v", err)
+	}
+
+	if err = operator.Verify("admin", code); err != IncorrectMFACodeError {
+		t.Errorf("the used code should be rejected, got %v", err)
+	}
+	previous, _ := otputil.GenerateCode(enrollment.Secret, step-1)
+	if err = operator.Verify("admin", previous); err != nil {
+		t.Errorf("the code of the previous time step should be accepted, got %v", err)
+	}
+	if err = operator.Verify("admin", strings.ToUpper(recoveryCodes[0])); err != nil {
+		t.Errorf("the recovery code should be accepted, got %v", err)
+	}
+	if err = operator.Verify("admin", recoveryCodes[0]); err != IncorrectMFACodeError {
+		t.Errorf("the used recovery code should be rejected, got %v", err)
+	}
+	status, err := operator.Status("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Enabled || !status.Required || status.RecoveryCodes != recoveryCodeCount-1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	if err = operator.Disable("admin"); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = operator.Status("admin"); err != nil || status.Enabled {
+		t.Errorf("MFA should be disabled, got %+v, %v", status, err)
+	}
+}
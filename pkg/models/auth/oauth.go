@@ -7,6 +7,7 @@ import (
 	"aiscope/pkg/apiserver/authentication/oauth"
 	aiscope "aiscope/pkg/client/clientset/versioned"
 	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/models/iam/group"
 	"context"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,18 +17,20 @@ import (
 )
 
 type oauthAuthenticator struct {
-	aiClient   aiscope.Interface
-	userGetter *userGetter
-	options    *authentication.Options
+	aiClient    aiscope.Interface
+	userGetter  *userGetter
+	groupSyncer group.Syncer
+	options     *authentication.Options
 }
 
 func NewOAuthAuthenticator(aiClient aiscope.Interface,
 	userLister iamv1alpha2listers.UserLister,
 	options *authentication.Options) OAuthAuthenticator {
 	authenticator := &oauthAuthenticator{
-		aiClient:   aiClient,
-		userGetter: &userGetter{userLister: userLister},
-		options:    options,
+		aiClient:    aiClient,
+		userGetter:  &userGetter{userLister: userLister},
+		groupSyncer: group.NewSyncer(aiClient),
+		options:     options,
 	}
 	return authenticator
 }
@@ -68,7 +71,7 @@ func (o *oauthAuthenticator) Authenticate(_ context.Context, provider string, re
 	}
 
 	if user != nil {
-		return &authuser.DefaultInfo{Name: user.GetName()}, providerOptions.Name, nil
+		return mappedUserInfo(o.groupSyncer, providerOptions, user, authenticated), providerOptions.Name, nil
 	}
 
 	return nil, "", errors.NewNotFound(iamv1alpha2.Resource("user"), authenticated.GetUsername())
@@ -1,9 +1,12 @@
 package auth
 
 import (
+	"aiscope/pkg/apiserver/request"
 	aiscope "aiscope/pkg/client/clientset/versioned"
+	"aiscope/pkg/simple/client/cache"
 	"context"
 	"fmt"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -17,8 +20,10 @@ import (
 
 	"aiscope/pkg/apiserver/authentication/identityprovider"
 	"aiscope/pkg/apiserver/authentication/oauth"
+	"aiscope/pkg/apiserver/authorization/scope"
 	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
 	"aiscope/pkg/constants"
+	"aiscope/pkg/models/iam/group"
 )
 
 var (
@@ -30,21 +35,55 @@ var (
 type passwordAuthenticator struct {
 	aiClient    aiscope.Interface
 	userGetter  *userGetter
+	groupSyncer group.Syncer
+	cache       cache.Interface
 	authOptions *authentication.Options
 }
 
 func NewPasswordAuthenticator(aiClient aiscope.Interface,
 	userLister iamv1alpha2listers.UserLister,
+	cacheClient cache.Interface,
 	options *authentication.Options) PasswordAuthenticator {
 	passwordAuthenticator := &passwordAuthenticator{
 		aiClient:    aiClient,
 		userGetter:  &userGetter{userLister: userLister},
+		groupSyncer: group.NewSyncer(aiClient),
+		cache:       cacheClient,
 		authOptions: options,
 	}
 	return passwordAuthenticator
 }
 
-func (p *passwordAuthenticator) Authenticate(_ context.Context, username, password string) (authuser.Info, string, error) {
+// Authenticate throttles the failed attempts per source IP, the users are blocked by the login record controller,
+// but the attempts against unknown usernames leave no login records.
+func (p *passwordAuthenticator) Authenticate(ctx context.Context, username, password string) (authuser.Info, string, error) {
+	sourceIP := ""
+	if requestInfo, ok := request.RequestInfoFrom(ctx); ok {
+		sourceIP = requestInfo.SourceIP
+	}
+	if sourceIP != "" && p.authOptions.AuthenticateRateLimiterMaxTries > 0 {
+		failedAttempts, err := p.cache.Keys(fmt.Sprintf("aiscope:ip:%s:loginfailure:*", sourceIP))
+		if err != nil {
+			klog.Error(err)
+			return nil, "", err
+		}
+		if len(failedAttempts) >= p.authOptions.AuthenticateRateLimiterMaxTries {
+			klog.Errorf("%s, source ip: %s", RateLimitExceededError, sourceIP)
+			return nil, "", RateLimitExceededError
+		}
+	}
+
+	authenticated, provider, err := p.authenticate(username, password)
+	if err == IncorrectPasswordError && sourceIP != "" && p.authOptions.AuthenticateRateLimiterMaxTries > 0 {
+		failedAttemptKey := fmt.Sprintf("aiscope:ip:%s:loginfailure:%d", sourceIP, time.Now().UnixNano())
+		if err := p.cache.Set(failedAttemptKey, username, p.authOptions.AuthenticateRateLimiterDuration); err != nil {
+			klog.Error(err)
+		}
+	}
+	return authenticated, provider, err
+}
+
+func (p *passwordAuthenticator) authenticate(username, password string) (authuser.Info, string, error) {
 	// empty username or password are not allowed
 	if username == "" || password == "" {
 		return nil, "", IncorrectPasswordError
@@ -84,7 +123,7 @@ func (p *passwordAuthenticator) Authenticate(_ context.Context, username, passwo
 				}
 			}
 			if linkedAccount != nil {
-				return &authuser.DefaultInfo{Name: linkedAccount.GetName()}, providerOptions.Name, nil
+				return mappedUserInfo(p.groupSyncer, &providerOptions, linkedAccount, authenticated), providerOptions.Name, nil
 			}
 		}
 	}
@@ -121,10 +160,16 @@ func (p *passwordAuthenticator) Authenticate(_ context.Context, username, passwo
 			Name:   user.Name,
 			Groups: user.Spec.Groups,
 		}
-		// check if the password is initialized
+		// the tokens are restricted to change the password until the password is initialized or renewed
 		if uninitialized := user.Annotations[iamv1alpha2.UninitializedAnnotation]; uninitialized != "" {
 			u.Extra = map[string][]string{
 				iamv1alpha2.ExtraUninitialized: {uninitialized},
+				iamv1alpha2.ExtraScopes:        {scope.ChangePassword},
+			}
+		} else if p.passwordExpired(user) {
+			u.Extra = map[string][]string{
+				iamv1alpha2.ExtraPasswordExpired: {"true"},
+				iamv1alpha2.ExtraScopes:          {scope.ChangePassword},
 			}
 		}
 		return u, "", nil
@@ -133,6 +178,18 @@ func (p *passwordAuthenticator) Authenticate(_ context.Context, username, passwo
 	return nil, "", IncorrectPasswordError
 }
 
+// passwordExpired returns whether the password exceeds the max age of the password policy,
+// the creation time of the user is used if the password has never been changed.
+func (p *passwordAuthenticator) passwordExpired(user *iamv1alpha2.User) bool {
+	lastChangeTime := user.CreationTimestamp.Time
+	if value := user.Annotations[iamv1alpha2.LastPasswordChangeTimeAnnotation]; value != "" {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			lastChangeTime = t
+		}
+	}
+	return p.authOptions.PasswordPolicy.IsExpired(lastChangeTime)
+}
+
 func PasswordVerify(encryptedPassword, password string) error {
 	if err := bcrypt.CompareHashAndPassword([]byte(encryptedPassword), []byte(password)); err != nil {
 		return IncorrectPasswordError
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	k8scache "k8s.io/client-go/tools/cache"
+
+	"aiscope/pkg/apiserver/authentication"
+	"aiscope/pkg/apiserver/request"
+	fakeaiscope "aiscope/pkg/client/clientset/versioned/fake"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/simple/client/cache"
+)
+
+func TestPasswordAuthenticatorSourceIPRateLimit(t *testing.T) {
+	options := authentication.NewOptions()
+	options.AuthenticateRateLimiterMaxTries = 3
+	userLister := iamv1alpha2listers.NewUserLister(k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{}))
+	authenticator := NewPasswordAuthenticator(fakeaiscope.NewSimpleClientset(), userLister, cache.NewSimpleCache(), options)
+
+	ctx := request.WithRequestInfo(context.Background(), &request.RequestInfo{SourceIP: "10.0.0.1"})
+	for i := 0; i < options.AuthenticateRateLimiterMaxTries; i++ {
+		if _, _, err := authenticator.Authenticate(ctx, "unknown", "password"); err != IncorrectPasswordError {
+			t.Fatalf("attempt %d: expected %v, got %v", i, IncorrectPasswordError, err)
+		}
+	}
+	if _, _, err := authenticator.Authenticate(ctx, "unknown", "password"); err != RateLimitExceededError {
+		t.Errorf("expected %v after %d failed attempts, got %v", RateLimitExceededError, options.AuthenticateRateLimiterMaxTries, err)
+	}
+
+	// other source IPs are not affected
+	ctx = request.WithRequestInfo(context.Background(), &request.RequestInfo{SourceIP: "10.0.0.2"})
+	if _, _, err := authenticator.Authenticate(ctx, "unknown", "password"); err != IncorrectPasswordError {
+		t.Errorf("expected %v, got %v", IncorrectPasswordError, err)
+	}
+}
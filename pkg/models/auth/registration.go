@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	authuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	aiscope "aiscope/pkg/client/clientset/versioned"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+)
+
+// RegistrationOperator completes the registration of the identities pre-registered at login,
+// the pre-registration user carries the identity provider and the uid of the identity in the extras.
+type RegistrationOperator interface {
+	// Register creates the user mapped to the identity, the username and the email default to the ones of the identity
+	Register(preRegistration authuser.Info, username string, email string) (*iamv1alpha2.User, error)
+	// Link maps the identity to the existing account, the password of the account is required as proof
+	Link(preRegistration authuser.Info, username string, password string) (*iamv1alpha2.User, error)
+}
+
+type registrationOperator struct {
+	aiClient   aiscope.Interface
+	userGetter *userGetter
+}
+
+func NewRegistrationOperator(aiClient aiscope.Interface, userLister iamv1alpha2listers.UserLister) RegistrationOperator {
+	return &registrationOperator{
+		aiClient:   aiClient,
+		userGetter: &userGetter{userLister: userLister},
+	}
+}
+
+func (r *registrationOperator) Register(preRegistration authuser.Info, username string, email string) (*iamv1alpha2.User, error) {
+	idp, uid, err := r.identityOf(preRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	extra := preRegistration.GetExtra()
+	if username == "" && len(extra[iamv1alpha2.ExtraUsername]) > 0 {
+		username = strings.ToLower(extra[iamv1alpha2.ExtraUsername][0])
+	}
+	if errs := validation.IsDNS1123Subdomain(username); len(errs) > 0 {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid username %s: %v", username, errs))
+	}
+	if email == "" && len(extra[iamv1alpha2.ExtraEmail]) > 0 {
+		email = extra[iamv1alpha2.ExtraEmail][0]
+	}
+
+	if _, err = r.userGetter.userLister.Get(username); err == nil {
+		return nil, errors.NewAlreadyExists(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), username)
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if email != "" {
+		if _, err = r.userGetter.findUser(email); err == nil {
+			return nil, errors.NewConflict(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), username,
+				fmt.Errorf("email %s is already in use", email))
+		} else if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	user := &iamv1alpha2.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: username,
+			Labels: map[string]string{
+				iamv1alpha2.IdentifyProviderLabel: idp,
+				iamv1alpha2.OriginUIDLabel:        uid,
+			},
+		},
+		Spec: iamv1alpha2.UserSpec{Email: email},
+	}
+	created, err := r.aiClient.IamV1alpha2().Users().Create(context.Background(), user, metav1.CreateOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *registrationOperator) Link(preRegistration authuser.Info, username string, password string) (*iamv1alpha2.User, error) {
+	idp, uid, err := r.identityOf(preRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.userGetter.findUser(username)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, IncorrectPasswordError
+		}
+		return nil, err
+	}
+	if user.Status.State != nil && *user.Status.State == iamv1alpha2.UserAuthLimitExceeded {
+		return nil, RateLimitExceededError
+	}
+	if user.Status.State == nil || *user.Status.State != iamv1alpha2.UserActive {
+		return nil, AccountIsNotActiveError
+	}
+	// the mapped users without password can not be linked
+	if user.Spec.EncryptedPassword == "" {
+		return nil, IncorrectPasswordError
+	}
+	if err = PasswordVerify(user.Spec.EncryptedPassword, password); err != nil {
+		return nil, err
+	}
+	if linked := user.Labels[iamv1alpha2.IdentifyProviderLabel]; linked != "" {
+		return nil, errors.NewConflict(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), user.Name,
+			fmt.Errorf("the user has been linked to identity provider %s", linked))
+	}
+
+	user = user.DeepCopy()
+	if user.Labels == nil {
+		user.Labels = make(map[string]string)
+	}
+	user.Labels[iamv1alpha2.IdentifyProviderLabel] = idp
+	user.Labels[iamv1alpha2.OriginUIDLabel] = uid
+	updated, err := r.aiClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// identityOf returns the identity provider and the uid of the pre-registered identity,
+// the identity must not be mapped to any user yet.
+func (r *registrationOperator) identityOf(preRegistration authuser.Info) (string, string, error) {
+	extra := preRegistration.GetExtra()
+	if preRegistration.GetName() != iamv1alpha2.PreRegistrationUser ||
+		len(extra[iamv1alpha2.ExtraIdentityProvider]) != 1 || len(extra[iamv1alpha2.ExtraUID]) != 1 {
+		return "", "", errors.NewBadRequest("invalid registration token")
+	}
+	idp := extra[iamv1alpha2.ExtraIdentityProvider][0]
+	uid := extra[iamv1alpha2.ExtraUID][0]
+	mapped, err := r.userGetter.findMappedUser(idp, uid)
+	if err == nil {
+		return "", "", errors.NewConflict(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), mapped.Name,
+			fmt.Errorf("the identity has been mapped to user %s", mapped.Name))
+	}
+	if !errors.IsNotFound(err) {
+		return "", "", err
+	}
+	return idp, uid, nil
+}
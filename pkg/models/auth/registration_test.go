@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authuser "k8s.io/apiserver/pkg/authentication/user"
+	k8scache "k8s.io/client-go/tools/cache"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	fakeaiscope "aiscope/pkg/client/clientset/versioned/fake"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+)
+
+func preRegistration(uid string) authuser.Info {
+	return &authuser.DefaultInfo{
+		Name: iamv1alpha2.PreRegistrationUser,
+		Extra: map[string][]string{
+			iamv1alpha2.ExtraIdentityProvider: {"github"},
+			iamv1alpha2.ExtraUID:              {uid},
+			iamv1alpha2.ExtraUsername:         {"Octocat"},
+			iamv1alpha2.ExtraEmail:            {"octocat@github.com"},
+		},
+	}
+}
+
+func TestRegistrationOperator(t *testing.T) {
+	encryptedPassword, err := bcrypt.GenerateFromPassword([]byte("P@88w0rd"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active := iamv1alpha2.UserActive
+	existing := []*iamv1alpha2.User{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+			Spec:       iamv1alpha2.UserSpec{Email: "admin@aiscope.io", EncryptedPassword: string(encryptedPassword)},
+			Status:     iamv1alpha2.UserStatus{State: &active},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "mapped", Labels: map[string]string{
+				iamv1alpha2.IdentifyProviderLabel: "github",
+				iamv1alpha2.OriginUIDLabel:        "100",
+			}},
+			Status: iamv1alpha2.UserStatus{State: &active},
+		},
+	}
+	indexer := k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{})
+	client := fakeaiscope.NewSimpleClientset()
+	for _, user := range existing {
+		if err := indexer.Add(user); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.IamV1alpha2().Users().Create(context.Background(), user, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	operator := NewRegistrationOperator(client, iamv1alpha2listers.NewUserLister(indexer))
+
+	if _, err = operator.Register(preRegistration("100"), "", ""); !errors.IsConflict(err) {
+		t.Errorf("the mapped identity should not be registered again, got %v", err)
+	}
+	if _, err = operator.Register(preRegistration("200"), "admin", ""); !errors.IsAlreadyExists(err) {
+		t.Errorf("the username should be unique, got %v", err)
+	}
+	if _, err = operator.Register(preRegistration("200"), "octocat", "admin@aiscope.io"); !errors.IsConflict(err) {
+		t.Errorf("the email should be unique, got %v", err)
+	}
+	if _, err = operator.Register(preRegistration("200"), "Invalid_Name", ""); !errors.IsBadRequest(err) {
+		t.Errorf("the username should be validated, got %v", err)
+	}
+
+	registered, err := operator.Register(preRegistration("200"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered.Name != "octocat" || registered.Spec.Email != "octocat@github.com" ||
+		registered.Labels[iamv1alpha2.IdentifyProviderLabel] != "github" || registered.Labels[iamv1alpha2.OriginUIDLabel] != "200" {
+		t.Errorf("unexpected registered user: %+v", registered)
+	}
+
+	if _, err = operator.Link(preRegistration("300"), "admin", "wrong"); err != IncorrectPasswordError {
+		t.Errorf("expected %v, got %v", IncorrectPasswordError, err)
+	}
+	if _, err = operator.Link(preRegistration("300"), "mapped", ""); err != IncorrectPasswordError {
+		t.Errorf("the users without password should not be linked, got %v", err)
+	}
+	linked, err := operator.Link(preRegistration("300"), "admin@aiscope.io", "P@88w0rd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked.Name != "admin" || linked.Labels[iamv1alpha2.OriginUIDLabel] != "300" {
+		t.Errorf("unexpected linked user: %+v", linked)
+	}
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authentication/token"
+)
+
+var sessionResource = iamv1alpha2.Resource("sessions")
+
+// Session is the login session of the user, the access token and the refresh token are issued in pairs,
+// the session ID is kept when the tokens are refreshed.
+type Session struct {
+	ID        string `json:"id"`
+	ClientID  string `json:"clientID,omitempty"`
+	SourceIP  string `json:"sourceIP,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
+	// CreationTimestamp is the login time
+	CreationTimestamp metav1.Time `json:"creationTimestamp,omitempty"`
+	// IssueTimestamp is the time the current tokens were issued
+	IssueTimestamp metav1.Time `json:"issueTimestamp,omitempty"`
+	// ExpirationTimestamp is the expiration time of the refresh token
+	ExpirationTimestamp metav1.Time `json:"expirationTimestamp,omitempty"`
+}
+
+// tokenRecord is cached for every issued token except the ID tokens, which are not bearer credentials.
+// Only the access tokens and the refresh tokens belong to sessions, the other cached tokens cannot authenticate.
+type tokenRecord struct {
+	TokenType token.Type `json:"tokenType"`
+	Session   *Session   `json:"session,omitempty"`
+}
+
+// IssueSessionTo issues the access token and the refresh token of the session.
+// The tokens of the session are replaced if the session ID is specified,
+// the other sessions of the user are revoked when multiple login is disabled.
+// Sessions are tracked only if the access tokens expire.
+func (t *tokenOperator) IssueSessionTo(user user.Info, session *Session) (string, string, error) {
+	now := time.Now()
+	session.IssueTimestamp = metav1.NewTime(now)
+	session.CreationTimestamp = session.IssueTimestamp
+	session.ExpirationTimestamp = metav1.NewTime(now.Add(t.options.OAuthOptions.AccessTokenMaxAge + t.options.OAuthOptions.AccessTokenInactivityTimeout))
+
+	records, err := t.sessionRecords(user.GetName())
+	if err != nil {
+		return "", "", err
+	}
+	var revoked []string
+	for key, record := range records {
+		if record.Session.ID == session.ID {
+			session.CreationTimestamp = record.Session.CreationTimestamp
+			revoked = append(revoked, key)
+			continue
+		}
+		// pre-registration users share the same name
+		if !t.options.MultipleLogin && user.GetName() != iamv1alpha2.PreRegistrationUser {
+			revoked = append(revoked, key)
+		}
+	}
+	// revoked before issuing, the refreshed tokens are identical if issued within the same second
+	if len(revoked) > 0 {
+		if err = t.cache.Del(revoked...); err != nil {
+			klog.Error(err)
+			return "", "", err
+		}
+	}
+	if session.ID == "" {
+		session.ID = string(uuid.NewUUID())
+	}
+
+	accessToken, err := t.issueSessionToken(user, session, token.AccessToken, t.options.OAuthOptions.AccessTokenMaxAge)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err := t.issueSessionToken(user, session, token.RefreshToken,
+		t.options.OAuthOptions.AccessTokenMaxAge+t.options.OAuthOptions.AccessTokenInactivityTimeout)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// ListSessions lists the active sessions of the user, the latest first
+func (t *tokenOperator) ListSessions(username string) ([]Session, error) {
+	records, err := t.sessionRecords(username)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make(map[string]Session)
+	for _, record := range records {
+		sessions[record.Session.ID] = *record.Session
+	}
+	result := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, session)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreationTimestamp.After(result[j].CreationTimestamp.Time)
+	})
+	return result, nil
+}
+
+// RevokeSession revokes the tokens of the session
+func (t *tokenOperator) RevokeSession(username string, id string) error {
+	records, err := t.sessionRecords(username)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	for key, record := range records {
+		if record.Session.ID == id {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return errors.NewNotFound(sessionResource, id)
+	}
+	if err = t.cache.Del(keys...); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (t *tokenOperator) issueSessionToken(user user.Info, session *Session, tokenType token.Type, expiresIn time.Duration) (string, error) {
+	claims := jwt.StandardClaims{Id: session.ID}
+	// the tokens can only be revoked by the client they were issued to
+	if session.ClientID != "" {
+		claims.Audience = []string{session.ClientID}
+	}
+	tokenStr, err := t.issuer.IssueTo(&token.IssueRequest{
+		User: user,
+		Claims: token.Claims{
+			StandardClaims: claims,
+			TokenType:      tokenType,
+			// the restricted scopes of the user, such as the users who must change the password
+			Scopes: user.GetExtra()[iamv1alpha2.ExtraScopes],
+		},
+		ExpiresIn: expiresIn,
+	})
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	if expiresIn > 0 {
+		if err = t.cacheToken(user.GetName(), tokenStr, &tokenRecord{TokenType: tokenType, Session: session}, expiresIn); err != nil {
+			return "", err
+		}
+	}
+	return tokenStr, nil
+}
+
+// sessionRecords returns the cached records of the session tokens of the user by the cache keys
+func (t *tokenOperator) sessionRecords(username string) (map[string]*tokenRecord, error) {
+	keys, err := t.cache.Keys(fmt.Sprintf("aiscope:user:%s:token:*", username))
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	records := make(map[string]*tokenRecord, len(keys))
+	for _, key := range keys {
+		data, err := t.cache.Get(key)
+		if err != nil {
+			// expired after listed
+			klog.V(4).Info(err)
+			continue
+		}
+		var record tokenRecord
+		if err = json.Unmarshal([]byte(data), &record); err != nil || record.Session == nil {
+			continue
+		}
+		records[key] = &record
+	}
+	return records, nil
+}
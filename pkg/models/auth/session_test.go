@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"aiscope/pkg/apiserver/authentication"
+	"aiscope/pkg/apiserver/authentication/token"
+	"aiscope/pkg/simple/client/cache"
+)
+
+func newTestTokenOperator(t *testing.T, multipleLogin bool) TokenManagementInterface {
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	options.MultipleLogin = multipleLogin
+	options.OAuthOptions.AccessTokenMaxAge = time.Hour
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTokenOperator(cache.NewSimpleCache(), issuer, options)
+}
+
+func TestSingleSession(t *testing.T) {
+	operator := newTestTokenOperator(t, false)
+	admin := &user.DefaultInfo{Name: "admin"}
+
+	firstAccessToken, firstRefreshToken, err := operator.IssueSessionTo(admin, &Session{SourceIP: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, _, err := operator.IssueSessionTo(admin, &Session{SourceIP: "10.0.0.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, revoked := range []string{firstAccessToken, firstRefreshToken} {
+		if _, err = operator.Verify(revoked); err == nil {
+			t.Errorf("the tokens of the previous session should be revoked")
+		}
+	}
+	if _, err = operator.Verify(accessToken); err != nil {
+		t.Errorf("failed to verify the token of the new session: %v", err)
+	}
+
+	sessions, err := operator.ListSessions("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].SourceIP != "10.0.0.2" {
+		t.Errorf("unexpected sessions: %+v", sessions)
+	}
+}
+
+func TestMultipleSessions(t *testing.T) {
+	operator := newTestTokenOperator(t, true)
+	admin := &user.DefaultInfo{Name: "admin"}
+
+	_, refreshToken, err := operator.IssueSessionTo(admin, &Session{ClientID: "kubectl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, _, err := operator.IssueSessionTo(admin, &Session{ClientID: "console"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// refreshing keeps the session
+	verified, err := operator.Verify(refreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = operator.IssueSessionTo(admin, &Session{ID: verified.Id, ClientID: "kubectl"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := operator.ListSessions("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v", sessions)
+	}
+	found := false
+	for _, session := range sessions {
+		found = found || session.ID == verified.Id
+	}
+	if !found {
+		t.Errorf("the refreshed session %s should be kept: %+v", verified.Id, sessions)
+	}
+
+	for _, session := range sessions {
+		if session.ClientID == "console" {
+			if err = operator.RevokeSession("admin", session.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err = operator.Verify(accessToken); err == nil {
+		t.Errorf("the tokens of the revoked session should be revoked")
+	}
+	if err = operator.RevokeSession("admin", "unknown"); err == nil {
+		t.Errorf("revoking an unknown session should fail")
+	}
+}
+
+func TestIDTokenIsNotSessionToken(t *testing.T) {
+	operator := newTestTokenOperator(t, false)
+	admin := &user.DefaultInfo{Name: "admin"}
+
+	idToken, err := operator.IssueTo(&token.IssueRequest{
+		User:      admin,
+		Claims:    token.Claims{TokenType: token.IDToken},
+		ExpiresIn: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = operator.Verify(idToken); err == nil {
+		t.Errorf("the ID token should not be cached as a bearer token")
+	}
+	keys, err := operator.(*tokenOperator).cache.Keys("aiscope:user:admin:token:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("only the session tokens should be cached, got %v", keys)
+	}
+}
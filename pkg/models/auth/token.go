@@ -1,10 +1,13 @@
 package auth
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"k8s.io/apiserver/pkg/authentication/user"
+
 	"aiscope/pkg/apiserver/authentication"
 
 	"k8s.io/klog/v2"
@@ -19,6 +22,12 @@ type TokenManagementInterface interface {
 	Verify(token string) (*token.VerifiedResponse, error)
 	// IssueTo issue a token for the specified user
 	IssueTo(request *token.IssueRequest) (string, error)
+	// IssueSessionTo issues the access token and the refresh token of the login session
+	IssueSessionTo(user user.Info, session *Session) (accessToken string, refreshToken string, err error)
+	// ListSessions lists the active login sessions of the user
+	ListSessions(username string) ([]Session, error)
+	// RevokeSession revokes the tokens of the login session
+	RevokeSession(username string, id string) error
 	// Revoke revoke the specified token
 	Revoke(token string) error
 	// RevokeAllUserTokens revoke all user tokens
@@ -33,16 +42,39 @@ type tokenOperator struct {
 	cache   cache.Interface
 }
 
-func (t tokenOperator) Revoke(token string) error {
-	pattern := fmt.Sprintf("aiscope:user:*:token:%s", token)
-	if keys, err := t.cache.Keys(pattern); err != nil {
-		klog.Error(err)
-		return err
-	} else if len(keys) > 0 {
-		if err := t.cache.Del(keys...); err != nil {
-			klog.Error(err)
+// Revoke deletes the cache key of the verified token, the tokens that cannot be verified are ignored.
+// The other tokens of the session are revoked with the refresh token,
+// see also https://datatracker.ietf.org/doc/html/rfc7009#section-2.1
+func (t *tokenOperator) Revoke(tokenStr string) error {
+	verified, err := t.issuer.Verify(tokenStr)
+	if err != nil {
+		klog.V(4).Info(err)
+		return nil
+	}
+	username := verified.User.GetName()
+	// personal access tokens are stored by name
+	if verified.TokenType == token.StaticToken {
+		if verified.Id == "" {
+			return nil
+		}
+		return accessTokenRevoke(t.cache, username, verified.Id, tokenStr)
+	}
+
+	keys := []string{tokenCacheKey(username, tokenStr)}
+	if verified.TokenType == token.RefreshToken && verified.Id != "" {
+		records, err := t.sessionRecords(username)
+		if err != nil {
 			return err
 		}
+		for key, record := range records {
+			if record.Session.ID == verified.Id {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if err = t.cache.Del(keys...); err != nil {
+		klog.Error(err)
+		return err
 	}
 	return nil
 }
@@ -61,8 +93,17 @@ func (t *tokenOperator) Verify(tokenStr string) (*token.VerifiedResponse, error)
 	if err != nil {
 		return nil, err
 	}
-	if t.options.OAuthOptions.AccessTokenMaxAge == 0 ||
-		response.TokenType == token.StaticToken {
+	// personal access tokens can be revoked individually
+	if response.TokenType == token.StaticToken && response.Id != "" {
+		if err := accessTokenValidate(t.cache, response.User.GetName(), response.Id, tokenStr); err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+	// authorization codes and MFA challenges are always checked, they MUST NOT be used more than once
+	if response.TokenType == token.StaticToken ||
+		(t.options.OAuthOptions.AccessTokenMaxAge == 0 &&
+			response.TokenType != token.AuthorizationCode && response.TokenType != token.MFAChallenge) {
 		return response, nil
 	}
 	if err := t.tokenCacheValidate(response.User.GetName(), tokenStr); err != nil {
@@ -77,8 +118,9 @@ func (t *tokenOperator) IssueTo(request *token.IssueRequest) (string, error) {
 		klog.Error(err)
 		return "", err
 	}
-	if request.ExpiresIn > 0 {
-		if err = t.cacheToken(request.User.GetName(), tokenStr, request.ExpiresIn); err != nil {
+	// ID tokens are verified by the relying parties with the published keys, they are not bearer credentials
+	if request.ExpiresIn > 0 && request.TokenType != token.IDToken {
+		if err = t.cacheToken(request.User.GetName(), tokenStr, &tokenRecord{TokenType: request.TokenType}, request.ExpiresIn); err != nil {
 			klog.Error(err)
 			return "", err
 		}
@@ -107,7 +149,7 @@ func (t *tokenOperator) Keys() *token.Keys {
 
 // tokenCacheValidate verify that the token is in the cache
 func (t *tokenOperator) tokenCacheValidate(username, token string) error {
-	key := fmt.Sprintf("aiscope:user:%s:token:%s", username, token)
+	key := tokenCacheKey(username, token)
 	if exist, err := t.cache.Exists(key); err != nil {
 		return err
 	} else if !exist {
@@ -119,11 +161,19 @@ func (t *tokenOperator) tokenCacheValidate(username, token string) error {
 }
 
 // cacheToken cache the token for a period of time
-func (t *tokenOperator) cacheToken(username, token string, duration time.Duration) error {
-	key := fmt.Sprintf("aiscope:user:%s:token:%s", username, token)
-	if err := t.cache.Set(key, token, duration); err != nil {
+func (t *tokenOperator) cacheToken(username, token string, record *tokenRecord, duration time.Duration) error {
+	key := tokenCacheKey(username, token)
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := t.cache.Set(key, string(data), duration); err != nil {
 		klog.Error(err)
 		return err
 	}
 	return nil
 }
+
+func tokenCacheKey(username, token string) string {
+	return fmt.Sprintf("aiscope:user:%s:token:%s", username, token)
+}
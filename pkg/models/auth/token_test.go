@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestRevoke(t *testing.T) {
+	operator := newTestTokenOperator(t, true)
+	admin := &user.DefaultInfo{Name: "admin"}
+
+	accessToken, refreshToken, err := operator.IssueSessionTo(admin, &Session{ClientID: "kubectl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherAccessToken, _, err := operator.IssueSessionTo(admin, &Session{ClientID: "console"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the token is never used as a pattern
+	for _, invalid := range []string{"*", "invalid"} {
+		if err = operator.Revoke(invalid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, active := range []string{accessToken, refreshToken, otherAccessToken} {
+		if _, err = operator.Verify(active); err != nil {
+			t.Errorf("the tokens should not be revoked by invalid tokens: %v", err)
+		}
+	}
+
+	// the access token of the session is revoked with the refresh token
+	if err = operator.Revoke(refreshToken); err != nil {
+		t.Fatal(err)
+	}
+	for _, revoked := range []string{accessToken, refreshToken} {
+		if _, err = operator.Verify(revoked); err == nil {
+			t.Errorf("the tokens of the session should be revoked")
+		}
+	}
+	if _, err = operator.Verify(otherAccessToken); err != nil {
+		t.Errorf("the tokens of the other sessions should not be revoked: %v", err)
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	operator := newTestTokenOperator(t, true).(*tokenOperator)
+	accessTokenOperator := NewAccessTokenOperator(operator.cache, operator.issuer)
+
+	created, err := accessTokenOperator.CreateAccessToken("admin", &AccessToken{Name: "pipeline"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = operator.Revoke(created.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = operator.Verify(created.Token); err == nil {
+		t.Errorf("the revoked access token should not be verified")
+	}
+}
@@ -0,0 +1,58 @@
+package experiment
+
+import (
+	"aiscope/pkg/api"
+	experimentv1alpha2 "aiscope/pkg/apis/experiment/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	"context"
+	"encoding/json"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+func (o *Operator) CreateOrUpdateCodeServer(namespace string, codeserver *experimentv1alpha2.CodeServer) (*experimentv1alpha2.CodeServer, error) {
+	var created *experimentv1alpha2.CodeServer
+	var err error
+
+	codeserver.Namespace = namespace
+
+	if codeserver.ResourceVersion != "" {
+		created, err = o.aiclient.ExperimentV1alpha2().CodeServers(namespace).Update(context.Background(), codeserver, metav1.UpdateOptions{})
+	} else {
+		created, err = o.aiclient.ExperimentV1alpha2().CodeServers(namespace).Create(context.Background(), codeserver, metav1.CreateOptions{})
+	}
+
+	return created, err
+}
+
+func (o *Operator) PatchCodeServer(namespace string, codeserver *experimentv1alpha2.CodeServer) (*experimentv1alpha2.CodeServer, error) {
+	data, err := json.Marshal(codeserver)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.aiclient.ExperimentV1alpha2().CodeServers(namespace).Patch(context.Background(), codeserver.Name, types.MergePatchType, data, metav1.PatchOptions{})
+}
+
+func (o *Operator) DeleteCodeServer(namespace, name string) error {
+	return o.aiclient.ExperimentV1alpha2().CodeServers(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+func (o *Operator) ListCodeServers(namespace string, queryParam *query.Query) (*api.ListResult, error) {
+	result, err := o.resourceGetter.List(experimentv1alpha2.ResourcePluralCodeServer, namespace, queryParam)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Operator) DescribeCodeServer(namespace, name string) (*experimentv1alpha2.CodeServer, error) {
+	obj, err := o.resourceGetter.Get(experimentv1alpha2.ResourcePluralCodeServer, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	result := obj.(*experimentv1alpha2.CodeServer)
+	return result, nil
+}
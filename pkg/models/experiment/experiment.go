@@ -15,6 +15,11 @@ type Interface interface {
 	DeleteTrackingServer(namespace, name string) error
 	ListTrackingServers(namespace string, queryParam *query.Query) (*api.ListResult, error)
 	DescribeTrackingServer(namespace, name string) (*experimentv1alpha2.TrackingServer, error)
+	CreateOrUpdateCodeServer(namespace string, codeserver *experimentv1alpha2.CodeServer) (*experimentv1alpha2.CodeServer, error)
+	PatchCodeServer(namespace string, codeserver *experimentv1alpha2.CodeServer) (*experimentv1alpha2.CodeServer, error)
+	DeleteCodeServer(namespace, name string) error
+	ListCodeServers(namespace string, queryParam *query.Query) (*api.ListResult, error)
+	DescribeCodeServer(namespace, name string) (*experimentv1alpha2.CodeServer, error)
 }
 
 type Operator struct {
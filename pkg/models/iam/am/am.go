@@ -0,0 +1,437 @@
+package am
+
+import (
+	"aiscope/pkg/api"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	aiscope "aiscope/pkg/client/clientset/versioned"
+	"aiscope/pkg/constants"
+	"aiscope/pkg/informers"
+	resourcev1alpha2 "aiscope/pkg/models/resources/v1alpha2/resource"
+	"aiscope/pkg/utils/sliceutil"
+	"context"
+	"encoding/json"
+	"fmt"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"strings"
+)
+
+type AccessManagementInterface interface {
+	ListGlobalRoleBindings(username string, groups []string) ([]*iamv1alpha2.GlobalRoleBinding, error)
+	ListWorkspaceRoleBindings(username string, groups []string, workspace string) ([]*iamv1alpha2.WorkspaceRoleBinding, error)
+	ListRoleBindings(username string, groups []string, namespace string) ([]*rbacv1.RoleBinding, error)
+	GetRoleReferenceRules(roleRef rbacv1.RoleRef, namespace string) ([]rbacv1.PolicyRule, error)
+	GetNamespaceControlledWorkspace(namespace string) (string, error)
+	GetGlobalRoleOfUser(username string, groups []string) ([]*iamv1alpha2.GlobalRole, error)
+	GetWorkspaceRoleOfUser(username string, groups []string, workspace string) ([]*iamv1alpha2.WorkspaceRole, error)
+	GetNamespaceRoleOfUser(username string, groups []string, namespace string) ([]*rbacv1.Role, error)
+	ListWorkspaceRoles(workspace string, queryParam *query.Query) (*api.ListResult, error)
+	GetWorkspaceRole(workspace string, name string) (*iamv1alpha2.WorkspaceRole, error)
+	CreateOrUpdateWorkspaceRole(workspace string, workspaceRole *iamv1alpha2.WorkspaceRole) (*iamv1alpha2.WorkspaceRole, error)
+	PatchWorkspaceRole(workspace string, workspaceRole *iamv1alpha2.WorkspaceRole) (*iamv1alpha2.WorkspaceRole, error)
+	DeleteWorkspaceRole(workspace string, name string) error
+	CreateOrUpdateWorkspaceRoleBinding(username string, workspace string, role string) error
+	RemoveUserFromWorkspace(username string, workspace string) error
+}
+
+type amOperator struct {
+	aiClient       aiscope.Interface
+	informers      informers.InformerFactory
+	resourceGetter *resourcev1alpha2.ResourceGetter
+}
+
+func NewReadOnlyOperator(factory informers.InformerFactory) AccessManagementInterface {
+	return &amOperator{
+		informers:      factory,
+		resourceGetter: resourcev1alpha2.NewResourceGetter(factory),
+	}
+}
+
+func NewOperator(aiClient aiscope.Interface, factory informers.InformerFactory) AccessManagementInterface {
+	return &amOperator{
+		aiClient:       aiClient,
+		informers:      factory,
+		resourceGetter: resourcev1alpha2.NewResourceGetter(factory),
+	}
+}
+
+func (am *amOperator) ListGlobalRoleBindings(username string, groups []string) ([]*iamv1alpha2.GlobalRoleBinding, error) {
+	roleBindings, err := am.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().GlobalRoleBindings().Lister().List(labels.Everything())
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	result := make([]*iamv1alpha2.GlobalRoleBinding, 0)
+	for _, roleBinding := range roleBindings {
+		if containsSubject(roleBinding.Subjects, username, groups) {
+			result = append(result, roleBinding)
+		}
+	}
+	return result, nil
+}
+
+func (am *amOperator) ListWorkspaceRoleBindings(username string, groups []string, workspace string) ([]*iamv1alpha2.WorkspaceRoleBinding, error) {
+	selector := labels.Everything()
+	if workspace != "" {
+		selector = labels.SelectorFromValidatedSet(labels.Set{tenantv1alpha2.WorkspaceLabel: workspace})
+	}
+
+	roleBindings, err := am.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().WorkspaceRoleBindings().Lister().List(selector)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	result := make([]*iamv1alpha2.WorkspaceRoleBinding, 0)
+	for _, roleBinding := range roleBindings {
+		if containsSubject(roleBinding.Subjects, username, groups) {
+			result = append(result, roleBinding)
+		}
+	}
+	return result, nil
+}
+
+func (am *amOperator) ListRoleBindings(username string, groups []string, namespace string) ([]*rbacv1.RoleBinding, error) {
+	roleBindings, err := am.informers.KubernetesSharedInformerFactory().Rbac().V1().RoleBindings().Lister().RoleBindings(namespace).List(labels.Everything())
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	result := make([]*rbacv1.RoleBinding, 0)
+	for _, roleBinding := range roleBindings {
+		if containsSubject(roleBinding.Subjects, username, groups) {
+			result = append(result, roleBinding)
+		}
+	}
+	return result, nil
+}
+
+// GetRoleReferenceRules resolves the rules of the role referenced by a binding,
+// namespace is only used for Role references.
+func (am *amOperator) GetRoleReferenceRules(roleRef rbacv1.RoleRef, namespace string) ([]rbacv1.PolicyRule, error) {
+	switch roleRef.Kind {
+	case iamv1alpha2.ResourceKindGlobalRole:
+		role, err := am.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().GlobalRoles().Lister().Get(roleRef.Name)
+		if err != nil {
+			return nil, err
+		}
+		return role.Rules, nil
+	case iamv1alpha2.ResourceKindWorkspaceRole:
+		role, err := am.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().WorkspaceRoles().Lister().Get(roleRef.Name)
+		if err != nil {
+			return nil, err
+		}
+		return role.Rules, nil
+	case iamv1alpha2.ResourceKindRole:
+		role, err := am.informers.KubernetesSharedInformerFactory().Rbac().V1().Roles().Lister().Roles(namespace).Get(roleRef.Name)
+		if err != nil {
+			return nil, err
+		}
+		return role.Rules, nil
+	case iamv1alpha2.ResourceKindClusterRole:
+		role, err := am.informers.KubernetesSharedInformerFactory().Rbac().V1().ClusterRoles().Lister().Get(roleRef.Name)
+		if err != nil {
+			return nil, err
+		}
+		return role.Rules, nil
+	default:
+		return nil, fmt.Errorf("unsupported role reference kind: %q", roleRef.Kind)
+	}
+}
+
+// GetNamespaceControlledWorkspace returns the workspace a namespace belongs to,
+// empty if the namespace is not labeled with a workspace.
+func (am *amOperator) GetNamespaceControlledWorkspace(namespace string) (string, error) {
+	ns, err := am.informers.KubernetesSharedInformerFactory().Core().V1().Namespaces().Lister().Get(namespace)
+	if err != nil {
+		return "", err
+	}
+	return ns.Labels[tenantv1alpha2.WorkspaceLabel], nil
+}
+
+func (am *amOperator) GetGlobalRoleOfUser(username string, groups []string) ([]*iamv1alpha2.GlobalRole, error) {
+	roleBindings, err := am.ListGlobalRoleBindings(username, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*iamv1alpha2.GlobalRole, 0)
+	for _, roleBinding := range roleBindings {
+		role, err := am.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().GlobalRoles().Lister().Get(roleBinding.RoleRef.Name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				klog.Warningf("invalid global role binding found: %s", roleBinding.Name)
+				continue
+			}
+			klog.Error(err)
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// GetNamespaceRoleOfUser returns the roles bound to the user in the namespace, all namespaces if empty.
+// Bindings to ClusterRoles are not included.
+func (am *amOperator) GetNamespaceRoleOfUser(username string, groups []string, namespace string) ([]*rbacv1.Role, error) {
+	roleBindings, err := am.ListRoleBindings(username, groups, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*rbacv1.Role, 0)
+	for _, roleBinding := range roleBindings {
+		if roleBinding.RoleRef.Kind != iamv1alpha2.ResourceKindRole {
+			continue
+		}
+		role, err := am.informers.KubernetesSharedInformerFactory().Rbac().V1().Roles().Lister().Roles(roleBinding.Namespace).Get(roleBinding.RoleRef.Name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				klog.Warningf("invalid role binding found: %s/%s", roleBinding.Namespace, roleBinding.Name)
+				continue
+			}
+			klog.Error(err)
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (am *amOperator) GetWorkspaceRoleOfUser(username string, groups []string, workspace string) ([]*iamv1alpha2.WorkspaceRole, error) {
+	roleBindings, err := am.ListWorkspaceRoleBindings(username, groups, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*iamv1alpha2.WorkspaceRole, 0)
+	for _, roleBinding := range roleBindings {
+		role, err := am.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().WorkspaceRoles().Lister().Get(roleBinding.RoleRef.Name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				klog.Warningf("invalid workspace role binding found: %s", roleBinding.Name)
+				continue
+			}
+			klog.Error(err)
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (am *amOperator) ListWorkspaceRoles(workspace string, queryParam *query.Query) (*api.ListResult, error) {
+	workspaceSelector := fmt.Sprintf("%s=%s", tenantv1alpha2.WorkspaceLabel, workspace)
+	if queryParam.LabelSelector == "" {
+		queryParam.LabelSelector = workspaceSelector
+	} else {
+		queryParam.LabelSelector = queryParam.LabelSelector + "," + workspaceSelector
+	}
+	return am.resourceGetter.List(iamv1alpha2.ResourcesPluralWorkspaceRole, "", queryParam)
+}
+
+// GetWorkspaceRole returns the role only if it is owned by the workspace,
+// roles of other workspaces are reported as not found.
+func (am *amOperator) GetWorkspaceRole(workspace string, name string) (*iamv1alpha2.WorkspaceRole, error) {
+	role, err := am.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().WorkspaceRoles().Lister().Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if role.Labels[tenantv1alpha2.WorkspaceLabel] != workspace {
+		return nil, errors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcesSingularWorkspaceRole), name)
+	}
+	return role, nil
+}
+
+// The labels and annotations managed by the controllers, the role templates and the aggregation
+// of the built-in roles can not be set through the API.
+var (
+	reservedWorkspaceRoleLabels      = []string{iamv1alpha2.RoleTemplateLabel}
+	reservedWorkspaceRoleAnnotations = []string{iamv1alpha2.AggregationRolesAnnotation, constants.CreatorAnnotationKey}
+)
+
+// roleTemplatePrefix is the name prefix of the role templates
+const roleTemplatePrefix = "role-template-"
+
+// retainReservedMetadata replaces the reserved labels and annotations of the role with the stored ones,
+// existing is nil if the role is being created.
+func retainReservedMetadata(workspaceRole *iamv1alpha2.WorkspaceRole, existing *iamv1alpha2.WorkspaceRole) {
+	var labels, annotations map[string]string
+	if existing != nil {
+		labels, annotations = existing.Labels, existing.Annotations
+	}
+	retain := func(values map[string]string, stored map[string]string, keys []string) map[string]string {
+		for _, key := range keys {
+			if value, ok := stored[key]; ok {
+				if values == nil {
+					values = make(map[string]string)
+				}
+				values[key] = value
+			} else {
+				delete(values, key)
+			}
+		}
+		return values
+	}
+	workspaceRole.Labels = retain(workspaceRole.Labels, labels, reservedWorkspaceRoleLabels)
+	workspaceRole.Annotations = retain(workspaceRole.Annotations, annotations, reservedWorkspaceRoleAnnotations)
+}
+
+func (am *amOperator) CreateOrUpdateWorkspaceRole(workspace string, workspaceRole *iamv1alpha2.WorkspaceRole) (*iamv1alpha2.WorkspaceRole, error) {
+	if workspaceRole.ResourceVersion != "" {
+		existing, err := am.GetWorkspaceRole(workspace, workspaceRole.Name)
+		if err != nil {
+			return nil, err
+		}
+		retainReservedMetadata(workspaceRole, existing)
+		if workspaceRole.Labels == nil {
+			workspaceRole.Labels = make(map[string]string)
+		}
+		workspaceRole.Labels[tenantv1alpha2.WorkspaceLabel] = workspace
+		return am.aiClient.IamV1alpha2().WorkspaceRoles().Update(context.Background(), workspaceRole, metav1.UpdateOptions{})
+	}
+
+	if strings.HasPrefix(workspaceRole.Name, roleTemplatePrefix) {
+		return nil, errors.NewBadRequest(fmt.Sprintf("the name prefix %s is reserved for the role templates", roleTemplatePrefix))
+	}
+	// the name of a workspace role is cluster wide, do not disclose the roles of other workspaces
+	existing, err := am.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().WorkspaceRoles().Lister().Get(workspaceRole.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if existing != nil && existing.Labels[tenantv1alpha2.WorkspaceLabel] != workspace {
+		return nil, errors.NewForbidden(iamv1alpha2.Resource(iamv1alpha2.ResourcesSingularWorkspaceRole), workspaceRole.Name,
+			fmt.Errorf("the name is used by another workspace"))
+	}
+	retainReservedMetadata(workspaceRole, nil)
+	if workspaceRole.Labels == nil {
+		workspaceRole.Labels = make(map[string]string)
+	}
+	workspaceRole.Labels[tenantv1alpha2.WorkspaceLabel] = workspace
+	return am.aiClient.IamV1alpha2().WorkspaceRoles().Create(context.Background(), workspaceRole, metav1.CreateOptions{})
+}
+
+func (am *amOperator) PatchWorkspaceRole(workspace string, workspaceRole *iamv1alpha2.WorkspaceRole) (*iamv1alpha2.WorkspaceRole, error) {
+	existing, err := am.GetWorkspaceRole(workspace, workspaceRole.Name)
+	if err != nil {
+		return nil, err
+	}
+	// the reserved metadata and the workspace label can not be changed
+	retainReservedMetadata(workspaceRole, existing)
+	if workspaceRole.Labels != nil {
+		workspaceRole.Labels[tenantv1alpha2.WorkspaceLabel] = workspace
+	}
+	data, err := json.Marshal(workspaceRole)
+	if err != nil {
+		return nil, err
+	}
+	return am.aiClient.IamV1alpha2().WorkspaceRoles().Patch(context.Background(), workspaceRole.Name, types.MergePatchType, data, metav1.PatchOptions{})
+}
+
+func (am *amOperator) DeleteWorkspaceRole(workspace string, name string) error {
+	if _, err := am.GetWorkspaceRole(workspace, name); err != nil {
+		return err
+	}
+	return am.aiClient.IamV1alpha2().WorkspaceRoles().Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+// CreateOrUpdateWorkspaceRoleBinding binds the user to the given role of the workspace,
+// any other role the user was bound to in the workspace is revoked.
+func (am *amOperator) CreateOrUpdateWorkspaceRoleBinding(username string, workspace string, role string) error {
+	if _, err := am.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().Users().Lister().Get(username); err != nil {
+		return err
+	}
+
+	workspaceRole, err := am.GetWorkspaceRole(workspace, role)
+	if err != nil {
+		return err
+	}
+
+	roleBindings, err := am.ListWorkspaceRoleBindings(username, nil, workspace)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, roleBinding := range roleBindings {
+		if roleBinding.RoleRef.Name == workspaceRole.Name {
+			found = true
+			continue
+		}
+		err := am.aiClient.IamV1alpha2().WorkspaceRoleBindings().Delete(context.Background(), roleBinding.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			klog.Error(err)
+			return err
+		}
+	}
+
+	if found {
+		return nil
+	}
+
+	roleBinding := &iamv1alpha2.WorkspaceRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s-%s", username, workspaceRole.Name),
+			Labels: map[string]string{
+				iamv1alpha2.UserReferenceLabel: username,
+				tenantv1alpha2.WorkspaceLabel:  workspace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: iamv1alpha2.SchemeGroupVersion.String(),
+			Kind:     iamv1alpha2.ResourceKindWorkspaceRole,
+			Name:     workspaceRole.Name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Name:     username,
+				Kind:     iamv1alpha2.ResourceKindUser,
+				APIGroup: iamv1alpha2.SchemeGroupVersion.String(),
+			},
+		},
+	}
+
+	if _, err := am.aiClient.IamV1alpha2().WorkspaceRoleBindings().Create(context.Background(), roleBinding, metav1.CreateOptions{}); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (am *amOperator) RemoveUserFromWorkspace(username string, workspace string) error {
+	roleBindings, err := am.ListWorkspaceRoleBindings(username, nil, workspace)
+	if err != nil {
+		return err
+	}
+	for _, roleBinding := range roleBindings {
+		err := am.aiClient.IamV1alpha2().WorkspaceRoleBindings().Delete(context.Background(), roleBinding.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			klog.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+func containsSubject(subjects []rbacv1.Subject, username string, groups []string) bool {
+	for _, subject := range subjects {
+		if subject.Kind == rbacv1.UserKind && subject.Name == username {
+			return true
+		}
+		if subject.Kind == rbacv1.GroupKind && sliceutil.HasString(groups, subject.Name) {
+			return true
+		}
+	}
+	return false
+}
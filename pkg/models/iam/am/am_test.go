@@ -0,0 +1,127 @@
+package am
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
+	fakeaiscope "aiscope/pkg/client/clientset/versioned/fake"
+	"aiscope/pkg/constants"
+	"aiscope/pkg/informers"
+)
+
+func newWorkspaceRole(name string, workspace string) *iamv1alpha2.WorkspaceRole {
+	return &iamv1alpha2.WorkspaceRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{tenantv1alpha2.WorkspaceLabel: workspace},
+			Annotations: map[string]string{iamv1alpha2.AggregationRolesAnnotation: `["role-template-view-projects"]`},
+		},
+	}
+}
+
+// newTestOperator stores the roles in both the client and the informers
+func newTestOperator(t *testing.T, roles ...*iamv1alpha2.WorkspaceRole) (AccessManagementInterface, *fakeaiscope.Clientset) {
+	client := fakeaiscope.NewSimpleClientset()
+	factory := informers.NewInformerFactories(fakek8s.NewSimpleClientset(), client)
+	indexer := factory.AIScopeSharedInformerFactory().Iam().V1alpha2().WorkspaceRoles().Informer().GetIndexer()
+	for _, role := range roles {
+		created, err := client.IamV1alpha2().WorkspaceRoles().Create(context.Background(), role, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = indexer.Add(created); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewOperator(client, factory), client
+}
+
+func TestCreateWorkspaceRole(t *testing.T) {
+	operator, _ := newTestOperator(t, newWorkspaceRole("other-admin", "other"))
+
+	tests := []struct {
+		description string
+		name        string
+		expected    func(error) bool
+	}{
+		{"role template name", "role-template-manage-members", errors.IsBadRequest},
+		{"name used by another workspace", "other-admin", errors.IsForbidden},
+		{"custom role", "reviewer", func(err error) bool { return err == nil }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			role := newWorkspaceRole(test.name, "other")
+			role.Labels[iamv1alpha2.RoleTemplateLabel] = "true"
+			role.Annotations[constants.CreatorAnnotationKey] = constants.SystemCreator
+			created, err := operator.CreateOrUpdateWorkspaceRole("ws", role)
+			if !test.expected(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if created.Labels[tenantv1alpha2.WorkspaceLabel] != "ws" {
+				t.Errorf("the role should be labeled with its workspace")
+			}
+			if _, ok := created.Labels[iamv1alpha2.RoleTemplateLabel]; ok {
+				t.Errorf("the role template label should be stripped")
+			}
+			for _, key := range []string{iamv1alpha2.AggregationRolesAnnotation, constants.CreatorAnnotationKey} {
+				if _, ok := created.Annotations[key]; ok {
+					t.Errorf("the annotation %s should be stripped", key)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateWorkspaceRoleRetainsReservedMetadata(t *testing.T) {
+	stored := newWorkspaceRole("ws-admin", "ws")
+	stored.Annotations[constants.CreatorAnnotationKey] = constants.SystemCreator
+	operator, client := newTestOperator(t, stored)
+
+	existing, err := client.IamV1alpha2().WorkspaceRoles().Get(context.Background(), "ws-admin", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	role := existing.DeepCopy()
+	// the fake client does not set the resource version
+	role.ResourceVersion = "1"
+	role.Labels = map[string]string{iamv1alpha2.RoleTemplateLabel: "true"}
+	role.Annotations = map[string]string{"aiscope.io/description": "updated"}
+
+	updated, err := operator.CreateOrUpdateWorkspaceRole("ws", role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := updated.Labels[iamv1alpha2.RoleTemplateLabel]; ok {
+		t.Errorf("the role template label should be stripped")
+	}
+	if updated.Labels[tenantv1alpha2.WorkspaceLabel] != "ws" {
+		t.Errorf("the workspace label should be retained")
+	}
+	for _, key := range []string{iamv1alpha2.AggregationRolesAnnotation, constants.CreatorAnnotationKey} {
+		if updated.Annotations[key] != existing.Annotations[key] {
+			t.Errorf("the annotation %s should be retained", key)
+		}
+	}
+
+	patch := &iamv1alpha2.WorkspaceRole{ObjectMeta: metav1.ObjectMeta{
+		Name:        "ws-admin",
+		Annotations: map[string]string{iamv1alpha2.AggregationRolesAnnotation: `["role-template-manage-members"]`},
+	}}
+	patched, err := operator.PatchWorkspaceRole("ws", patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Annotations[iamv1alpha2.AggregationRolesAnnotation] != existing.Annotations[iamv1alpha2.AggregationRolesAnnotation] {
+		t.Errorf("the aggregation roles should not be patched")
+	}
+}
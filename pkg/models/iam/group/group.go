@@ -0,0 +1,380 @@
+package group
+
+import (
+	"aiscope/pkg/api"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	aiscope "aiscope/pkg/client/clientset/versioned"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/informers"
+	resourcev1alpha2 "aiscope/pkg/models/resources/v1alpha2/resource"
+	"context"
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+)
+
+type GroupOperator interface {
+	ListGroups(workspace string, queryParam *query.Query) (*api.ListResult, error)
+	CreateGroup(workspace string, group *iamv1alpha2.Group) (*iamv1alpha2.Group, error)
+	DescribeGroup(workspace string, name string) (*iamv1alpha2.Group, error)
+	UpdateGroup(workspace string, group *iamv1alpha2.Group) (*iamv1alpha2.Group, error)
+	PatchGroup(workspace string, group *iamv1alpha2.Group) (*iamv1alpha2.Group, error)
+	DeleteGroup(workspace string, name string) error
+	ListGroupBindings(workspace string, queryParam *query.Query) (*api.ListResult, error)
+	CreateGroupBinding(workspace string, group string, username string) (*iamv1alpha2.GroupBinding, error)
+	DeleteGroupBinding(workspace string, name string) error
+	ListWorkspaceRoleBindings(workspace string, queryParam *query.Query) (*api.ListResult, error)
+	CreateWorkspaceRoleBinding(workspace string, group string, role string) (*iamv1alpha2.WorkspaceRoleBinding, error)
+	DeleteWorkspaceRoleBinding(workspace string, name string) error
+	ListRoleBindings(workspace string, namespace string, queryParam *query.Query) (*api.ListResult, error)
+	CreateRoleBinding(workspace string, namespace string, group string, role string) (*rbacv1.RoleBinding, error)
+	DeleteRoleBinding(workspace string, namespace string, name string) error
+}
+
+type groupOperator struct {
+	k8sClient      kubernetes.Interface
+	aiClient       aiscope.Interface
+	informers      informers.InformerFactory
+	resourceGetter *resourcev1alpha2.ResourceGetter
+}
+
+func New(k8sClient kubernetes.Interface, aiClient aiscope.Interface, factory informers.InformerFactory) GroupOperator {
+	return &groupOperator{
+		k8sClient:      k8sClient,
+		aiClient:       aiClient,
+		informers:      factory,
+		resourceGetter: resourcev1alpha2.NewResourceGetter(factory),
+	}
+}
+
+func (o *groupOperator) ListGroups(workspace string, queryParam *query.Query) (*api.ListResult, error) {
+	queryParam.LabelSelector = withSelector(queryParam.LabelSelector, tenantv1alpha2.WorkspaceLabel, workspace)
+	return o.resourceGetter.List(iamv1alpha2.ResourcePluralGroup, "", queryParam)
+}
+
+// CreateGroup creates the group in the workspace, a group nested in another group
+// references its parent with the GroupParent label, the parent must live in the same workspace.
+func (o *groupOperator) CreateGroup(workspace string, group *iamv1alpha2.Group) (*iamv1alpha2.Group, error) {
+	if group.Labels == nil {
+		group.Labels = make(map[string]string)
+	}
+	group.Labels[tenantv1alpha2.WorkspaceLabel] = workspace
+
+	if err := o.validateParent(workspace, group); err != nil {
+		return nil, err
+	}
+
+	return o.aiClient.IamV1alpha2().Groups().Create(context.Background(), group, metav1.CreateOptions{})
+}
+
+// DescribeGroup returns the group only if it is owned by the workspace,
+// groups of other workspaces are reported as not found.
+func (o *groupOperator) DescribeGroup(workspace string, name string) (*iamv1alpha2.Group, error) {
+	group, err := o.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().Groups().Lister().Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if group.Labels[tenantv1alpha2.WorkspaceLabel] != workspace {
+		return nil, errors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcePluralGroup), name)
+	}
+	return group, nil
+}
+
+func (o *groupOperator) UpdateGroup(workspace string, group *iamv1alpha2.Group) (*iamv1alpha2.Group, error) {
+	if _, err := o.DescribeGroup(workspace, group.Name); err != nil {
+		return nil, err
+	}
+	if group.Labels == nil {
+		group.Labels = make(map[string]string)
+	}
+	group.Labels[tenantv1alpha2.WorkspaceLabel] = workspace
+
+	if err := o.validateParent(workspace, group); err != nil {
+		return nil, err
+	}
+
+	return o.aiClient.IamV1alpha2().Groups().Update(context.Background(), group, metav1.UpdateOptions{})
+}
+
+func (o *groupOperator) PatchGroup(workspace string, group *iamv1alpha2.Group) (*iamv1alpha2.Group, error) {
+	old, err := o.DescribeGroup(workspace, group.Name)
+	if err != nil {
+		return nil, err
+	}
+	// the workspace label can not be changed
+	if group.Labels != nil {
+		group.Labels[tenantv1alpha2.WorkspaceLabel] = workspace
+		if _, ok := group.Labels[iamv1alpha2.GroupParent]; ok {
+			patched := old.DeepCopy()
+			patched.Labels[iamv1alpha2.GroupParent] = group.Labels[iamv1alpha2.GroupParent]
+			if err := o.validateParent(workspace, patched); err != nil {
+				return nil, err
+			}
+		}
+	}
+	data, err := json.Marshal(group)
+	if err != nil {
+		return nil, err
+	}
+	return o.aiClient.IamV1alpha2().Groups().Patch(context.Background(), group.Name, types.MergePatchType, data, metav1.PatchOptions{})
+}
+
+// DeleteGroup deletes the group, the group controller removes the bindings of the group
+// and the garbage collector removes the child groups.
+func (o *groupOperator) DeleteGroup(workspace string, name string) error {
+	if _, err := o.DescribeGroup(workspace, name); err != nil {
+		return err
+	}
+	return o.aiClient.IamV1alpha2().Groups().Delete(context.Background(), name, *metav1.NewDeleteOptions(0))
+}
+
+func (o *groupOperator) ListGroupBindings(workspace string, queryParam *query.Query) (*api.ListResult, error) {
+	queryParam.LabelSelector = withSelector(queryParam.LabelSelector, tenantv1alpha2.WorkspaceLabel, workspace)
+	return o.resourceGetter.List(iamv1alpha2.ResourcePluralGroupBinding, "", queryParam)
+}
+
+// CreateGroupBinding adds the user to the group, the groupbinding controller
+// keeps the groups of the user in sync with the bindings.
+func (o *groupOperator) CreateGroupBinding(workspace string, group string, username string) (*iamv1alpha2.GroupBinding, error) {
+	if _, err := o.DescribeGroup(workspace, group); err != nil {
+		return nil, err
+	}
+	if _, err := o.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().Users().Lister().Get(username); err != nil {
+		return nil, err
+	}
+
+	groupBinding := &iamv1alpha2.GroupBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s-%s", group, username),
+			Labels: map[string]string{
+				iamv1alpha2.UserReferenceLabel:  username,
+				iamv1alpha2.GroupReferenceLabel: group,
+				tenantv1alpha2.WorkspaceLabel:   workspace,
+			},
+		},
+		GroupRef: iamv1alpha2.GroupRef{
+			APIGroup: iamv1alpha2.SchemeGroupVersion.String(),
+			Kind:     iamv1alpha2.ResourceKindGroup,
+			Name:     group,
+		},
+		Users: []string{username},
+	}
+
+	return o.aiClient.IamV1alpha2().GroupBindings().Create(context.Background(), groupBinding, metav1.CreateOptions{})
+}
+
+func (o *groupOperator) DeleteGroupBinding(workspace string, name string) error {
+	groupBinding, err := o.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().GroupBindings().Lister().Get(name)
+	if err != nil {
+		return err
+	}
+	if groupBinding.Labels[tenantv1alpha2.WorkspaceLabel] != workspace {
+		return errors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcePluralGroupBinding), name)
+	}
+	return o.aiClient.IamV1alpha2().GroupBindings().Delete(context.Background(), name, *metav1.NewDeleteOptions(0))
+}
+
+// ListWorkspaceRoleBindings lists the workspace role bindings of groups, bindings of workspace members are not included.
+func (o *groupOperator) ListWorkspaceRoleBindings(workspace string, queryParam *query.Query) (*api.ListResult, error) {
+	queryParam.LabelSelector = withSelector(queryParam.LabelSelector, tenantv1alpha2.WorkspaceLabel, workspace)
+	queryParam.LabelSelector = withSelector(queryParam.LabelSelector, iamv1alpha2.GroupReferenceLabel, "")
+	return o.resourceGetter.List(iamv1alpha2.ResourcesPluralWorkspaceRoleBinding, "", queryParam)
+}
+
+func (o *groupOperator) CreateWorkspaceRoleBinding(workspace string, group string, role string) (*iamv1alpha2.WorkspaceRoleBinding, error) {
+	if err := o.checkBindableGroup(workspace, group); err != nil {
+		return nil, err
+	}
+	workspaceRole, err := o.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().WorkspaceRoles().Lister().Get(role)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceRole.Labels[tenantv1alpha2.WorkspaceLabel] != workspace {
+		return nil, errors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralWorkspaceRole), role)
+	}
+
+	roleBinding := &iamv1alpha2.WorkspaceRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s-%s", group, workspaceRole.Name),
+			Labels: map[string]string{
+				iamv1alpha2.GroupReferenceLabel: group,
+				tenantv1alpha2.WorkspaceLabel:   workspace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: iamv1alpha2.SchemeGroupVersion.String(),
+			Kind:     iamv1alpha2.ResourceKindWorkspaceRole,
+			Name:     workspaceRole.Name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Name:     group,
+				Kind:     rbacv1.GroupKind,
+				APIGroup: rbacv1.GroupName,
+			},
+		},
+	}
+
+	return o.aiClient.IamV1alpha2().WorkspaceRoleBindings().Create(context.Background(), roleBinding, metav1.CreateOptions{})
+}
+
+func (o *groupOperator) DeleteWorkspaceRoleBinding(workspace string, name string) error {
+	roleBinding, err := o.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().WorkspaceRoleBindings().Lister().Get(name)
+	if err != nil {
+		return err
+	}
+	if roleBinding.Labels[tenantv1alpha2.WorkspaceLabel] != workspace || roleBinding.Labels[iamv1alpha2.GroupReferenceLabel] == "" {
+		return errors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralWorkspaceRoleBinding), name)
+	}
+	return o.aiClient.IamV1alpha2().WorkspaceRoleBindings().Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+// ListRoleBindings lists the role bindings of groups in the namespace, the namespace must belong to the workspace.
+func (o *groupOperator) ListRoleBindings(workspace string, namespace string, queryParam *query.Query) (*api.ListResult, error) {
+	if err := o.checkNamespace(workspace, namespace); err != nil {
+		return nil, err
+	}
+	queryParam.LabelSelector = withSelector(queryParam.LabelSelector, iamv1alpha2.GroupReferenceLabel, "")
+	return o.resourceGetter.List(iamv1alpha2.ResourcesPluralRoleBinding, namespace, queryParam)
+}
+
+func (o *groupOperator) CreateRoleBinding(workspace string, namespace string, group string, role string) (*rbacv1.RoleBinding, error) {
+	if err := o.checkNamespace(workspace, namespace); err != nil {
+		return nil, err
+	}
+	if err := o.checkBindableGroup(workspace, group); err != nil {
+		return nil, err
+	}
+	if _, err := o.informers.KubernetesSharedInformerFactory().Rbac().V1().Roles().Lister().Roles(namespace).Get(role); err != nil {
+		return nil, err
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", group, role),
+			Namespace: namespace,
+			Labels: map[string]string{
+				iamv1alpha2.GroupReferenceLabel: group,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     iamv1alpha2.ResourceKindRole,
+			Name:     role,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Name:     group,
+				Kind:     rbacv1.GroupKind,
+				APIGroup: rbacv1.GroupName,
+			},
+		},
+	}
+
+	return o.k8sClient.RbacV1().RoleBindings(namespace).Create(context.Background(), roleBinding, metav1.CreateOptions{})
+}
+
+func (o *groupOperator) DeleteRoleBinding(workspace string, namespace string, name string) error {
+	if err := o.checkNamespace(workspace, namespace); err != nil {
+		return err
+	}
+	roleBinding, err := o.informers.KubernetesSharedInformerFactory().Rbac().V1().RoleBindings().Lister().RoleBindings(namespace).Get(name)
+	if err != nil {
+		return err
+	}
+	if roleBinding.Labels[iamv1alpha2.GroupReferenceLabel] == "" {
+		return errors.NewNotFound(rbacv1.Resource(iamv1alpha2.ResourcesPluralRoleBinding), name)
+	}
+	return o.k8sClient.RbacV1().RoleBindings(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+// checkBindableGroup checks the group can be bound to the roles of the workspace, both the groups of the workspace
+// and the global groups, e.g. the groups synced from identity providers, are allowed.
+func (o *groupOperator) checkBindableGroup(workspace string, name string) error {
+	group, err := o.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().Groups().Lister().Get(name)
+	if err != nil {
+		return err
+	}
+	if owner, ok := group.Labels[tenantv1alpha2.WorkspaceLabel]; ok && owner != workspace {
+		return errors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcePluralGroup), name)
+	}
+	return nil
+}
+
+func (o *groupOperator) checkNamespace(workspace string, namespace string) error {
+	ns, err := o.informers.KubernetesSharedInformerFactory().Core().V1().Namespaces().Lister().Get(namespace)
+	if err != nil {
+		return err
+	}
+	if ns.Labels[tenantv1alpha2.WorkspaceLabel] != workspace {
+		return errors.NewNotFound(corev1.Resource("namespaces"), namespace)
+	}
+	return nil
+}
+
+// validateParent makes sure the parent group lives in the same workspace and
+// the group is not one of its own ancestors.
+func (o *groupOperator) validateParent(workspace string, group *iamv1alpha2.Group) error {
+	parent := group.Labels[iamv1alpha2.GroupParent]
+	if parent == "" {
+		return nil
+	}
+	groupLister := o.informers.AIScopeSharedInformerFactory().Iam().V1alpha2().Groups().Lister()
+	visited := sets.NewString(group.Name)
+	for parent != "" {
+		if visited.Has(parent) {
+			return errors.NewBadRequest(fmt.Sprintf("group %s can not be nested in its descendant %s", group.Name, group.Labels[iamv1alpha2.GroupParent]))
+		}
+		visited.Insert(parent)
+		g, err := groupLister.Get(parent)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return errors.NewBadRequest(fmt.Sprintf("parent group %s not found", parent))
+			}
+			return err
+		}
+		if g.Labels[tenantv1alpha2.WorkspaceLabel] != workspace {
+			return errors.NewBadRequest(fmt.Sprintf("parent group %s does not belong to workspace %s", parent, workspace))
+		}
+		parent = g.Labels[iamv1alpha2.GroupParent]
+	}
+	return nil
+}
+
+// ResolveGroups returns the groups together with all their ancestors, so a member of a child group
+// inherits the roles bound to the parent groups. Groups not managed by aiscope are kept as is.
+func ResolveGroups(groupLister iamv1alpha2listers.GroupLister, groups []string) []string {
+	resolved := make([]string, 0, len(groups))
+	visited := sets.NewString()
+	for _, name := range groups {
+		for name != "" && !visited.Has(name) {
+			visited.Insert(name)
+			resolved = append(resolved, name)
+			group, err := groupLister.Get(name)
+			if err != nil {
+				break
+			}
+			name = group.Labels[iamv1alpha2.GroupParent]
+		}
+	}
+	return resolved
+}
+
+func withSelector(labelSelector string, key string, value string) string {
+	selector := key
+	if value != "" {
+		selector = fmt.Sprintf("%s=%s", key, value)
+	}
+	if labelSelector == "" {
+		return selector
+	}
+	return labelSelector + "," + selector
+}
@@ -0,0 +1,80 @@
+package group
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+)
+
+func newGroup(name, parent string) *iamv1alpha2.Group {
+	group := &iamv1alpha2.Group{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+	if parent != "" {
+		group.Labels[iamv1alpha2.GroupParent] = parent
+	}
+	return group
+}
+
+func TestResolveGroups(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, group := range []*iamv1alpha2.Group{
+		newGroup("root", ""),
+		newGroup("dev", "root"),
+		newGroup("backend", "dev"),
+		newGroup("ops", "root"),
+		// a broken hierarchy must not loop forever
+		newGroup("a", "b"),
+		newGroup("b", "a"),
+	} {
+		if err := indexer.Add(group); err != nil {
+			t.Fatal(err)
+		}
+	}
+	groupLister := iamv1alpha2listers.NewGroupLister(indexer)
+
+	tests := []struct {
+		description string
+		groups      []string
+		expected    []string
+	}{
+		{"no groups", nil, []string{}},
+		{"top level group", []string{"root"}, []string{"root"}},
+		{"nested group", []string{"backend"}, []string{"backend", "dev", "root"}},
+		{"shared ancestors", []string{"backend", "ops"}, []string{"backend", "dev", "root", "ops"}},
+		{"unmanaged group", []string{"ldap-admins", "dev"}, []string{"ldap-admins", "dev", "root"}},
+		{"cycle", []string{"a"}, []string{"a", "b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got := ResolveGroups(groupLister, test.groups)
+			if diff := cmp.Diff(got, test.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestGroupName(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		group    string
+		expected string
+	}{
+		{"ldap-", "developers", "ldap-developers"},
+		{"ldap-", "Domain Admins", "ldap-domain-admins"},
+		{"dex-", "org:team_a", "dex-org-team-a"},
+		{"dex-", "/platform/", "dex-platform"},
+		{"oidc:", "admins", "oidc-admins"},
+	}
+
+	for _, test := range tests {
+		if got := GroupName(test.prefix, test.group); got != test.expected {
+			t.Errorf("GroupName(%q, %q) = %q, want %q", test.prefix, test.group, got, test.expected)
+		}
+	}
+}
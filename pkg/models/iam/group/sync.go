@@ -0,0 +1,195 @@
+package group
+
+import (
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authentication/identityprovider"
+	"aiscope/pkg/apiserver/authentication/oauth"
+	aiscope "aiscope/pkg/client/clientset/versioned"
+	"context"
+	"fmt"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"regexp"
+	"strings"
+)
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+	repeatedDashes   = regexp.MustCompile(`-{2,}`)
+)
+
+// Syncer mirrors the groups of identity providers into Group and GroupBinding objects,
+// all the mirrored objects are labelled with the identity provider.
+type Syncer interface {
+	// SyncGroups mirrors all the groups of the identity provider and their members,
+	// groups and bindings no longer present in the identity provider are removed.
+	SyncGroups(provider *oauth.IdentityProviderOptions, groups []identityprovider.Group) error
+	// SyncUserGroups refreshes the memberships of the user in the groups of the identity provider,
+	// the names of the mirrored groups are returned.
+	SyncUserGroups(provider *oauth.IdentityProviderOptions, username string, groups []string) ([]string, error)
+}
+
+type syncer struct {
+	aiClient aiscope.Interface
+}
+
+func NewSyncer(aiClient aiscope.Interface) Syncer {
+	return &syncer{aiClient: aiClient}
+}
+
+// GroupName returns the name of the Group mirrored from the group of the identity provider.
+func GroupName(prefix string, group string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(prefix+group), "-")
+	return strings.Trim(repeatedDashes.ReplaceAllString(name, "-"), "-.")
+}
+
+func (s *syncer) SyncGroups(provider *oauth.IdentityProviderOptions, groups []identityprovider.Group) error {
+	idp := provider.Name
+	users, err := s.aiClient.IamV1alpha2().Users().List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{iamv1alpha2.IdentifyProviderLabel: idp}).String(),
+	})
+	if err != nil {
+		return err
+	}
+	// only the users mapped to the identity provider can be bound
+	usernames := make(map[string]string, len(users.Items))
+	for _, user := range users.Items {
+		usernames[user.Labels[iamv1alpha2.OriginUIDLabel]] = user.Name
+	}
+
+	expectedGroups := sets.NewString()
+	expectedBindings := make(map[string]bindingKey)
+	for _, group := range groups {
+		name := GroupName(provider.GroupPrefix(), group.Name)
+		if err := s.ensureGroup(idp, name, group.Name); err != nil {
+			return err
+		}
+		expectedGroups.Insert(name)
+		for _, member := range group.Members {
+			if username, ok := usernames[member]; ok {
+				key := bindingKey{group: name, username: username}
+				expectedBindings[key.name()] = key
+			}
+		}
+	}
+
+	if err := s.syncBindings(idp, labels.Set{iamv1alpha2.IdentifyProviderLabel: idp}, expectedBindings); err != nil {
+		return err
+	}
+
+	existing, err := s.aiClient.IamV1alpha2().Groups().List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{iamv1alpha2.IdentifyProviderLabel: idp}).String(),
+	})
+	if err != nil {
+		return err
+	}
+	for _, group := range existing.Items {
+		if expectedGroups.Has(group.Name) {
+			continue
+		}
+		// the group controller deletes the bindings of the group
+		if err := s.aiClient.IamV1alpha2().Groups().Delete(context.Background(), group.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syncer) SyncUserGroups(provider *oauth.IdentityProviderOptions, username string, groups []string) ([]string, error) {
+	idp := provider.Name
+	names := make([]string, 0, len(groups))
+	expectedBindings := make(map[string]bindingKey)
+	for _, group := range groups {
+		name := GroupName(provider.GroupPrefix(), group)
+		if err := s.ensureGroup(idp, name, group); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		key := bindingKey{group: name, username: username}
+		expectedBindings[key.name()] = key
+	}
+	selector := labels.Set{iamv1alpha2.IdentifyProviderLabel: idp, iamv1alpha2.UserReferenceLabel: username}
+	if err := s.syncBindings(idp, selector, expectedBindings); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+type bindingKey struct {
+	group    string
+	username string
+}
+
+func (k bindingKey) name() string {
+	return fmt.Sprintf("%s-%s", k.group, k.username)
+}
+
+// syncBindings creates the expected bindings and deletes the other bindings matching the selector.
+func (s *syncer) syncBindings(idp string, selector labels.Set, expected map[string]bindingKey) error {
+	existing, err := s.aiClient.IamV1alpha2().GroupBindings().List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	found := sets.NewString()
+	for _, groupBinding := range existing.Items {
+		if _, ok := expected[groupBinding.Name]; ok {
+			found.Insert(groupBinding.Name)
+			continue
+		}
+		if err := s.aiClient.IamV1alpha2().GroupBindings().Delete(context.Background(), groupBinding.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		klog.V(4).Infof("user %v removed from group %s", groupBinding.Users, groupBinding.GroupRef.Name)
+	}
+
+	for name, key := range expected {
+		if found.Has(name) {
+			continue
+		}
+		groupBinding := &iamv1alpha2.GroupBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					iamv1alpha2.IdentifyProviderLabel: idp,
+					iamv1alpha2.GroupReferenceLabel:   key.group,
+					iamv1alpha2.UserReferenceLabel:    key.username,
+				},
+			},
+			GroupRef: iamv1alpha2.GroupRef{
+				APIGroup: iamv1alpha2.SchemeGroupVersion.String(),
+				Kind:     iamv1alpha2.ResourceKindGroup,
+				Name:     key.group,
+			},
+			Users: []string{key.username},
+		}
+		if _, err := s.aiClient.IamV1alpha2().GroupBindings().Create(context.Background(), groupBinding, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		klog.V(4).Infof("user %s added to group %s", key.username, key.group)
+	}
+	return nil
+}
+
+func (s *syncer) ensureGroup(idp string, name string, origin string) error {
+	group := &iamv1alpha2.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				iamv1alpha2.IdentifyProviderLabel: idp,
+			},
+			Annotations: map[string]string{
+				iamv1alpha2.OriginGroupAnnotation: origin,
+			},
+		},
+	}
+	if _, err := s.aiClient.IamV1alpha2().Groups().Create(context.Background(), group, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
@@ -3,25 +3,40 @@ package im
 import (
 	"aiscope/pkg/api"
 	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authentication"
 	"aiscope/pkg/apiserver/query"
 	aiscope "aiscope/pkg/client/clientset/versioned"
 	resources "aiscope/pkg/models/resources/v1alpha2"
 	"context"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
+	"time"
 )
 
 type IdentityManagementInterface interface {
 	CreateUser(user *iamv1alpha2.User) (*iamv1alpha2.User, error)
 	ListUsers(query *query.Query) (result *api.ListResult, err error)
 	DescribeUser(username string) (*iamv1alpha2.User, error)
+	UpdateUser(user *iamv1alpha2.User) (*iamv1alpha2.User, error)
+	PatchUser(user *iamv1alpha2.User) (*iamv1alpha2.User, error)
+	DeleteUser(username string) error
+	ModifyPassword(username string, currentPassword string, password string, verify bool) error
+	UpdateUserState(username string, state iamv1alpha2.UserState) (*iamv1alpha2.User, error)
+	UnlockUser(username string) (*iamv1alpha2.User, error)
+	ListLoginRecords(username string, query *query.Query) (*api.ListResult, error)
 }
 
-func NewOperator(aiClient aiscope.Interface, userGetter resources.Interface, loginRecordGetter resources.Interface) IdentityManagementInterface {
+func NewOperator(aiClient aiscope.Interface, userGetter resources.Interface, loginRecordGetter resources.Interface, options *authentication.Options) IdentityManagementInterface {
 	im := &imOperator{
 		aiClient:          aiClient,
 		userGetter:		   userGetter,
 		loginRecordGetter: loginRecordGetter,
+		options:           options,
 	}
 	return im
 }
@@ -30,9 +45,16 @@ type imOperator struct {
 	aiClient          aiscope.Interface
 	userGetter        resources.Interface
 	loginRecordGetter resources.Interface
+	options           *authentication.Options
 }
 
 func (im *imOperator) CreateUser(user *iamv1alpha2.User) (*iamv1alpha2.User, error) {
+	// the plain text password is encrypted by the user controller
+	if password := user.Spec.EncryptedPassword; password != "" && !isEncrypted(password) {
+		if err := im.options.PasswordPolicy.Validate(password); err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+	}
 	user, err := im.aiClient.IamV1alpha2().Users().Create(context.Background(), user, metav1.CreateOptions{})
 	if err != nil {
 		klog.Error(err)
@@ -68,9 +90,256 @@ func (im *imOperator) DescribeUser(username string) (*iamv1alpha2.User, error) {
 	return ensurePasswordNotOutput(user), nil
 }
 
+// UpdateUser updates the user, the password and the status are kept as they are,
+// use ModifyPassword and UpdateUserState to change them.
+func (im *imOperator) UpdateUser(user *iamv1alpha2.User) (*iamv1alpha2.User, error) {
+	old, err := im.fetch(user.Name)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	user = user.DeepCopy()
+	user.Spec.EncryptedPassword = old.Spec.EncryptedPassword
+	keepPasswordAnnotations(user, old)
+	// groups are maintained by the groupbinding controller
+	user.Spec.Groups = old.Spec.Groups
+	user.Status = old.Status
+	if user.ResourceVersion == "" {
+		user.ResourceVersion = old.ResourceVersion
+	}
+	updated, err := im.aiClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return ensurePasswordNotOutput(updated), nil
+}
+
+func (im *imOperator) PatchUser(user *iamv1alpha2.User) (*iamv1alpha2.User, error) {
+	old, err := im.fetch(user.Name)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	user = user.DeepCopy()
+	// the password, the groups and the status can't be patched
+	user.Spec.EncryptedPassword = ""
+	for _, annotation := range passwordAnnotations {
+		delete(user.Annotations, annotation)
+	}
+	user.Spec.Groups = nil
+	user.Status = iamv1alpha2.UserStatus{}
+	// email is always serialized, keep it if absent from the patch
+	if user.Spec.Email == "" {
+		user.Spec.Email = old.Spec.Email
+	}
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := im.aiClient.IamV1alpha2().Users().Patch(context.Background(), user.Name, types.MergePatchType, data, metav1.PatchOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return ensurePasswordNotOutput(patched), nil
+}
+
+func (im *imOperator) DeleteUser(username string) error {
+	return im.aiClient.IamV1alpha2().Users().Delete(context.Background(), username, *metav1.NewDeleteOptions(0))
+}
+
+// ModifyPassword sets the password of the user, the current password is verified if required.
+// The password is encrypted before it is stored, the plain text never reaches the user object.
+func (im *imOperator) ModifyPassword(username string, currentPassword string, password string, verify bool) error {
+	user, err := im.fetch(username)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	if verify {
+		if user.Spec.EncryptedPassword == "" ||
+			bcrypt.CompareHashAndPassword([]byte(user.Spec.EncryptedPassword), []byte(currentPassword)) != nil {
+			return errors.NewBadRequest("incorrect current password")
+		}
+	}
+
+	policy := im.options.PasswordPolicy
+	if err = policy.Validate(password); err != nil {
+		return errors.NewBadRequest(err.Error())
+	}
+	// the current password is the most recent one
+	var history []string
+	if user.Spec.EncryptedPassword != "" {
+		history = append(history, user.Spec.EncryptedPassword)
+	}
+	if data := user.Annotations[iamv1alpha2.PasswordHistoryAnnotation]; data != "" {
+		var previous []string
+		if err = json.Unmarshal([]byte(data), &previous); err != nil {
+			klog.Warningf("invalid password history of user %s: %v", username, err)
+		}
+		history = append(history, previous...)
+	}
+	if len(history) > policy.HistorySize {
+		history = history[:policy.HistorySize]
+	}
+	for _, previous := range history {
+		if bcrypt.CompareHashAndPassword([]byte(previous), []byte(password)) == nil {
+			return errors.NewBadRequest(fmt.Sprintf("the password must not be one of the last %d passwords", policy.HistorySize))
+		}
+	}
+
+	encrypted, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	user = user.DeepCopy()
+	user.Spec.EncryptedPassword = string(encrypted)
+	if user.Annotations == nil {
+		user.Annotations = make(map[string]string)
+	}
+	user.Annotations[iamv1alpha2.LastPasswordChangeTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	delete(user.Annotations, iamv1alpha2.UninitializedAnnotation)
+	// the new password is kept in the spec, the history holds the previous ones
+	keep := policy.HistorySize - 1
+	if keep < 0 {
+		keep = 0
+	}
+	if len(history) > keep {
+		history = history[:keep]
+	}
+	if len(history) > 0 {
+		data, err := json.Marshal(history)
+		if err != nil {
+			return err
+		}
+		user.Annotations[iamv1alpha2.PasswordHistoryAnnotation] = string(data)
+	} else {
+		delete(user.Annotations, iamv1alpha2.PasswordHistoryAnnotation)
+	}
+	_, err = im.aiClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// UpdateUserState enables or disables the user account
+func (im *imOperator) UpdateUserState(username string, state iamv1alpha2.UserState) (*iamv1alpha2.User, error) {
+	if state != iamv1alpha2.UserActive && state != iamv1alpha2.UserDisabled {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unsupported user state %q", state))
+	}
+
+	user, err := im.fetch(username)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	if user.Status.State != nil && *user.Status.State == state {
+		return ensurePasswordNotOutput(user), nil
+	}
+
+	user = user.DeepCopy()
+	user.Status.State = &state
+	user.Status.Reason = ""
+	if state == iamv1alpha2.UserDisabled {
+		user.Status.Reason = iamv1alpha2.UserDisabledByAdministrator
+	}
+	user.Status.LastTransitionTime = &metav1.Time{Time: time.Now()}
+	updated, err := im.aiClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return ensurePasswordNotOutput(updated), nil
+}
+
+// UnlockUser unblocks the user blocked by too many failed login attempts before the lockout expires,
+// the failed attempts before the unlock are no longer counted.
+func (im *imOperator) UnlockUser(username string) (*iamv1alpha2.User, error) {
+	user, err := im.fetch(username)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	if user.Status.State == nil || *user.Status.State != iamv1alpha2.UserAuthLimitExceeded {
+		return ensurePasswordNotOutput(user), nil
+	}
+
+	user = user.DeepCopy()
+	active := iamv1alpha2.UserActive
+	user.Status = iamv1alpha2.UserStatus{
+		State:              &active,
+		LastTransitionTime: &metav1.Time{Time: time.Now()},
+	}
+	updated, err := im.aiClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return ensurePasswordNotOutput(updated), nil
+}
+
+func (im *imOperator) ListLoginRecords(username string, queryParam *query.Query) (*api.ListResult, error) {
+	userSelector := fmt.Sprintf("%s=%s", iamv1alpha2.UserReferenceLabel, username)
+	if queryParam.LabelSelector == "" {
+		queryParam.LabelSelector = userSelector
+	} else {
+		queryParam.LabelSelector = queryParam.LabelSelector + "," + userSelector
+	}
+	result, err := im.loginRecordGetter.List("", queryParam)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// fetch returns the user from the cache, the encrypted password included
+func (im *imOperator) fetch(username string) (*iamv1alpha2.User, error) {
+	obj, err := im.userGetter.Get("", username)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*iamv1alpha2.User), nil
+}
+
 func ensurePasswordNotOutput(user *iamv1alpha2.User) *iamv1alpha2.User {
 	out := user.DeepCopy()
 	// ensure encrypted password will not be output
 	out.Spec.EncryptedPassword = ""
+	delete(out.Annotations, iamv1alpha2.PasswordHistoryAnnotation)
 	return out
+}
+
+// passwordAnnotations are maintained with the password, they can only be changed by ModifyPassword
+var passwordAnnotations = []string{
+	iamv1alpha2.LastPasswordChangeTimeAnnotation,
+	iamv1alpha2.PasswordHistoryAnnotation,
+	iamv1alpha2.UninitializedAnnotation,
+}
+
+func keepPasswordAnnotations(user *iamv1alpha2.User, old *iamv1alpha2.User) {
+	for _, annotation := range passwordAnnotations {
+		if value, ok := old.Annotations[annotation]; ok {
+			if user.Annotations == nil {
+				user.Annotations = make(map[string]string)
+			}
+			user.Annotations[annotation] = value
+		} else {
+			delete(user.Annotations, annotation)
+		}
+	}
+}
+
+func isEncrypted(password string) bool {
+	// cost > 0 means the password has been encrypted
+	cost, _ := bcrypt.Cost([]byte(password))
+	return cost > 0
 }
\ No newline at end of file
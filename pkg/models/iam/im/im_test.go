@@ -0,0 +1,90 @@
+package im
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"aiscope/pkg/api"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/authentication"
+	"aiscope/pkg/apiserver/query"
+	aiscope "aiscope/pkg/client/clientset/versioned"
+	fakeaiscope "aiscope/pkg/client/clientset/versioned/fake"
+)
+
+// userGetter reads the users from the client, the informers are not needed
+type userGetter struct {
+	aiClient aiscope.Interface
+}
+
+func (g *userGetter) Get(_, name string) (runtime.Object, error) {
+	return g.aiClient.IamV1alpha2().Users().Get(context.Background(), name, metav1.GetOptions{})
+}
+
+func (g *userGetter) List(_ string, _ *query.Query) (*api.ListResult, error) {
+	return &api.ListResult{}, nil
+}
+
+func TestModifyPasswordPolicy(t *testing.T) {
+	client := fakeaiscope.NewSimpleClientset()
+	admin := &iamv1alpha2.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "admin",
+			Annotations: map[string]string{iamv1alpha2.UninitializedAnnotation: "true"},
+		},
+	}
+	if _, err := client.IamV1alpha2().Users().Create(context.Background(), admin, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	options := authentication.NewOptions()
+	options.PasswordPolicy.MinLength = 8
+	options.PasswordPolicy.RequireDigit = true
+	options.PasswordPolicy.HistorySize = 2
+	operator := NewOperator(client, &userGetter{aiClient: client}, nil, options)
+
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"short1", true},
+		{"NoDigitsAtAll", true},
+		{"P@88w0rd1", false},
+		// the current password
+		{"P@88w0rd1", true},
+		{"P@88w0rd2", false},
+		// the previous password
+		{"P@88w0rd1", true},
+		{"P@88w0rd3", false},
+		// out of the history
+		{"P@88w0rd1", false},
+	}
+
+	for i, test := range tests {
+		err := operator.ModifyPassword("admin", "", test.password, false)
+		if (err != nil) != test.wantErr {
+			t.Fatalf("%d: ModifyPassword(%s) error = %v, wantErr %v", i, test.password, err, test.wantErr)
+		}
+	}
+
+	user, err := client.IamV1alpha2().Users().Get(context.Background(), "admin", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := user.Annotations[iamv1alpha2.UninitializedAnnotation]; ok {
+		t.Errorf("the password should be initialized")
+	}
+	if user.Annotations[iamv1alpha2.LastPasswordChangeTimeAnnotation] == "" {
+		t.Errorf("the last password change time should be recorded")
+	}
+
+	described, err := operator.DescribeUser("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := described.Annotations[iamv1alpha2.PasswordHistoryAnnotation]; ok || described.Spec.EncryptedPassword != "" {
+		t.Errorf("the passwords should not be output")
+	}
+}
@@ -30,39 +30,101 @@ import (
 
 const (
 	inClusterCAFilePath  = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
-	configMapPrefix      = "kubeconfig-"
-	kubeconfigNameFormat = configMapPrefix + "%s"
+	secretPrefix         = "kubeconfig-"
+	kubeconfigNameFormat = secretPrefix + "%s"
 	defaultClusterName   = "local"
 	defaultNamespace     = "default"
 	kubeconfigFileName   = "config"
-	configMapKind        = "ConfigMap"
-	configMapAPIVersion  = "v1"
+	privateKeyFileName   = "client.key"
+	kubeconfigKind       = "Config"
+	kubeconfigAPIVersion = "v1"
+	secretKind           = "Secret"
+	secretAPIVersion     = "v1"
+	// csrAnnotation records the CertificateSigningRequest the pending private key belongs to
+	csrAnnotation = "aiscope.io/certificate-signing-request"
+	// privateKeyAnnotation was used to carry the private key along with the CertificateSigningRequest,
+	// only kept to complete requests created before the private key moved to the secret
 	privateKeyAnnotation = "aiscope.io/private-key"
 	residual             = 72 * time.Hour
+
+	SecretTypeKubeConfig corev1.SecretType = "aiscope.io/kubeconfig"
 )
 
 type Interface interface {
+	GetKubeConfig(username string) (string, error)
 	CreateKubeConfig(user *iamv1alpha2.User) error
+	RegenerateKubeConfig(user *iamv1alpha2.User) error
 	UpdateKubeconfig(username string, csr *certificatesv1.CertificateSigningRequest) error
 }
 
 type operator struct {
-	k8sClient       kubernetes.Interface
-	configMapLister corev1listers.ConfigMapLister
-	config          *rest.Config
-	masterURL       string
+	k8sClient    kubernetes.Interface
+	secretLister corev1listers.SecretLister
+	config       *rest.Config
+	masterURL    string
+}
+
+// SecretName returns the name of the secret holding the kubeconfig of the user
+func SecretName(username string) string {
+	return fmt.Sprintf(kubeconfigNameFormat, username)
 }
 
-func NewOperator(k8sClient kubernetes.Interface, configMapLister corev1listers.ConfigMapLister, config *rest.Config) Interface {
-	return &operator{k8sClient: k8sClient, configMapLister: configMapLister, config: config}
+// FileName is the key of the kubeconfig in the secret
+const FileName = kubeconfigFileName
+
+func NewOperator(k8sClient kubernetes.Interface, secretLister corev1listers.SecretLister, config *rest.Config, masterURL string) Interface {
+	return &operator{k8sClient: k8sClient, secretLister: secretLister, config: config, masterURL: masterURL}
 }
 
-// CreateKubeConfig Create kubeconfig configmap in AiscopeControlNamespace for the specified user
+// GetKubeConfig returns the kubeconfig of the specified user, the server is rewritten to
+// the public address of the kubernetes apiserver if configured.
+func (o *operator) GetKubeConfig(username string) (string, error) {
+	secret, err := o.secretLister.Secrets(constants.AIScopeControlNamespace).Get(fmt.Sprintf(kubeconfigNameFormat, username))
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+
+	kubeconfig, err := clientcmd.Load(secret.Data[kubeconfigFileName])
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+
+	if _, ok := kubeconfig.AuthInfos[username]; !ok {
+		return "", errors.NewServiceUnavailable(fmt.Sprintf("the client certificate of user %s has not been issued yet", username))
+	}
+
+	if o.masterURL != "" {
+		for _, cluster := range kubeconfig.Clusters {
+			cluster.Server = o.masterURL
+		}
+	}
+
+	data, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	return string(data), nil
+}
+
+// CreateKubeConfig Create kubeconfig secret in AiscopeControlNamespace for the specified user
 func (o *operator) CreateKubeConfig(user *iamv1alpha2.User) error {
-	configName := fmt.Sprintf(kubeconfigNameFormat, user.Name)
-	cm, err := o.configMapLister.ConfigMaps(constants.AIScopeControlNamespace).Get(configName)
+	return o.createKubeConfig(user, false)
+}
+
+// RegenerateKubeConfig requests a new client certificate for the specified user regardless of the expiration,
+// the current certificate is kept in the kubeconfig until the new one is issued.
+func (o *operator) RegenerateKubeConfig(user *iamv1alpha2.User) error {
+	return o.createKubeConfig(user, true)
+}
+
+func (o *operator) createKubeConfig(user *iamv1alpha2.User, force bool) error {
+	secretName := fmt.Sprintf(kubeconfigNameFormat, user.Name)
+	secret, err := o.secretLister.Secrets(constants.AIScopeControlNamespace).Get(secretName)
 	// already exist and cert will not expire in 3 days
-	if err == nil && !isExpired(cm, user.Name) {
+	if err == nil && !force && !isExpired(secret, user.Name) {
 		return nil
 	}
 
@@ -72,27 +134,88 @@ func (o *operator) CreateKubeConfig(user *iamv1alpha2.User) error {
 		return err
 	}
 
-	// create a new CSR
-	var ca []byte
-	if len(o.config.CAData) > 0 {
-		ca = o.config.CAData
+	csr, key, err := newCSR(user.Name)
+	if err != nil {
+		klog.Errorln(err)
+		return err
+	}
+
+	// update secret if it already exist, keep the current certificate until the new one is issued.
+	if secret != nil {
+		secret = secret.DeepCopy()
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		secret.Annotations[csrAnnotation] = csr.Name
+		secret.Data[privateKeyFileName] = key
+		if _, err = o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+			klog.Errorln(err)
+			return err
+		}
 	} else {
-		ca, err = ioutil.ReadFile(inClusterCAFilePath)
+		kubeconfig, err := o.newKubeConfig(user.Name)
 		if err != nil {
 			klog.Errorln(err)
 			return err
 		}
+
+		secret = &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       secretKind,
+				APIVersion: secretAPIVersion,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        secretName,
+				Labels:      map[string]string{constants.UsernameLabelKey: user.Name},
+				Annotations: map[string]string{csrAnnotation: csr.Name},
+			},
+			Type: SecretTypeKubeConfig,
+			Data: map[string][]byte{kubeconfigFileName: kubeconfig, privateKeyFileName: key},
+		}
+
+		if err = controllerutil.SetControllerReference(user, secret, scheme.Scheme); err != nil {
+			klog.Errorln(err)
+			return err
+		}
+
+		if _, err = o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Create(context.Background(), secret, metav1.CreateOptions{}); err != nil {
+			klog.Errorln(err)
+			return err
+		}
+	}
+
+	// kubeconfig used to be stored in a configmap of the same name, private key included
+	err = o.k8sClient.CoreV1().ConfigMaps(constants.AIScopeControlNamespace).Delete(context.Background(), secretName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorln(err)
+		return err
 	}
 
-	if err = o.createCSR(user.Name); err != nil {
+	// create csr
+	if _, err = o.k8sClient.CertificatesV1().CertificateSigningRequests().Create(context.Background(), csr, metav1.CreateOptions{}); err != nil {
 		klog.Errorln(err)
 		return err
 	}
 
-	currentContext := fmt.Sprintf("%s@%s", user.Name, defaultClusterName)
+	return nil
+}
+
+func (o *operator) newKubeConfig(username string) ([]byte, error) {
+	var ca []byte
+	if len(o.config.CAData) > 0 {
+		ca = o.config.CAData
+	} else {
+		var err error
+		ca, err = ioutil.ReadFile(inClusterCAFilePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	currentContext := fmt.Sprintf("%s@%s", username, defaultClusterName)
 	config := clientcmdapi.Config{
-		Kind:        configMapKind,
-		APIVersion:  configMapAPIVersion,
+		Kind:        kubeconfigKind,
+		APIVersion:  kubeconfigAPIVersion,
 		Preferences: clientcmdapi.Preferences{},
 		Clusters: map[string]*clientcmdapi.Cluster{defaultClusterName: {
 			Server:                   o.config.Host,
@@ -101,56 +224,17 @@ func (o *operator) CreateKubeConfig(user *iamv1alpha2.User) error {
 		}},
 		Contexts: map[string]*clientcmdapi.Context{currentContext: {
 			Cluster:   defaultClusterName,
-			AuthInfo:  user.Name,
+			AuthInfo:  username,
 			Namespace: defaultNamespace,
 		}},
 		CurrentContext: currentContext,
 	}
 
-	kubeconfig, err := clientcmd.Write(config)
-	if err != nil {
-		klog.Error(err)
-		return err
-	}
-
-	// update configmap if it already exist.
-	if cm != nil {
-		cm.Data = map[string]string{kubeconfigFileName: string(kubeconfig)}
-		if _, err = o.k8sClient.CoreV1().ConfigMaps(constants.AIScopeControlNamespace).Update(context.Background(), cm, metav1.UpdateOptions{}); err != nil {
-			klog.Errorln(err)
-			return err
-		}
-		return nil
-	}
-
-	// create a new config
-	cm = &corev1.ConfigMap{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       configMapKind,
-			APIVersion: configMapAPIVersion,
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:   configName,
-			Labels: map[string]string{constants.UsernameLabelKey: user.Name},
-		},
-		Data: map[string]string{kubeconfigFileName: string(kubeconfig)},
-	}
-
-	if err = controllerutil.SetControllerReference(user, cm, scheme.Scheme); err != nil {
-		klog.Errorln(err)
-		return err
-	}
-
-	if _, err = o.k8sClient.CoreV1().ConfigMaps(constants.AIScopeControlNamespace).Create(context.Background(), cm, metav1.CreateOptions{}); err != nil {
-		klog.Errorln(err)
-		return err
-	}
-
-	return nil
+	return clientcmd.Write(config)
 }
 
-
-func (o *operator) createCSR(username string) error {
+// newCSR returns a CertificateSigningRequest of the client certificate for the specified user and its private key
+func newCSR(username string) (*certificatesv1.CertificateSigningRequest, []byte, error) {
 	csrConfig := &certutil.Config{
 		CommonName:   username,
 		Organization: nil,
@@ -160,29 +244,23 @@ func (o *operator) createCSR(username string) error {
 
 	x509csr, x509key, err := pkiutil.NewCSRAndKey(csrConfig)
 	if err != nil {
-		klog.Errorln(err)
-		return err
+		return nil, nil, err
 	}
 
 	var csrBuffer, keyBuffer bytes.Buffer
 	if err = pem.Encode(&keyBuffer, &pem.Block{Type: "PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(x509key)}); err != nil {
-		klog.Errorln(err)
-		return err
+		return nil, nil, err
 	}
 
 	var csrBytes []byte
 	if csrBytes, err = x509.CreateCertificateRequest(rand.Reader, x509csr, x509key); err != nil {
-		klog.Errorln(err)
-		return err
+		return nil, nil, err
 	}
 
 	if err = pem.Encode(&csrBuffer, &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrBytes}); err != nil {
-		klog.Errorln(err)
-		return err
+		return nil, nil, err
 	}
 
-	csr := csrBuffer.Bytes()
-	key := keyBuffer.Bytes()
 	csrName := fmt.Sprintf("%s-csr-%d", username, time.Now().Unix())
 	k8sCSR := &certificatesv1.CertificateSigningRequest{
 		TypeMeta: metav1.TypeMeta{
@@ -190,12 +268,11 @@ func (o *operator) createCSR(username string) error {
 			APIVersion: "certificates.k8s.io/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        csrName,
-			Labels:      map[string]string{constants.UsernameLabelKey: username},
-			Annotations: map[string]string{privateKeyAnnotation: string(key)},
+			Name:   csrName,
+			Labels: map[string]string{constants.UsernameLabelKey: username},
 		},
 		Spec: certificatesv1.CertificateSigningRequestSpec{
-			Request:    csr,
+			Request:    csrBuffer.Bytes(),
 			SignerName: certificatesv1.KubeAPIServerClientSignerName,
 			Usages:     []certificatesv1.KeyUsage{certificatesv1.UsageKeyEncipherment, certificatesv1.UsageClientAuth, certificatesv1.UsageDigitalSignature},
 			Username:   username,
@@ -203,19 +280,12 @@ func (o *operator) createCSR(username string) error {
 		},
 	}
 
-	// create csr
-	if _, err = o.k8sClient.CertificatesV1().CertificateSigningRequests().Create(context.Background(), k8sCSR, metav1.CreateOptions{}); err != nil {
-		klog.Errorln(err)
-		return err
-	}
-
-	return nil
+	return k8sCSR, keyBuffer.Bytes(), nil
 }
 
 // isExpired returns whether the client certificate in kubeconfig is expired
-func isExpired(cm *corev1.ConfigMap, username string) bool {
-	data := []byte(cm.Data[kubeconfigFileName])
-	kubeconfig, err := clientcmd.Load(data)
+func isExpired(secret *corev1.Secret, username string) bool {
+	kubeconfig, err := clientcmd.Load(secret.Data[kubeconfigFileName])
 	if err != nil {
 		klog.Errorln(err)
 		return true
@@ -240,15 +310,31 @@ func isExpired(cm *corev1.ConfigMap, username string) bool {
 
 // UpdateKubeconfig Update client key and client certificate after CertificateSigningRequest has been approved
 func (o *operator) UpdateKubeconfig(username string, csr *certificatesv1.CertificateSigningRequest) error {
-	configName := fmt.Sprintf(kubeconfigNameFormat, username)
-	configMap, err := o.k8sClient.CoreV1().ConfigMaps(constants.AIScopeControlNamespace).Get(context.Background(), configName, metav1.GetOptions{})
+	secretName := fmt.Sprintf(kubeconfigNameFormat, username)
+	secret, err := o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Get(context.Background(), secretName, metav1.GetOptions{})
 	if err != nil {
 		klog.Errorln(err)
 		return err
 	}
 
-	configMap = applyCert(configMap, csr)
-	_, err = o.k8sClient.CoreV1().ConfigMaps(constants.AIScopeControlNamespace).Update(context.Background(), configMap, metav1.UpdateOptions{})
+	privateKey := secret.Data[privateKeyFileName]
+	if secret.Annotations[csrAnnotation] != csr.Name {
+		if legacy := csr.Annotations[privateKeyAnnotation]; legacy != "" {
+			privateKey = []byte(legacy)
+		} else {
+			// superseded by a newer request, the private key is gone
+			klog.V(4).Infof("csr %s of user %s is superseded, ignore", csr.Name, username)
+			return nil
+		}
+	}
+
+	secret, err = applyCert(secret, username, privateKey, csr.Status.Certificate)
+	if err != nil {
+		klog.Errorln(err)
+		return err
+	}
+
+	_, err = o.k8sClient.CoreV1().Secrets(constants.AIScopeControlNamespace).Update(context.Background(), secret, metav1.UpdateOptions{})
 	if err != nil {
 		klog.Errorln(err)
 		return err
@@ -256,40 +342,27 @@ func (o *operator) UpdateKubeconfig(username string, csr *certificatesv1.Certifi
 	return nil
 }
 
-
-func applyCert(cm *corev1.ConfigMap, csr *certificatesv1.CertificateSigningRequest) *corev1.ConfigMap {
-	data := []byte(cm.Data[kubeconfigFileName])
-	kubeconfig, err := clientcmd.Load(data)
+func applyCert(secret *corev1.Secret, username string, privateKey, clientCert []byte) (*corev1.Secret, error) {
+	kubeconfig, err := clientcmd.Load(secret.Data[kubeconfigFileName])
 	if err != nil {
-		klog.Error(err)
-		return cm
+		return nil, err
 	}
 
-	username := getControlledUsername(cm)
-	privateKey := csr.Annotations[privateKeyAnnotation]
-	clientCert := csr.Status.Certificate
 	kubeconfig.AuthInfos = map[string]*clientcmdapi.AuthInfo{
 		username: {
-			ClientKeyData:         []byte(privateKey),
+			ClientKeyData:         privateKey,
 			ClientCertificateData: clientCert,
 		},
 	}
 
-	data, err = clientcmd.Write(*kubeconfig)
+	data, err := clientcmd.Write(*kubeconfig)
 	if err != nil {
-		klog.Error(err)
-		return cm
+		return nil, err
 	}
 
-	cm.Data[kubeconfigFileName] = string(data)
-	return cm
-}
-
-func getControlledUsername(cm *corev1.ConfigMap) string {
-	for _, ownerReference := range cm.OwnerReferences {
-		if ownerReference.Kind == iamv1alpha2.ResourceKindUser {
-			return ownerReference.Name
-		}
-	}
-	return ""
+	secret = secret.DeepCopy()
+	secret.Data[kubeconfigFileName] = data
+	delete(secret.Data, privateKeyFileName)
+	delete(secret.Annotations, csrAnnotation)
+	return secret, nil
 }
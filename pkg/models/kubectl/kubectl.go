@@ -19,6 +19,7 @@ import (
 
 	iamv1alpha2informers "aiscope/pkg/client/informers/externalversions/iam/v1alpha2"
 	"aiscope/pkg/models"
+	"aiscope/pkg/models/kubeconfig"
 
 	"aiscope/pkg/constants"
 )
@@ -26,6 +27,7 @@ import (
 const (
 	namespace        = constants.AIScopeControlNamespace
 	deployNameFormat = "kubectl-%s"
+	kubeconfigPath   = "/etc/aiscope/kubeconfig"
 )
 
 type Interface interface {
@@ -109,6 +111,7 @@ func (o *operator) CreateKubectlDeploy(username string, owner metav1.Object) err
 	}
 
 	replica := int32(1)
+	automountServiceAccountToken := false
 	selector := metav1.LabelSelector{MatchLabels: map[string]string{constants.UsernameLabelKey: username}}
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -128,16 +131,42 @@ func (o *operator) CreateKubectlDeploy(username string, owner metav1.Object) err
 						{
 							Name:  "kubectl",
 							Image: o.kubectlImage,
+							// kubectl runs with the credential of the user instead of a service account
+							Env: []v1.EnvVar{
+								{
+									Name:  "KUBECONFIG",
+									Value: kubeconfigPath + "/" + kubeconfig.FileName,
+								},
+							},
 							VolumeMounts: []v1.VolumeMount{
 								{
 									Name:      "host-time",
 									MountPath: "/etc/localtime",
 								},
+								{
+									Name:      "kubeconfig",
+									MountPath: kubeconfigPath,
+									ReadOnly:  true,
+								},
 							},
 						},
 					},
-					ServiceAccountName: "aiscope-cluster-admin",
+					AutomountServiceAccountToken: &automountServiceAccountToken,
 					Volumes: []v1.Volume{
+						{
+							Name: "kubeconfig",
+							VolumeSource: v1.VolumeSource{
+								Secret: &v1.SecretVolumeSource{
+									SecretName: kubeconfig.SecretName(username),
+									Items: []v1.KeyToPath{
+										{
+											Key:  kubeconfig.FileName,
+											Path: kubeconfig.FileName,
+										},
+									},
+								},
+							},
+						},
 						{
 							Name: "host-time",
 							VolumeSource: v1.VolumeSource{
@@ -160,10 +189,25 @@ func (o *operator) CreateKubectlDeploy(username string, owner metav1.Object) err
 	}
 
 	_, err = o.k8sClient.AppsV1().Deployments(namespace).Create(context.Background(), deployment, metav1.CreateOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		klog.Error(err)
+		return err
+	}
+
+	// kubectl pods used to run with a cluster admin service account, switch them to the credential of the user
+	existing, err := o.k8sClient.AppsV1().Deployments(namespace).Get(context.Background(), deployName, metav1.GetOptions{})
 	if err != nil {
-		if errors.IsAlreadyExists(err) {
-			return nil
-		}
+		klog.Error(err)
+		return err
+	}
+	if automount := existing.Spec.Template.Spec.AutomountServiceAccountToken; automount != nil && !*automount {
+		return nil
+	}
+	existing.Spec.Template = deployment.Spec.Template
+	if _, err = o.k8sClient.AppsV1().Deployments(namespace).Update(context.Background(), existing, metav1.UpdateOptions{}); err != nil {
 		klog.Error(err)
 		return err
 	}
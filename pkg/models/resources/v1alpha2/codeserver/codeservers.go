@@ -0,0 +1,57 @@
+package codeserver
+
+import (
+	"aiscope/pkg/api"
+	experimentv1alpha2 "aiscope/pkg/apis/experiment/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	informers "aiscope/pkg/client/informers/externalversions"
+	"aiscope/pkg/models/resources/v1alpha2"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type codeserverGetter struct {
+	sharedInformers informers.SharedInformerFactory
+}
+
+func New(sharedInformers informers.SharedInformerFactory) v1alpha2.Interface {
+	return &codeserverGetter{sharedInformers: sharedInformers}
+}
+
+func (g *codeserverGetter) Get(namespace, name string) (runtime.Object, error) {
+	return g.sharedInformers.Experiment().V1alpha2().CodeServers().Lister().CodeServers(namespace).Get(name)
+}
+
+func (g *codeserverGetter) List(namespace string, query *query.Query) (*api.ListResult, error) {
+	codeservers, err := g.sharedInformers.Experiment().V1alpha2().CodeServers().Lister().CodeServers(namespace).List(query.Selector())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []runtime.Object
+	for _, cs := range codeservers {
+		result = append(result, cs)
+	}
+	return v1alpha2.DefaultList(result, query, g.compare, g.filter), nil
+}
+
+func (g *codeserverGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
+	leftCodeServer, ok := left.(*experimentv1alpha2.CodeServer)
+	if !ok {
+		return false
+	}
+	rightCodeServer, ok := right.(*experimentv1alpha2.CodeServer)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaCompare(leftCodeServer.ObjectMeta, rightCodeServer.ObjectMeta, field)
+}
+
+func (g *codeserverGetter) filter(object runtime.Object, filter query.Filter) bool {
+	codeserver, ok := object.(*experimentv1alpha2.CodeServer)
+
+	if !ok {
+		return false
+	}
+
+	return v1alpha2.DefaultObjectMetaFilter(codeserver.ObjectMeta, filter)
+}
@@ -0,0 +1,55 @@
+package group
+
+import (
+	"aiscope/pkg/api"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	informers "aiscope/pkg/client/informers/externalversions"
+	"aiscope/pkg/models/resources/v1alpha2"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type groupsGetter struct {
+	sharedInformers informers.SharedInformerFactory
+}
+
+func New(sharedInformers informers.SharedInformerFactory) v1alpha2.Interface {
+	return &groupsGetter{sharedInformers: sharedInformers}
+}
+
+func (g *groupsGetter) Get(_, name string) (runtime.Object, error) {
+	return g.sharedInformers.Iam().V1alpha2().Groups().Lister().Get(name)
+}
+
+func (g *groupsGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
+	groups, err := g.sharedInformers.Iam().V1alpha2().Groups().Lister().List(query.Selector())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []runtime.Object
+	for _, group := range groups {
+		result = append(result, group)
+	}
+	return v1alpha2.DefaultList(result, query, g.compare, g.filter), nil
+}
+
+func (g *groupsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
+	leftGroup, ok := left.(*iamv1alpha2.Group)
+	if !ok {
+		return false
+	}
+	rightGroup, ok := right.(*iamv1alpha2.Group)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaCompare(leftGroup.ObjectMeta, rightGroup.ObjectMeta, field)
+}
+
+func (g *groupsGetter) filter(object runtime.Object, filter query.Filter) bool {
+	group, ok := object.(*iamv1alpha2.Group)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaFilter(group.ObjectMeta, filter)
+}
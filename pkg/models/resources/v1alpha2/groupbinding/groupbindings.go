@@ -0,0 +1,55 @@
+package groupbinding
+
+import (
+	"aiscope/pkg/api"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	informers "aiscope/pkg/client/informers/externalversions"
+	"aiscope/pkg/models/resources/v1alpha2"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type groupBindingsGetter struct {
+	sharedInformers informers.SharedInformerFactory
+}
+
+func New(sharedInformers informers.SharedInformerFactory) v1alpha2.Interface {
+	return &groupBindingsGetter{sharedInformers: sharedInformers}
+}
+
+func (g *groupBindingsGetter) Get(_, name string) (runtime.Object, error) {
+	return g.sharedInformers.Iam().V1alpha2().GroupBindings().Lister().Get(name)
+}
+
+func (g *groupBindingsGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
+	groupBindings, err := g.sharedInformers.Iam().V1alpha2().GroupBindings().Lister().List(query.Selector())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []runtime.Object
+	for _, groupBinding := range groupBindings {
+		result = append(result, groupBinding)
+	}
+	return v1alpha2.DefaultList(result, query, g.compare, g.filter), nil
+}
+
+func (g *groupBindingsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
+	leftGroupBinding, ok := left.(*iamv1alpha2.GroupBinding)
+	if !ok {
+		return false
+	}
+	rightGroupBinding, ok := right.(*iamv1alpha2.GroupBinding)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaCompare(leftGroupBinding.ObjectMeta, rightGroupBinding.ObjectMeta, field)
+}
+
+func (g *groupBindingsGetter) filter(object runtime.Object, filter query.Filter) bool {
+	groupBinding, ok := object.(*iamv1alpha2.GroupBinding)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaFilter(groupBinding.ObjectMeta, filter)
+}
@@ -3,11 +3,20 @@ package resource
 import (
 	"aiscope/pkg/api"
 	experimentv1alpha2 "aiscope/pkg/apis/experiment/v1alpha2"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
 	"aiscope/pkg/apiserver/query"
 	"aiscope/pkg/informers"
 	"aiscope/pkg/models/resources/v1alpha2"
+	"aiscope/pkg/models/resources/v1alpha2/codeserver"
+	"aiscope/pkg/models/resources/v1alpha2/group"
+	"aiscope/pkg/models/resources/v1alpha2/groupbinding"
 	"aiscope/pkg/models/resources/v1alpha2/namespace"
+	"aiscope/pkg/models/resources/v1alpha2/rolebinding"
 	"aiscope/pkg/models/resources/v1alpha2/trackingserver"
+	"aiscope/pkg/models/resources/v1alpha2/workspace"
+	"aiscope/pkg/models/resources/v1alpha2/workspacerole"
+	"aiscope/pkg/models/resources/v1alpha2/workspacerolebinding"
 	"aiscope/pkg/server/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -25,7 +34,14 @@ func NewResourceGetter(factory informers.InformerFactory) *ResourceGetter {
 	clusterResourceGetters := make(map[schema.GroupVersionResource]v1alpha2.Interface)
 
 	clusterResourceGetters[schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}] = namespace.New(factory.KubernetesSharedInformerFactory())
+	clusterResourceGetters[tenantv1alpha2.SchemeGroupVersion.WithResource(tenantv1alpha2.ResourcePluralWorkspace)] = workspace.New(factory.AIScopeSharedInformerFactory())
+	clusterResourceGetters[iamv1alpha2.SchemeGroupVersion.WithResource(iamv1alpha2.ResourcesPluralWorkspaceRole)] = workspacerole.New(factory.AIScopeSharedInformerFactory())
+	clusterResourceGetters[iamv1alpha2.SchemeGroupVersion.WithResource(iamv1alpha2.ResourcesPluralWorkspaceRoleBinding)] = workspacerolebinding.New(factory.AIScopeSharedInformerFactory())
+	clusterResourceGetters[iamv1alpha2.SchemeGroupVersion.WithResource(iamv1alpha2.ResourcePluralGroup)] = group.New(factory.AIScopeSharedInformerFactory())
+	clusterResourceGetters[iamv1alpha2.SchemeGroupVersion.WithResource(iamv1alpha2.ResourcePluralGroupBinding)] = groupbinding.New(factory.AIScopeSharedInformerFactory())
+	namespacedResourceGetters[schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}] = rolebinding.New(factory.KubernetesSharedInformerFactory())
 	namespacedResourceGetters[experimentv1alpha2.SchemeGroupVersion.WithResource(experimentv1alpha2.ResourcePluralTrackingServer)] = trackingserver.New(factory.AIScopeSharedInformerFactory())
+	namespacedResourceGetters[experimentv1alpha2.SchemeGroupVersion.WithResource(experimentv1alpha2.ResourcePluralCodeServer)] = codeserver.New(factory.AIScopeSharedInformerFactory())
 
 	return &ResourceGetter{
 		namespacedResourceGetters: namespacedResourceGetters,
@@ -0,0 +1,55 @@
+package rolebinding
+
+import (
+	"aiscope/pkg/api"
+	"aiscope/pkg/apiserver/query"
+	"aiscope/pkg/models/resources/v1alpha2"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+)
+
+type roleBindingsGetter struct {
+	sharedInformers informers.SharedInformerFactory
+}
+
+func New(sharedInformers informers.SharedInformerFactory) v1alpha2.Interface {
+	return &roleBindingsGetter{sharedInformers: sharedInformers}
+}
+
+func (g *roleBindingsGetter) Get(namespace, name string) (runtime.Object, error) {
+	return g.sharedInformers.Rbac().V1().RoleBindings().Lister().RoleBindings(namespace).Get(name)
+}
+
+func (g *roleBindingsGetter) List(namespace string, query *query.Query) (*api.ListResult, error) {
+	roleBindings, err := g.sharedInformers.Rbac().V1().RoleBindings().Lister().RoleBindings(namespace).List(query.Selector())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []runtime.Object
+	for _, roleBinding := range roleBindings {
+		result = append(result, roleBinding)
+	}
+	return v1alpha2.DefaultList(result, query, g.compare, g.filter), nil
+}
+
+func (g *roleBindingsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
+	leftRoleBinding, ok := left.(*rbacv1.RoleBinding)
+	if !ok {
+		return false
+	}
+	rightRoleBinding, ok := right.(*rbacv1.RoleBinding)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaCompare(leftRoleBinding.ObjectMeta, rightRoleBinding.ObjectMeta, field)
+}
+
+func (g *roleBindingsGetter) filter(object runtime.Object, filter query.Filter) bool {
+	roleBinding, ok := object.(*rbacv1.RoleBinding)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaFilter(roleBinding.ObjectMeta, filter)
+}
@@ -0,0 +1,55 @@
+package workspace
+
+import (
+	"aiscope/pkg/api"
+	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	informers "aiscope/pkg/client/informers/externalversions"
+	"aiscope/pkg/models/resources/v1alpha2"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type workspaceGetter struct {
+	sharedInformers informers.SharedInformerFactory
+}
+
+func New(sharedInformers informers.SharedInformerFactory) v1alpha2.Interface {
+	return &workspaceGetter{sharedInformers: sharedInformers}
+}
+
+func (g *workspaceGetter) Get(_, name string) (runtime.Object, error) {
+	return g.sharedInformers.Tenant().V1alpha2().Workspaces().Lister().Get(name)
+}
+
+func (g *workspaceGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
+	workspaces, err := g.sharedInformers.Tenant().V1alpha2().Workspaces().Lister().List(query.Selector())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []runtime.Object
+	for _, workspace := range workspaces {
+		result = append(result, workspace)
+	}
+	return v1alpha2.DefaultList(result, query, g.compare, g.filter), nil
+}
+
+func (g *workspaceGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
+	leftWorkspace, ok := left.(*tenantv1alpha2.Workspace)
+	if !ok {
+		return false
+	}
+	rightWorkspace, ok := right.(*tenantv1alpha2.Workspace)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaCompare(leftWorkspace.ObjectMeta, rightWorkspace.ObjectMeta, field)
+}
+
+func (g *workspaceGetter) filter(object runtime.Object, filter query.Filter) bool {
+	workspace, ok := object.(*tenantv1alpha2.Workspace)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaFilter(workspace.ObjectMeta, filter)
+}
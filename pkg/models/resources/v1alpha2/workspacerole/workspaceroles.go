@@ -0,0 +1,55 @@
+package workspacerole
+
+import (
+	"aiscope/pkg/api"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	informers "aiscope/pkg/client/informers/externalversions"
+	"aiscope/pkg/models/resources/v1alpha2"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type workspaceRolesGetter struct {
+	sharedInformers informers.SharedInformerFactory
+}
+
+func New(sharedInformers informers.SharedInformerFactory) v1alpha2.Interface {
+	return &workspaceRolesGetter{sharedInformers: sharedInformers}
+}
+
+func (g *workspaceRolesGetter) Get(_, name string) (runtime.Object, error) {
+	return g.sharedInformers.Iam().V1alpha2().WorkspaceRoles().Lister().Get(name)
+}
+
+func (g *workspaceRolesGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
+	roles, err := g.sharedInformers.Iam().V1alpha2().WorkspaceRoles().Lister().List(query.Selector())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []runtime.Object
+	for _, role := range roles {
+		result = append(result, role)
+	}
+	return v1alpha2.DefaultList(result, query, g.compare, g.filter), nil
+}
+
+func (g *workspaceRolesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
+	leftRole, ok := left.(*iamv1alpha2.WorkspaceRole)
+	if !ok {
+		return false
+	}
+	rightRole, ok := right.(*iamv1alpha2.WorkspaceRole)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaCompare(leftRole.ObjectMeta, rightRole.ObjectMeta, field)
+}
+
+func (g *workspaceRolesGetter) filter(object runtime.Object, filter query.Filter) bool {
+	role, ok := object.(*iamv1alpha2.WorkspaceRole)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaFilter(role.ObjectMeta, filter)
+}
@@ -0,0 +1,55 @@
+package workspacerolebinding
+
+import (
+	"aiscope/pkg/api"
+	iamv1alpha2 "aiscope/pkg/apis/iam/v1alpha2"
+	"aiscope/pkg/apiserver/query"
+	informers "aiscope/pkg/client/informers/externalversions"
+	"aiscope/pkg/models/resources/v1alpha2"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type workspaceRoleBindingsGetter struct {
+	sharedInformers informers.SharedInformerFactory
+}
+
+func New(sharedInformers informers.SharedInformerFactory) v1alpha2.Interface {
+	return &workspaceRoleBindingsGetter{sharedInformers: sharedInformers}
+}
+
+func (g *workspaceRoleBindingsGetter) Get(_, name string) (runtime.Object, error) {
+	return g.sharedInformers.Iam().V1alpha2().WorkspaceRoleBindings().Lister().Get(name)
+}
+
+func (g *workspaceRoleBindingsGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
+	roleBindings, err := g.sharedInformers.Iam().V1alpha2().WorkspaceRoleBindings().Lister().List(query.Selector())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []runtime.Object
+	for _, roleBinding := range roleBindings {
+		result = append(result, roleBinding)
+	}
+	return v1alpha2.DefaultList(result, query, g.compare, g.filter), nil
+}
+
+func (g *workspaceRoleBindingsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
+	leftRoleBinding, ok := left.(*iamv1alpha2.WorkspaceRoleBinding)
+	if !ok {
+		return false
+	}
+	rightRoleBinding, ok := right.(*iamv1alpha2.WorkspaceRoleBinding)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaCompare(leftRoleBinding.ObjectMeta, rightRoleBinding.ObjectMeta, field)
+}
+
+func (g *workspaceRoleBindingsGetter) filter(object runtime.Object, filter query.Filter) bool {
+	roleBinding, ok := object.(*iamv1alpha2.WorkspaceRoleBinding)
+	if !ok {
+		return false
+	}
+	return v1alpha2.DefaultObjectMetaFilter(roleBinding.ObjectMeta, filter)
+}
@@ -3,13 +3,24 @@ package tenant
 import (
 	"aiscope/pkg/api"
 	tenantv1alpha2 "aiscope/pkg/apis/tenant/v1alpha2"
+	"aiscope/pkg/apiserver/authorization/authorizer"
 	"aiscope/pkg/apiserver/query"
+	"aiscope/pkg/apiserver/request"
 	aiscope "aiscope/pkg/client/clientset/versioned"
+	"aiscope/pkg/informers"
+	"aiscope/pkg/models/iam/am"
+	resources "aiscope/pkg/models/resources/v1alpha2"
 	resourcev1alpha2 "aiscope/pkg/models/resources/v1alpha2/resource"
+	"aiscope/pkg/utils/sliceutil"
 	"context"
+	"encoding/json"
+	"fmt"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -17,6 +28,11 @@ import (
 
 type Interface interface {
 	CreateWorkspace(workspace *tenantv1alpha2.Workspace) (*tenantv1alpha2.Workspace, error)
+	ListWorkspaces(user user.Info, queryParam *query.Query) (*api.ListResult, error)
+	DescribeWorkspace(workspace string) (*tenantv1alpha2.Workspace, error)
+	UpdateWorkspace(workspace *tenantv1alpha2.Workspace) (*tenantv1alpha2.Workspace, error)
+	PatchWorkspace(workspace *tenantv1alpha2.Workspace) (*tenantv1alpha2.Workspace, error)
+	DeleteWorkspace(workspace string) error
 	CreateNamespace(workspace string, namespace *corev1.Namespace) (*corev1.Namespace, error)
 	ListNamespaces(user user.Info, workspace string, queryParam *query.Query) (*api.ListResult, error)
 }
@@ -25,12 +41,17 @@ type tenantOperator struct {
 	aiClient            aiscope.Interface
 	k8sclient           kubernetes.Interface
 	resourceGetter      *resourcev1alpha2.ResourceGetter
+	am                  am.AccessManagementInterface
+	authorizer          authorizer.Authorizer
 }
 
-func NewOperator(aiClient aiscope.Interface, k8sclient kubernetes.Interface) Interface {
+func NewOperator(aiClient aiscope.Interface, k8sclient kubernetes.Interface, informers informers.InformerFactory, authorizer authorizer.Authorizer) Interface {
 	return &tenantOperator{
 		aiClient:           aiClient,
 		k8sclient:          k8sclient,
+		resourceGetter:     resourcev1alpha2.NewResourceGetter(informers),
+		am:                 am.NewReadOnlyOperator(informers),
+		authorizer:         authorizer,
 	}
 }
 
@@ -38,6 +59,96 @@ func (t *tenantOperator) CreateWorkspace(workspace *tenantv1alpha2.Workspace) (*
 	return t.aiClient.TenantV1alpha2().Workspaces().Create(context.Background(), workspace, metav1.CreateOptions{})
 }
 
+func (t *tenantOperator) ListWorkspaces(user user.Info, queryParam *query.Query) (*api.ListResult, error) {
+	// every authenticated user is allowed to reach the list route,
+	// only the permissions granted to the user itself allow listing all the workspaces
+	listWS := authorizer.AttributesRecord{
+		User:            withoutAllAuthenticated(user),
+		Verb:            "list",
+		APIGroup:        tenantv1alpha2.SchemeGroupVersion.Group,
+		Resource:        "workspaces",
+		ResourceRequest: true,
+		ResourceScope:   request.GlobalScope,
+	}
+
+	decision, _, err := t.authorizer.Authorize(listWS)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	// allowed to list all workspaces
+	if decision == authorizer.DecisionAllow {
+		result, err := t.resourceGetter.List(tenantv1alpha2.ResourcePluralWorkspace, "", queryParam)
+		if err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		return result, nil
+	}
+
+	// retrieving associated resources through workspace role binding
+	workspaceRoleBindings, err := t.am.ListWorkspaceRoleBindings(user.GetName(), user.GetGroups(), "")
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	selector := queryParam.Selector()
+	workspaces := make([]runtime.Object, 0)
+	for _, roleBinding := range workspaceRoleBindings {
+		workspaceName := roleBinding.Labels[tenantv1alpha2.WorkspaceLabel]
+		obj, err := t.resourceGetter.Get(tenantv1alpha2.ResourcePluralWorkspace, "", workspaceName)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				klog.Warningf("workspace role binding: %+v found but workspace not exist", roleBinding.Name)
+				continue
+			}
+			klog.Error(err)
+			return nil, err
+		}
+		workspace := obj.(*tenantv1alpha2.Workspace)
+		// label matching selector, remove duplicate entity
+		if selector.Matches(labels.Set(workspace.Labels)) && !contains(workspaces, workspace) {
+			workspaces = append(workspaces, workspace)
+		}
+	}
+
+	// use default pagination search logic
+	result := resources.DefaultList(workspaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
+		return resources.DefaultObjectMetaCompare(left.(*tenantv1alpha2.Workspace).ObjectMeta, right.(*tenantv1alpha2.Workspace).ObjectMeta, field)
+	}, func(object runtime.Object, filter query.Filter) bool {
+		return resources.DefaultObjectMetaFilter(object.(*tenantv1alpha2.Workspace).ObjectMeta, filter)
+	})
+
+	return result, nil
+}
+
+func (t *tenantOperator) DescribeWorkspace(workspace string) (*tenantv1alpha2.Workspace, error) {
+	obj, err := t.resourceGetter.Get(tenantv1alpha2.ResourcePluralWorkspace, "", workspace)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return obj.(*tenantv1alpha2.Workspace), nil
+}
+
+func (t *tenantOperator) UpdateWorkspace(workspace *tenantv1alpha2.Workspace) (*tenantv1alpha2.Workspace, error) {
+	return t.aiClient.TenantV1alpha2().Workspaces().Update(context.Background(), workspace, metav1.UpdateOptions{})
+}
+
+func (t *tenantOperator) PatchWorkspace(workspace *tenantv1alpha2.Workspace) (*tenantv1alpha2.Workspace, error) {
+	data, err := json.Marshal(workspace)
+	if err != nil {
+		return nil, err
+	}
+	return t.aiClient.TenantV1alpha2().Workspaces().Patch(context.Background(), workspace.Name, types.MergePatchType, data, metav1.PatchOptions{})
+}
+
+func (t *tenantOperator) DeleteWorkspace(workspace string) error {
+	return t.aiClient.TenantV1alpha2().Workspaces().Delete(context.Background(), workspace, metav1.DeleteOptions{})
+}
+
 func (t *tenantOperator) CreateNamespace(workspace string, namespace *corev1.Namespace) (*corev1.Namespace, error) {
 	return t.k8sclient.CoreV1().Namespaces().Create(context.Background(), labelNamespaceWithWorkspaceName(namespace, workspace), metav1.CreateOptions{})
 }
@@ -53,16 +164,98 @@ func labelNamespaceWithWorkspaceName(namespace *corev1.Namespace, workspaceName
 }
 
 func (t *tenantOperator) ListNamespaces(user user.Info, workspace string, queryParam *query.Query) (*api.ListResult, error) {
+	nsScope := request.ClusterScope
+	if workspace != "" {
+		nsScope = request.WorkspaceScope
+		// filter by workspace
+		queryParam.LabelSelector = withWorkspaceSelector(queryParam.LabelSelector, workspace)
+	}
+
+	listNSInWS := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            "list",
+		APIGroup:        tenantv1alpha2.SchemeGroupVersion.Group,
+		Workspace:       workspace,
+		Resource:        "namespaces",
+		ResourceRequest: true,
+		ResourceScope:   nsScope,
+	}
+
+	decision, _, err := t.authorizer.Authorize(listNSInWS)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
 	// allowed to list all namespaces in the specified scope
-	// if decision == authorizer.DecisionAllow {
+	if decision == authorizer.DecisionAllow {
 		result, err := t.resourceGetter.List("namespaces", "", queryParam)
 		if err != nil {
 			klog.Error(err)
 			return nil, err
 		}
 		return result, nil
-	// }
+	}
+
+	// retrieving associated resources through role binding
+	roleBindings, err := t.am.ListRoleBindings(user.GetName(), user.GetGroups(), "")
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	selector := queryParam.Selector()
+	namespaces := make([]runtime.Object, 0)
+	for _, roleBinding := range roleBindings {
+		obj, err := t.resourceGetter.Get("namespaces", "", roleBinding.Namespace)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			klog.Error(err)
+			return nil, err
+		}
+		namespace := obj.(*corev1.Namespace)
+		// label matching selector, remove duplicate entity
+		if selector.Matches(labels.Set(namespace.Labels)) && !contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	// use default pagination search logic
+	result := resources.DefaultList(namespaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
+		return resources.DefaultObjectMetaCompare(left.(*corev1.Namespace).ObjectMeta, right.(*corev1.Namespace).ObjectMeta, field)
+	}, func(object runtime.Object, filter query.Filter) bool {
+		namespace := object.(*corev1.Namespace)
+		if filter.Field == query.FieldStatus {
+			return string(namespace.Status.Phase) == string(filter.Value)
+		}
+		return resources.DefaultObjectMetaFilter(namespace.ObjectMeta, filter)
+	})
+
+	return result, nil
+}
 
+// withWorkspaceSelector narrows the label selector down to the namespaces of the workspace
+func withWorkspaceSelector(labelSelector string, workspace string) string {
+	workspaceSelector := fmt.Sprintf("%s=%s", tenantv1alpha2.WorkspaceLabel, workspace)
+	if labelSelector == "" {
+		return workspaceSelector
+	}
+	return labelSelector + "," + workspaceSelector
+}
+
+// withoutAllAuthenticated drops the group shared by all the authenticated users
+func withoutAllAuthenticated(info user.Info) user.Info {
+	return &user.DefaultInfo{
+		Name: info.GetName(),
+		UID:  info.GetUID(),
+		// RemoveString modifies the slice in place
+		Groups: sliceutil.RemoveString(append([]string(nil), info.GetGroups()...), func(group string) bool {
+			return group == user.AllAuthenticated
+		}),
+		Extra: info.GetExtra(),
+	}
 }
 
 func contains(objects []runtime.Object, object runtime.Object) bool {
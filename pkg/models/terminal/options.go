@@ -0,0 +1,29 @@
+package terminal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	// IdleTimeout closes the terminal session once no input is received from the client for the duration.
+	IdleTimeout time.Duration `json:"idleTimeout" yaml:"idleTimeout"`
+}
+
+func NewOptions() *Options {
+	return &Options{IdleTimeout: 30 * time.Minute}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet, s *Options) {
+	fs.DurationVar(&o.IdleTimeout, "terminal-idle-timeout", s.IdleTimeout, "Idle timeout of web terminal sessions.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+	if o.IdleTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("terminal idle timeout must be positive, got %s", o.IdleTimeout))
+	}
+	return errs
+}
@@ -0,0 +1,309 @@
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2listers "aiscope/pkg/client/listers/iam/v1alpha2"
+	"aiscope/pkg/models"
+	"aiscope/pkg/models/kubectl"
+)
+
+const (
+	// EndOfTransmission is sent to the process when the session is closed
+	EndOfTransmission = "\u0004"
+
+	writeWait         = 10 * time.Second
+	kubectlPodTimeout = 2 * time.Minute
+)
+
+var validShells = sets.NewString("bash", "sh")
+
+// PtyHandler is what remotecommand expects from a pty
+type PtyHandler interface {
+	io.Reader
+	io.Writer
+	remotecommand.TerminalSizeQueue
+}
+
+// Message is the messaging protocol between the web terminal and the server.
+//
+// OP      DIRECTION  FIELD(S) USED  DESCRIPTION
+// ---------------------------------------------------------------------
+// stdin   fe->be     Data           Keystrokes/paste buffer
+// resize  fe->be     Rows, Cols     New terminal size
+// stdout  be->fe     Data           Output from the process
+// toast   be->fe     Data           OOB message to be shown to the user
+type Message struct {
+	Op   string `json:"op"`
+	Data string `json:"data,omitempty"`
+	Rows uint16 `json:"rows,omitempty"`
+	Cols uint16 `json:"cols,omitempty"`
+}
+
+// Session implements PtyHandler over a websocket connection
+type Session struct {
+	conn        *websocket.Conn
+	idleTimeout time.Duration
+	sizeChan    chan remotecommand.TerminalSize
+	doneChan    chan struct{}
+	closeOnce   sync.Once
+	writeLock   sync.Mutex
+	// buffer holds the stdin not consumed by the last Read
+	buffer []byte
+}
+
+func newSession(conn *websocket.Conn, idleTimeout time.Duration) *Session {
+	return &Session{
+		conn:        conn,
+		idleTimeout: idleTimeout,
+		sizeChan:    make(chan remotecommand.TerminalSize),
+		doneChan:    make(chan struct{}),
+	}
+}
+
+// Next returns the new terminal size after the terminal has been resized, nil once the session is closed.
+func (t *Session) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-t.sizeChan:
+		return &size
+	case <-t.doneChan:
+		return nil
+	}
+}
+
+// Read reads the stdin from the websocket connection, the read fails once the client
+// stays idle longer than the idle timeout.
+func (t *Session) Read(p []byte) (int, error) {
+	for len(t.buffer) == 0 {
+		if err := t.conn.SetReadDeadline(time.Now().Add(t.idleTimeout)); err != nil {
+			return copy(p, EndOfTransmission), err
+		}
+		_, data, err := t.conn.ReadMessage()
+		if err != nil {
+			// send terminate signal to the process to avoid resource leak
+			return copy(p, EndOfTransmission), err
+		}
+
+		var msg Message
+		if err = json.Unmarshal(data, &msg); err != nil {
+			return copy(p, EndOfTransmission), err
+		}
+
+		switch msg.Op {
+		case "stdin":
+			t.buffer = []byte(msg.Data)
+		case "resize":
+			select {
+			case t.sizeChan <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}:
+			case <-t.doneChan:
+				return copy(p, EndOfTransmission), io.EOF
+			}
+		default:
+			return copy(p, EndOfTransmission), fmt.Errorf("unknown message type '%s'", msg.Op)
+		}
+	}
+
+	n := copy(p, t.buffer)
+	t.buffer = t.buffer[n:]
+	return n, nil
+}
+
+// Write writes the output of the process to the websocket connection
+func (t *Session) Write(p []byte) (int, error) {
+	if err := t.write(Message{Op: "stdout", Data: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Toast sends an OOB message to the client
+func (t *Session) Toast(p string) error {
+	return t.write(Message{Op: "toast", Data: p})
+}
+
+func (t *Session) write(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	if err = t.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	return t.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Close closes the session with the status and reason sent to the client
+func (t *Session) Close(code int, reason string) {
+	t.closeOnce.Do(func() {
+		close(t.doneChan)
+		t.writeLock.Lock()
+		defer t.writeLock.Unlock()
+		closeMessage := websocket.FormatCloseMessage(code, reason)
+		if err := t.conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(writeWait)); err != nil {
+			klog.V(4).Info(err)
+		}
+		t.conn.Close()
+	})
+}
+
+type Interface interface {
+	// HandleSession execs a shell in the container of the pod and streams the tty over the connection
+	HandleSession(shell, namespace, podName, containerName string, conn *websocket.Conn)
+	// HandleKubectlSession streams the tty of the kubectl pod of the user over the connection,
+	// the kubectl pod is created on demand.
+	HandleKubectlSession(username string, conn *websocket.Conn)
+}
+
+type terminaler struct {
+	client     kubernetes.Interface
+	config     *rest.Config
+	options    *Options
+	kubectl    kubectl.Interface
+	userLister iamv1alpha2listers.UserLister
+}
+
+func NewTerminaler(client kubernetes.Interface, config *rest.Config, options *Options, kubectl kubectl.Interface,
+	userLister iamv1alpha2listers.UserLister) Interface {
+	return &terminaler{client: client, config: config, options: options, kubectl: kubectl, userLister: userLister}
+}
+
+func (t *terminaler) HandleSession(shell, namespace, podName, containerName string, conn *websocket.Conn) {
+	session := newSession(conn, t.options.IdleTimeout)
+
+	pod, err := t.client.CoreV1().Pods(namespace).Get(context.Background(), podName, metav1.GetOptions{})
+	if err != nil {
+		klog.Warning(err)
+		session.Close(websocket.CloseInternalServerErr, err.Error())
+		return
+	}
+
+	if containerName == "" && len(pod.Spec.Containers) > 0 {
+		containerName = pod.Spec.Containers[0].Name
+	}
+
+	var shells []string
+	if validShells.Has(shell) {
+		shells = append(shells, shell)
+	}
+	for _, item := range validShells.List() {
+		if item != shell {
+			shells = append(shells, item)
+		}
+	}
+
+	for _, item := range shells {
+		err = t.startProcess(namespace, podName, containerName, []string{item}, session)
+		// the shell ran and exited, don't fall back to the next one
+		if _, exited := err.(exec.CodeExitError); err == nil || exited {
+			break
+		}
+		klog.V(4).Infof("exec %s in %s/%s failed: %v", item, namespace, podName, err)
+	}
+
+	if _, exited := err.(exec.CodeExitError); err != nil && !exited {
+		session.Close(websocket.CloseInternalServerErr, err.Error())
+		return
+	}
+
+	session.Close(websocket.CloseNormalClosure, "Process exited")
+}
+
+func (t *terminaler) HandleKubectlSession(username string, conn *websocket.Conn) {
+	session := newSession(conn, t.options.IdleTimeout)
+
+	podInfo, err := t.ensureKubectlPod(username, session)
+	if err != nil {
+		klog.Warning(err)
+		session.Close(websocket.CloseInternalServerErr, err.Error())
+		return
+	}
+
+	err = t.startProcess(podInfo.Namespace, podInfo.Pod, podInfo.Container, []string{"bash"}, session)
+	if _, exited := err.(exec.CodeExitError); err != nil && !exited {
+		session.Close(websocket.CloseInternalServerErr, err.Error())
+		return
+	}
+
+	session.Close(websocket.CloseNormalClosure, "Process exited")
+}
+
+func (t *terminaler) ensureKubectlPod(username string, session *Session) (models.PodInfo, error) {
+	if podInfo, err := t.kubectl.GetKubectlPod(username); err == nil {
+		return podInfo, nil
+	}
+
+	user, err := t.userLister.Get(username)
+	if err != nil {
+		return models.PodInfo{}, err
+	}
+
+	if err = t.kubectl.CreateKubectlDeploy(username, user); err != nil {
+		return models.PodInfo{}, err
+	}
+
+	if err = session.Toast("Starting the kubectl pod, please wait..."); err != nil {
+		return models.PodInfo{}, err
+	}
+
+	var podInfo models.PodInfo
+	err = wait.PollImmediate(time.Second, kubectlPodTimeout, func() (bool, error) {
+		podInfo, err = t.kubectl.GetKubectlPod(username)
+		return err == nil, nil
+	})
+	if err != nil {
+		return models.PodInfo{}, fmt.Errorf("kubectl pod of user %s is not ready: %v", username, err)
+	}
+	return podInfo, nil
+}
+
+// startProcess is called by handleAttach
+// Executed cmd in the container specified in request and connects it up with the ptyHandler (a session)
+func (t *terminaler) startProcess(namespace, podName, containerName string, cmd []string, ptyHandler PtyHandler) error {
+	req := t.client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec")
+
+	req.VersionedParams(&corev1.PodExecOptions{
+		Container: containerName,
+		Command:   cmd,
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       true,
+	}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(t.config, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	return executor.Stream(remotecommand.StreamOptions{
+		Stdin:             ptyHandler,
+		Stdout:            ptyHandler,
+		Stderr:            ptyHandler,
+		TerminalSizeQueue: ptyHandler,
+		Tty:               true,
+	})
+}
@@ -0,0 +1,51 @@
+package otputil
+
+import (
+	"testing"
+	"time"
+)
+
+// the SHA1 test vectors of https://datatracker.ietf.org/doc/html/rfc6238#appendix-B truncated to 6 digits
+func TestGenerateCode(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		time     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		code, err := GenerateCode(secret, TimeStep(time.Unix(test.time, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != test.expected {
+			t.Errorf("GenerateCode() at %d = %s, want %s", test.time, code, test.expected)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	previous, _ := GenerateCode(secret, TimeStep(now)-1)
+	if step, ok := Validate(secret, previous, now); !ok || step != TimeStep(now)-1 {
+		t.Errorf("the code of the previous time step should be accepted")
+	}
+	expired, _ := GenerateCode(secret, TimeStep(now)-2)
+	if _, ok := Validate(secret, expired, now); ok && expired != previous {
+		t.Errorf("the expired code should be rejected")
+	}
+	if _, ok := Validate(secret, "12345", now); ok {
+		t.Errorf("the malformed code should be rejected")
+	}
+}